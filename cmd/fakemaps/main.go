@@ -0,0 +1,106 @@
+// Command fakemaps runs a local stand-in for Google's tbm=map endpoint, place
+// detail pages, review listings and business websites with a synthetic
+// dataset, for developing and exercising geotap offline.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/rendis/geotap/internal/fakemaps"
+)
+
+func main() {
+	var (
+		addr          string
+		numPlaces     int
+		lat, lng      float64
+		radius        float64
+		categoriesStr string
+		seed          uint64
+		latency       time.Duration
+		jitter        time.Duration
+		faultRate     float64
+		failFirst     int
+		faultStr      string
+		sites         bool
+	)
+
+	fs := flag.NewFlagSet("fakemaps", flag.ExitOnError)
+	fs.StringVar(&addr, "addr", "127.0.0.1:8088", "Listen address")
+	fs.IntVar(&numPlaces, "places", 5000, "Number of synthetic places")
+	fs.Float64Var(&lat, "lat", 40.4168, "Dataset center latitude")
+	fs.Float64Var(&lng, "lng", -3.7038, "Dataset center longitude")
+	fs.Float64Var(&radius, "radius", 10, "Dataset half-width in km")
+	fs.StringVar(&categoriesStr, "categories", "Restaurant,Cafe,Bar,Pharmacy,Hotel", "Comma-separated place categories")
+	fs.Uint64Var(&seed, "seed", 1, "Dataset random seed")
+	fs.DurationVar(&latency, "latency", 0, "Delay added to every response")
+	fs.DurationVar(&jitter, "jitter", 0, "Random extra delay, up to this much")
+	fs.Float64Var(&faultRate, "fault-rate", 0, "Fraction of requests (0-1) answered with a fault")
+	fs.IntVar(&failFirst, "fail-first", 0, "Fail the first N requests")
+	fs.StringVar(&faultStr, "fault-status", "429", "Comma-separated fault statuses to rotate through (429, 403, 302)")
+	fs.BoolVar(&sites, "sites", false, "Point place websites at fake business sites served under /sites/ (for geotap contacts)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: fakemaps [flags]\n\nServe synthetic tbm=map results, place details and reviews on a local endpoint.\n\nFlags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  fakemaps -places 20000 -radius 5\n")
+		fmt.Fprintf(os.Stderr, "  fakemaps -latency 300ms -jitter 200ms -fault-rate 0.05 -fault-status 429,302\n")
+		fmt.Fprintf(os.Stderr, "  fakemaps -sites\n")
+		fmt.Fprintf(os.Stderr, "\nThen point a scan at it:\n")
+		fmt.Fprintf(os.Stderr, "  geotap scan -queries restaurants -lat 40.4168 -lng -3.7038 -radius 5 -endpoint http://127.0.0.1:8088/search -output ./projects\n")
+		fmt.Fprintf(os.Stderr, "  geotap enrich -db ./projects/geotap_....db -endpoint http://127.0.0.1:8088/maps\n")
+		fmt.Fprintf(os.Stderr, "  geotap reviews -db ./projects/geotap_....db -endpoint http://127.0.0.1:8088/maps/rpc/listugcposts\n")
+		fmt.Fprintf(os.Stderr, "  geotap contacts -db ./projects/geotap_....db  (scan with -sites)\n")
+	}
+	fs.Parse(os.Args[1:])
+
+	var statuses []int
+	for _, s := range strings.Split(faultStr, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid -fault-status %q\n", s)
+			os.Exit(1)
+		}
+		statuses = append(statuses, n)
+	}
+
+	latDeg := radius / 111.0
+	lngDeg := radius / (111.0 * math.Cos(lat*math.Pi/180))
+	bound := orb.Bound{
+		Min: orb.Point{lng - lngDeg, lat - latDeg},
+		Max: orb.Point{lng + lngDeg, lat + latDeg},
+	}
+
+	places := fakemaps.RandomPlaces(seed, numPlaces, bound, strings.Split(categoriesStr, ","))
+	if sites {
+		fakemaps.UseSites(places, "http://"+addr)
+	}
+
+	srv := fakemaps.New(fakemaps.Config{
+		Places:        places,
+		Latency:       latency,
+		Jitter:        jitter,
+		FaultRate:     faultRate,
+		FailFirst:     failFirst,
+		FaultStatuses: statuses,
+	})
+
+	fmt.Fprintf(os.Stderr, "fakemaps: %d places around %.4f, %.4f (±%.1fkm)\n", numPlaces, lat, lng, radius)
+	fmt.Fprintf(os.Stderr, "fakemaps: endpoint http://%s/search\n", addr)
+	fmt.Fprintf(os.Stderr, "fakemaps: details  http://%s/maps\n", addr)
+	fmt.Fprintf(os.Stderr, "fakemaps: reviews  http://%s/maps/rpc/listugcposts\n", addr)
+	if sites {
+		fmt.Fprintf(os.Stderr, "fakemaps: sites    http://%s/sites/\n", addr)
+	}
+	log.Fatal(http.ListenAndServe(addr, srv.Handler()))
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/crawler"
+	"github.com/rendis/geotap/internal/engine/storage"
+)
+
+func runContacts(args []string) error {
+	var dbPath string
+	var concurrency, maxPages, depth int
+	var timeout time.Duration
+	var refresh bool
+
+	fs := flag.NewFlagSet("contacts", flag.ExitOnError)
+	fs.StringVar(&dbPath, "db", "", "Path to .db file of a scan (required)")
+	fs.IntVar(&concurrency, "concurrency", 8, "Max websites crawled at once")
+	fs.IntVar(&maxPages, "max-pages", 5, "Max pages fetched per website, homepage included")
+	fs.IntVar(&depth, "depth", 1, "Link hops followed from the homepage (0 = homepage only)")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "Timeout per page request")
+	fs.BoolVar(&refresh, "refresh", false, "Crawl again the websites of places already done")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: geotap contacts [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Crawl the website of every place in a scan (homepage plus contact and about\n")
+		fmt.Fprintf(os.Stderr, "pages, honoring robots.txt) for emails, social profiles and phone numbers,\n")
+		fmt.Fprintf(os.Stderr, "into the contacts table. Re-running continues where it stopped.\n\nFlags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  geotap contacts -db ./projects/geotap_20260212_120000.db\n")
+		fmt.Fprintf(os.Stderr, "  geotap contacts -db data.db -concurrency 16 -max-pages 3\n")
+		fmt.Fprintf(os.Stderr, "  geotap contacts -db data.db -depth 0 -refresh\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("opening db: %w", err)
+	}
+	if concurrency <= 0 {
+		return fmt.Errorf("-concurrency must be > 0")
+	}
+	if maxPages <= 0 {
+		return fmt.Errorf("-max-pages must be > 0")
+	}
+	if depth < 0 {
+		return fmt.Errorf("-depth must be >= 0")
+	}
+	crawlDepth := depth
+	if crawlDepth == 0 {
+		crawlDepth = -1 // homepage only
+	}
+
+	store, err := storage.NewStore(dbPath)
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+	defer store.Close()
+
+	added, err := store.EnqueueContactJobs(refresh)
+	if err != nil {
+		return err
+	}
+	counts, err := store.ContactCounts()
+	if err != nil {
+		return err
+	}
+	if storage.Unfinished(counts) == 0 {
+		fmt.Fprintf(os.Stderr, "Nothing to crawl: all %d places with a website are done (use -refresh to crawl again)\n", counts[storage.JobDone])
+		return nil
+	}
+
+	// Append to the scan's log file
+	logPath := strings.TrimSuffix(dbPath, ".db") + ".log"
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log: %w", err)
+	}
+	defer logFile.Close()
+	logger := log.New(logFile, "", log.LstdFlags)
+	logger.Printf("=== Session contacts: new=%d pending=%d interrupted=%d failed=%d done=%d refresh=%v concurrency=%d max_pages=%d depth=%d ===",
+		added, counts[storage.JobPending], counts[storage.JobInterrupted], counts[storage.JobFailed], counts[storage.JobDone], refresh, concurrency, maxPages, depth)
+
+	fmt.Fprintf(os.Stderr, "Log: %s\n", logPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		fmt.Fprintln(os.Stderr, "\nShutting down gracefully...")
+		cancel()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Crawling websites: %d places (%d new), %d already done (concurrency=%d)\n",
+		storage.Unfinished(counts), added, counts[storage.JobDone], concurrency)
+
+	startTime := time.Now()
+	stats, err := crawler.Run(ctx, store, logger, &crawler.RunOptions{
+		Crawl: crawler.Options{
+			MaxPages: maxPages,
+			MaxDepth: crawlDepth,
+			Timeout:  timeout,
+		},
+		Concurrency: concurrency,
+	})
+	if err != nil && err != context.Canceled {
+		return fmt.Errorf("crawling websites: %w", err)
+	}
+	duration := time.Since(startTime).Truncate(time.Second)
+
+	logger.Printf("Done: pages=%d emails=%d socials=%d phones=%d errors=%d",
+		stats.Pages.Load(), stats.Emails.Load(), stats.Socials.Load(), stats.Phones.Load(), stats.Errors.Load())
+
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  GeoTap Contacts Complete\n")
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  Places:     %d\n", stats.PlacesTotal.Load())
+	fmt.Fprintf(os.Stderr, "  Pages:      %d\n", stats.Pages.Load())
+	fmt.Fprintf(os.Stderr, "  Emails:     %d\n", stats.Emails.Load())
+	fmt.Fprintf(os.Stderr, "  Socials:    %d\n", stats.Socials.Load())
+	fmt.Fprintf(os.Stderr, "  Phones:     %d\n", stats.Phones.Load())
+	fmt.Fprintf(os.Stderr, "  Errors:     %d\n", stats.Errors.Load())
+	if counts, err := store.ContactCounts(); err == nil {
+		if pending := storage.Unfinished(counts); pending > 0 {
+			fmt.Fprintf(os.Stderr, "  Unfinished: %d places (run 'geotap contacts -db %s' again)\n", pending, dbPath)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "  Duration:   %s\n", duration)
+	fmt.Fprintf(os.Stderr, "  Database:   %s\n", dbPath)
+	fmt.Fprintf(os.Stderr, "  Log:        %s\n", logPath)
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/rendis/geotap/internal/engine/storage"
+)
+
+func runDiff(args []string) error {
+	var dbPath string
+	var from, to int64
+	var list, asJSON bool
+
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	fs.StringVar(&dbPath, "db", "", "Path to .db file with two or more scans (required)")
+	fs.Int64Var(&from, "from", 0, "Earlier scan session ID (default: the scan before -to)")
+	fs.Int64Var(&to, "to", 0, "Later scan session ID (default: the latest scan)")
+	fs.BoolVar(&list, "list", false, "List the sessions of the project and exit")
+	fs.BoolVar(&asJSON, "json", false, "Print the report as JSON")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: geotap diff [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Report the places a rescan found new, no longer found, and changed. Append\n")
+		fmt.Fprintf(os.Stderr, "rescans to a project with 'geotap scan -db'.\n\nFlags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  geotap diff -db ./projects/geotap_20260212_120000.db\n")
+		fmt.Fprintf(os.Stderr, "  geotap diff -db data.db -list\n")
+		fmt.Fprintf(os.Stderr, "  geotap diff -db data.db -from 1 -to 4 -json > changes.json\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("opening db: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("opening db: %w", err)
+	}
+	defer db.Close()
+	if _, err := storage.Migrate(db); err != nil {
+		return err
+	}
+
+	sessions, err := storage.LoadSessions(db)
+	if err != nil {
+		return err
+	}
+	if list {
+		printSessions(sessions)
+		return nil
+	}
+
+	if from == 0 || to == 0 {
+		scans := storage.ScanSessions(sessions)
+		if to == 0 && len(scans) > 0 {
+			to = scans[len(scans)-1].ID
+		}
+		if from == 0 {
+			for _, s := range scans {
+				if s.ID < to {
+					from = s.ID
+				}
+			}
+		}
+		if from == 0 {
+			return fmt.Errorf("need two scans to compare, the project has %d (append one with 'geotap scan -db %s')", len(scans), dbPath)
+		}
+	}
+
+	d, err := storage.DiffSessions(db, from, to)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			From        int64               `json:"from"`
+			To          int64               `json:"to"`
+			New         []storage.DiffPlace `json:"new"`
+			Disappeared []storage.DiffPlace `json:"disappeared"`
+			Changed     []storage.DiffPlace `json:"changed"`
+		}{d.From.ID, d.To.ID, d.New, d.Disappeared, d.Changed})
+	}
+
+	fmt.Printf("Session %d (%s %s) → session %d (%s %s)\n",
+		d.From.ID, d.From.Kind, d.From.StartedAt.Local().Format("2006-01-02 15:04"),
+		d.To.ID, d.To.Kind, d.To.StartedAt.Local().Format("2006-01-02 15:04"))
+	fmt.Printf("  New:         %d\n", len(d.New))
+	fmt.Printf("  Disappeared: %d\n", len(d.Disappeared))
+	fmt.Printf("  Changed:     %d\n", len(d.Changed))
+
+	printDiffPlaces("New", "+", d.New)
+	printDiffPlaces("Disappeared", "-", d.Disappeared)
+	printDiffPlaces("Changed", "~", d.Changed)
+	return nil
+}
+
+func printDiffPlaces(title, mark string, places []storage.DiffPlace) {
+	if len(places) == 0 {
+		return
+	}
+	fmt.Printf("\n%s (%d)\n", title, len(places))
+	for _, p := range places {
+		line := fmt.Sprintf("  %s %s", mark, p.Name)
+		if p.Address != "" {
+			line += " — " + p.Address
+		}
+		fmt.Printf("%s  [%s]\n", line, p.CID)
+		for _, c := range p.Changes {
+			fmt.Printf("      %s: %s → %s\n", c.Field, diffValue(c.Old), diffValue(c.New))
+		}
+	}
+}
+
+// diffValue renders a changed field value on one line.
+func diffValue(v string) string {
+	if v == "" {
+		return "(empty)"
+	}
+	return truncateMiddle(v, 60)
+}
+
+func printSessions(sessions []storage.Session) {
+	if len(sessions) == 0 {
+		fmt.Println("No sessions recorded")
+		return
+	}
+	fmt.Printf("%4s  %-7s %-9s %-16s %-11s %8s %8s  %s\n", "ID", "KIND", "SOURCE", "STARTED", "STATUS", "FOUND", "NEW", "ORIGIN")
+	for _, s := range sessions {
+		fmt.Printf("%4d  %-7s %-9s %-16s %-11s %8d %8d  %s\n",
+			s.ID, s.Kind, s.Source, s.StartedAt.Local().Format("2006-01-02 15:04"), s.Status, s.Stats.Found, s.Stats.Stored, s.Origin)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/scraper"
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+)
+
+func runEnrich(args []string) error {
+	var dbPath, proxyURL, proxiesFile, endpoint string
+	var concurrency int
+
+	fs := flag.NewFlagSet("enrich", flag.ExitOnError)
+	fs.StringVar(&dbPath, "db", "", "Path to .db file of a scan (required)")
+	fs.IntVar(&concurrency, "concurrency", 0, "Max concurrent requests (default: the scan's value)")
+	fs.StringVar(&proxyURL, "proxy", "", "HTTP/SOCKS5 proxy URL (pass again when the scan used proxies)")
+	fs.StringVar(&proxiesFile, "proxies", "", "File with one HTTP/SOCKS5 proxy URL per line to rotate across")
+	fs.StringVar(&endpoint, "endpoint", "", "Override the place details URL, e.g. a local fakemaps server (testing)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: geotap enrich [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Fetch opening hours, popular times, attributes, business status and recent\n")
+		fmt.Fprintf(os.Stderr, "reviews for every place in a scan. Re-running continues where it stopped.\n\nFlags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  geotap enrich -db ./projects/geotap_20260212_120000.db\n")
+		fmt.Fprintf(os.Stderr, "  geotap enrich -db data.db -proxies proxies.txt -concurrency 50\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("opening db: %w", err)
+	}
+
+	store, err := storage.NewStore(dbPath)
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+	defer store.Close()
+
+	// Reuse the scan's language; databases from before resumable scans
+	// have no saved parameters and get the defaults. Proxies are not stored
+	// and come from the flags.
+	params, err := store.LoadParams()
+	if err != nil {
+		params = model.SearchParams{Lang: "en", Concurrency: 10}
+	}
+	params.DBPath = dbPath
+	if concurrency > 0 {
+		params.Concurrency = concurrency
+	}
+	if err := applyProxyFlags(&params, proxyURL, proxiesFile); err != nil {
+		return err
+	}
+	params.DetailsEndpoint = endpoint
+
+	added, err := store.EnqueueEnrichJobs()
+	if err != nil {
+		return err
+	}
+	counts, err := store.EnrichCounts()
+	if err != nil {
+		return err
+	}
+	if storage.Unfinished(counts) == 0 {
+		fmt.Fprintf(os.Stderr, "Nothing to enrich: all %d places are done\n", counts[storage.JobDone])
+		return nil
+	}
+
+	// Append to the scan's log file
+	logPath := strings.TrimSuffix(dbPath, ".db") + ".log"
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log: %w", err)
+	}
+	defer logFile.Close()
+	logger := log.New(logFile, "", log.LstdFlags)
+	logger.Printf("=== Session enrich: new=%d pending=%d interrupted=%d failed=%d done=%d concurrency=%d ===",
+		added, counts[storage.JobPending], counts[storage.JobInterrupted], counts[storage.JobFailed], counts[storage.JobDone], params.Concurrency)
+
+	fmt.Fprintf(os.Stderr, "Log: %s\n", logPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		fmt.Fprintln(os.Stderr, "\nShutting down gracefully...")
+		cancel()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Enriching: %d places (%d new), %d already done (concurrency=%d)\n",
+		storage.Unfinished(counts), added, counts[storage.JobDone], params.Concurrency)
+
+	startTime := time.Now()
+	stats, err := scraper.Enrich(ctx, params, store, logger, nil)
+	if err != nil && err != context.Canceled {
+		return fmt.Errorf("enriching: %w", err)
+	}
+	duration := time.Since(startTime).Truncate(time.Second)
+
+	logger.Printf("Done: enriched=%d reviews=%d errors=%d rate_limits=%d",
+		stats.Enriched.Load(), stats.Reviews.Load(), stats.Errors.Load(), stats.RateLimits.Load())
+
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  GeoTap Enrich Complete\n")
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  Places:     %d\n", stats.PlacesTotal.Load())
+	fmt.Fprintf(os.Stderr, "  Enriched:   %d\n", stats.Enriched.Load())
+	fmt.Fprintf(os.Stderr, "  Reviews:    %d new\n", stats.Reviews.Load())
+	fmt.Fprintf(os.Stderr, "  Errors:     %d\n", stats.Errors.Load())
+	if proxies := stats.Proxies(); len(proxies) > 0 {
+		fmt.Fprintf(os.Stderr, "  Proxies:    %d\n", len(proxies))
+		for _, p := range proxies {
+			fmt.Fprintf(os.Stderr, "    %-32s ok=%d fail=%d rl=%d benched=%d\n",
+				truncateMiddle(p.URL, 32), p.Successes.Load(), p.Failures.Load(), p.RateLimits.Load(), p.Benched.Load())
+		}
+	}
+	if counts, err := store.EnrichCounts(); err == nil {
+		if pending := storage.Unfinished(counts); pending > 0 {
+			fmt.Fprintf(os.Stderr, "  Unfinished: %d places (run 'geotap enrich -db %s' again)\n", pending, dbPath)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "  Duration:   %s\n", duration)
+	fmt.Fprintf(os.Stderr, "  Database:   %s\n", dbPath)
+	fmt.Fprintf(os.Stderr, "  Log:        %s\n", logPath)
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+
+	return nil
+}
@@ -1,33 +1,44 @@
 package main
 
 import (
-	"database/sql"
-	"encoding/csv"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
-	_ "modernc.org/sqlite"
-
+	"github.com/rendis/geotap/internal/engine/export"
 	"github.com/rendis/geotap/internal/model"
 )
 
 func runExport(args []string) error {
-	var dbPath, outputPath, format string
+	var dbPath, outputPath, format, compression, openAt string
+	var openNow bool
 
 	fs := flag.NewFlagSet("export", flag.ExitOnError)
 	fs.StringVar(&dbPath, "db", "", "Path to .db file (required)")
 	fs.StringVar(&outputPath, "output", "", "Output file path (default: same dir as db)")
-	fs.StringVar(&format, "format", "csv", "Export format: csv")
+	fs.StringVar(&format, "format", "csv", "Export format: "+strings.Join(export.Names(), ", "))
+	fs.StringVar(&compression, "compress", "", "Output compression: gzip, zstd (default: from -output extension; parquet compresses its pages)")
+	fs.BoolVar(&openNow, "open-now", false, "Only places open now, in their local time")
+	fs.StringVar(&openAt, "open-at", "", "Only places open at a time: \"21:30\" (today) or \"sat 21:30\"")
 
 	fs.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: geotap export [flags]\n\nFlags:\n")
+		fmt.Fprintf(os.Stderr, "Usage: geotap export [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Export the places of a scan as CSV, as GeoJSON, KML or GPX for GIS, mapping\n")
+		fmt.Fprintf(os.Stderr, "and GPS apps, or as JSON Lines or Parquet for data pipelines. Places are\n")
+		fmt.Fprintf(os.Stderr, "streamed one at a time, so memory stays flat on large scans.\n\nFlags:\n")
 		fs.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  geotap export -db ./projects/geotap_20260212.db\n")
 		fmt.Fprintf(os.Stderr, "  geotap export -db data.db -output results.csv\n")
+		fmt.Fprintf(os.Stderr, "  geotap export -db data.db -format geojson\n")
+		fmt.Fprintf(os.Stderr, "  geotap export -db data.db -format kml -output places.kml\n")
+		fmt.Fprintf(os.Stderr, "  geotap export -db data.db -format parquet\n")
+		fmt.Fprintf(os.Stderr, "  geotap export -db data.db -format jsonl -compress zstd\n")
+		fmt.Fprintf(os.Stderr, "  geotap export -db data.db -output results.csv.gz\n")
+		fmt.Fprintf(os.Stderr, "  geotap export -db data.db -open-at \"sun 13:00\"\n")
 	}
 
 	if err := fs.Parse(args); err != nil {
@@ -37,100 +48,73 @@ func runExport(args []string) error {
 	if dbPath == "" {
 		return fmt.Errorf("-db is required")
 	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("opening db: %w", err)
+	}
+	if err := export.Check(dbPath); err != nil {
+		return err
+	}
 
-	if format != "csv" {
-		return fmt.Errorf("unsupported format: %s (only csv supported)", format)
+	f, err := export.Lookup(format)
+	if err != nil {
+		return err
+	}
+	if openNow {
+		if openAt != "" {
+			return fmt.Errorf("-open-now and -open-at are mutually exclusive")
+		}
+		openAt = "now"
+	}
+	if openAt != "" {
+		if _, err := model.ParseOpenAt(openAt, time.Now()); err != nil {
+			return fmt.Errorf("invalid -open-at: %w", err)
+		}
+	}
+
+	if compression == "" && outputPath != "" {
+		compression = export.CompressionOf(outputPath)
 	}
 
 	// Default output path
 	if outputPath == "" {
 		dir := filepath.Dir(dbPath)
 		base := strings.TrimSuffix(filepath.Base(dbPath), ".db")
-		outputPath = filepath.Join(dir, base+".csv")
-	}
-
-	// Load businesses
-	businesses, err := loadFromDB(dbPath)
-	if err != nil {
-		return fmt.Errorf("loading db: %w", err)
+		outputPath = filepath.Join(dir, base+f.FileExt(compression))
 	}
 
-	if len(businesses) == 0 {
-		return fmt.Errorf("no businesses found in database")
-	}
-
-	// Export
-	f, err := os.Create(outputPath)
+	w, err := export.Create(outputPath, f, compression)
 	if err != nil {
-		return fmt.Errorf("creating output: %w", err)
+		return err
 	}
-	defer f.Close()
-
-	w := csv.NewWriter(f)
-	defer w.Flush()
 
-	w.Write([]string{
-		"name", "rating", "review_count", "category", "categories",
-		"address", "city", "postal_code", "country_code",
-		"lat", "lng", "phone", "website", "google_url",
-		"description", "price_range", "query",
+	// Stream places straight from the db to the file
+	now := time.Now()
+	var total, written int
+	err = export.Stream(dbPath, func(b *model.Business, tz string) error {
+		total++
+		if openAt != "" && !b.Hours.OpenAtSpec(openAt, now, tz) {
+			return nil
+		}
+		written++
+		return w.Write(b)
 	})
-
-	for _, b := range businesses {
-		w.Write([]string{
-			b.Name,
-			fmt.Sprintf("%.1f", b.Rating),
-			fmt.Sprintf("%d", b.ReviewCount),
-			b.Category,
-			b.Categories,
-			b.Address,
-			b.City,
-			b.PostalCode,
-			b.CountryCode,
-			fmt.Sprintf("%.6f", b.Lat),
-			fmt.Sprintf("%.6f", b.Lng),
-			b.Phone,
-			b.Website,
-			b.GoogleURL,
-			b.Description,
-			b.PriceRange,
-			b.Query,
-		})
+	if cerr := w.Close(); err == nil {
+		err = cerr
 	}
 
-	fmt.Fprintf(os.Stderr, "Exported %d businesses to %s\n", len(businesses), outputPath)
-	return nil
-}
-
-func loadFromDB(dbPath string) ([]model.Business, error) {
-	db, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		return nil, err
+	switch {
+	case err != nil:
+		err = fmt.Errorf("exporting: %w", err)
+	case total == 0:
+		err = fmt.Errorf("no businesses found in database")
+	case written == 0:
+		err = fmt.Errorf("no businesses open at %q (of %d)", openAt, total)
 	}
-	defer db.Close()
-
-	rows, err := db.Query(`
-		SELECT name, rating, review_count, category, address, price_range,
-		       lat, lng, cid, phone, website, google_url, description, place_id,
-		       open_hours, thumbnail, categories, city, postal_code, country_code, query
-		FROM businesses ORDER BY name`)
 	if err != nil {
-		return nil, err
+		os.Remove(outputPath)
+		return err
 	}
-	defer rows.Close()
 
-	var businesses []model.Business
-	for rows.Next() {
-		var b model.Business
-		err := rows.Scan(
-			&b.Name, &b.Rating, &b.ReviewCount, &b.Category, &b.Address, &b.PriceRange,
-			&b.Lat, &b.Lng, &b.CID, &b.Phone, &b.Website, &b.GoogleURL, &b.Description, &b.PlaceID,
-			&b.OpenHours, &b.Thumbnail, &b.Categories, &b.City, &b.PostalCode, &b.CountryCode, &b.Query,
-		)
-		if err != nil {
-			continue
-		}
-		businesses = append(businesses, b)
-	}
-	return businesses, nil
+	fmt.Fprintf(os.Stderr, "Exported %d businesses to %s\n", written, outputPath)
+	return nil
 }
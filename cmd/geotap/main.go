@@ -18,12 +18,60 @@ func main() {
 				os.Exit(1)
 			}
 			return
+		case "plan":
+			if err := runPlan(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "resume":
+			if err := runResume(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "enrich":
+			if err := runEnrich(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "reviews":
+			if err := runReviews(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "contacts":
+			if err := runContacts(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			return
 		case "export":
 			if err := runExport(os.Args[2:]); err != nil {
 				fmt.Fprintf(os.Stderr, "error: %v\n", err)
 				os.Exit(1)
 			}
 			return
+		case "diff":
+			if err := runDiff(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "merge":
+			if err := runMerge(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		case "migrate":
+			if err := runMigrate(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			return
 		case "version":
 			fmt.Println("geotap " + version)
 			return
@@ -46,9 +94,17 @@ func printUsage() {
 Usage:
   geotap                Launch interactive TUI
   geotap scan [flags]   Run headless scan
-  geotap export [flags] Export .db to CSV
+  geotap plan [flags]   Estimate a scan without sending requests
+  geotap resume [flags] Resume an interrupted scan
+  geotap enrich [flags] Fetch place details (hours, popular times, reviews)
+  geotap reviews [flags] Page through every review of the scanned places
+  geotap contacts [flags] Crawl websites for emails, social profiles and phones
+  geotap export [flags] Export .db to CSV, GeoJSON, KML, GPX, JSONL or Parquet
+  geotap diff [flags]   Compare two scans of a project
+  geotap merge [flags]  Combine several .db files into one
+  geotap migrate [flags] Upgrade a .db to the current schema
   geotap version        Show version
 
-Run 'geotap scan --help' or 'geotap export --help' for flags.
+Run 'geotap <command> --help' for flags.
 `)
 }
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/storage"
+)
+
+func runMerge(args []string) error {
+	var outPath, prefer string
+
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	fs.StringVar(&outPath, "out", "", "Path of the merged .db file to create (required)")
+	fs.StringVar(&prefer, "prefer", storage.PreferRecent, "Rule for places found in several sources: recent, complete")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: geotap merge -out <file> [flags] <db> <db> [db...]\n\n")
+		fmt.Fprintf(os.Stderr, "Combine project databases, e.g. a scan split across machines, into a new one.\n")
+		fmt.Fprintf(os.Stderr, "Places are deduplicated by CID; when sources disagree on a field, -prefer\n")
+		fmt.Fprintf(os.Stderr, "picks the copy updated last (recent) or with the most fields filled\n")
+		fmt.Fprintf(os.Stderr, "(complete), and empty fields are filled from the other copies. Sources are\n")
+		fmt.Fprintf(os.Stderr, "not modified.\n\nFlags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  geotap merge -out merged.db north.db south.db\n")
+		fmt.Fprintf(os.Stderr, "  geotap merge -out chile.db -prefer complete ./machines/*.db\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if outPath == "" {
+		return fmt.Errorf("-out is required")
+	}
+	if prefer != storage.PreferRecent && prefer != storage.PreferComplete {
+		return fmt.Errorf("-prefer must be %s or %s", storage.PreferRecent, storage.PreferComplete)
+	}
+	sources := fs.Args()
+	if len(sources) < 2 {
+		return fmt.Errorf("need at least two databases to merge, got %d", len(sources))
+	}
+	if _, err := os.Stat(outPath); err == nil {
+		return fmt.Errorf("%s already exists", outPath)
+	}
+	seen := map[string]string{absPath(outPath): outPath}
+	for _, src := range sources {
+		if _, err := os.Stat(src); err != nil {
+			return fmt.Errorf("opening db: %w", err)
+		}
+		if prev, ok := seen[absPath(src)]; ok {
+			return fmt.Errorf("%s is given twice (as %s)", src, prev)
+		}
+		seen[absPath(src)] = src
+	}
+
+	store, err := storage.NewStore(outPath)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", outPath, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Merging %d databases into %s (prefer %s)\n", len(sources), outPath, prefer)
+	startTime := time.Now()
+	merged, err := store.Merge(sources, prefer)
+	if err != nil {
+		store.Close()
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			os.Remove(outPath + suffix)
+		}
+		return err
+	}
+
+	printMergeReport(merged)
+	printMergeSummary(merged, prefer, store, time.Since(startTime).Truncate(time.Millisecond), outPath)
+	return store.Close()
+}
+
+// absPath resolves path for comparing file arguments, falling back to path
+// itself.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// printMergeReport writes a table of what each source brought, the places
+// it shares with the sources before it, and the sources whose scan jobs
+// were left out.
+func printMergeReport(merged []storage.MergeSource) {
+	fmt.Fprintf(os.Stderr, "\n%3s  %-28s %8s %8s %8s %9s %6s %8s\n",
+		"#", "DATABASE", "PLACES", "NEW", "SHARED", "CONFLICTS", "TAKEN", "SESSIONS")
+	for i, m := range merged {
+		fmt.Fprintf(os.Stderr, "%3d  %-28s %8d %8d %8d %9d %6d %8d\n",
+			i+1, truncateMiddle(filepath.Base(m.Path), 28), m.Places, m.New, m.Places-m.New, m.Conflicts, m.Taken, m.Sessions)
+	}
+
+	var overlaps []string
+	for i, m := range merged {
+		var with []string
+		for j := 0; j < i; j++ {
+			if n := m.Overlaps[j]; n > 0 {
+				with = append(with, fmt.Sprintf("%d with #%d", n, j+1))
+			}
+		}
+		if len(with) > 0 {
+			overlaps = append(overlaps, fmt.Sprintf("  #%d %s: %s", i+1, filepath.Base(m.Path), strings.Join(with, ", ")))
+		}
+	}
+	if len(overlaps) > 0 {
+		fmt.Fprintf(os.Stderr, "\nOverlaps:\n%s\n", strings.Join(overlaps, "\n"))
+	}
+
+	var skipped []string
+	for i, m := range merged {
+		if !m.Ledger {
+			skipped = append(skipped, fmt.Sprintf("  #%d %s", i+1, filepath.Base(m.Path)))
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "\nScan jobs left out (of several searches, or of another search than the ledger merged first):\n%s\n", strings.Join(skipped, "\n"))
+	}
+}
+
+// printMergeSummary writes the final merge report to stderr.
+func printMergeSummary(merged []storage.MergeSource, prefer string, store *storage.Store, duration time.Duration, outPath string) {
+	var shared, conflicts, sessions, reviews, contacts int
+	for _, m := range merged {
+		shared += m.Places - m.New
+		conflicts += m.Conflicts
+		sessions += m.Sessions
+		reviews += m.Reviews
+		contacts += m.Contacts
+	}
+	total, _ := store.Count()
+
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  GeoTap Merge Complete\n")
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  Sources:    %d\n", len(merged))
+	fmt.Fprintf(os.Stderr, "  Places:     %d (unique)\n", total)
+	fmt.Fprintf(os.Stderr, "  Shared:     %d already in an earlier source\n", shared)
+	fmt.Fprintf(os.Stderr, "  Conflicts:  %d resolved (prefer %s)\n", conflicts, prefer)
+	fmt.Fprintf(os.Stderr, "  Sessions:   %d\n", sessions)
+	if reviews > 0 {
+		fmt.Fprintf(os.Stderr, "  Reviews:    %d\n", reviews)
+	}
+	if contacts > 0 {
+		fmt.Fprintf(os.Stderr, "  Contacts:   %d\n", contacts)
+	}
+	fmt.Fprintf(os.Stderr, "  Duration:   %s\n", duration)
+	fmt.Fprintf(os.Stderr, "  Database:   %s\n", outPath)
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+}
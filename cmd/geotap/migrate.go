@@ -0,0 +1,85 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/rendis/geotap/internal/engine/storage"
+)
+
+func runMigrate(args []string) error {
+	var dbPath string
+	var dryRun bool
+
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.StringVar(&dbPath, "db", "", "Path to .db file (required)")
+	fs.BoolVar(&dryRun, "dry-run", false, "Only report pending migrations")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: geotap migrate [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Upgrade a project database to the schema of this geotap. Scans, the\n")
+		fmt.Fprintf(os.Stderr, "explorer and the other commands also upgrade the databases they open.\n\nFlags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  geotap migrate -db ./projects/geotap_20260212_120000.db\n")
+		fmt.Fprintf(os.Stderr, "  geotap migrate -db data.db -dry-run\n")
+		fmt.Fprintf(os.Stderr, "  for f in ./projects/*.db; do geotap migrate -db \"$f\"; done\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("opening db: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("opening db: %w", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("PRAGMA busy_timeout=5000"); err != nil {
+		return fmt.Errorf("setting busy timeout: %w", err)
+	}
+
+	from, err := storage.SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	pending, err := storage.PendingMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: schema version %d, latest %d\n", dbPath, from, storage.LatestVersion())
+	if len(pending) == 0 {
+		fmt.Fprintf(os.Stderr, "Up to date\n")
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Pending migrations:\n")
+	for _, m := range pending {
+		fmt.Fprintf(os.Stderr, "  %3d  %s\n", m.Version, m.Name)
+	}
+	if dryRun {
+		return nil
+	}
+
+	applied, err := storage.Migrate(db)
+	if err != nil {
+		return fmt.Errorf("migrating: %w", err)
+	}
+	to, err := storage.SchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Applied %d migrations: schema version %d → %d\n", len(applied), from, to)
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rendis/geotap/internal/engine/geo"
+	"github.com/rendis/geotap/internal/engine/scraper"
+	"github.com/rendis/geotap/internal/model"
+)
+
+func runPlan(args []string) error {
+	var params model.SearchParams
+	var queriesStr, geojsonPath string
+
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	bindSearchFlags(fs, &params, &queriesStr)
+	fs.StringVar(&geojsonPath, "geojson", "", "Write the sector grid as GeoJSON to this file")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: geotap plan [flags]\n\nDry run: generate the grid and estimate the scan without sending requests.\n\nFlags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  geotap plan -queries restaurants -country Chile\n")
+		fmt.Fprintf(os.Stderr, "  geotap plan -queries \"cafes,bars\" -country Spain -zoom 12 -concurrency 50 -geojson sectors.geojson\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := finalizeSearchParams(&params, queriesStr); err != nil {
+		return err
+	}
+
+	area, err := resolveArea(params)
+	if err != nil {
+		return err
+	}
+
+	est := scraper.EstimateScan(len(area.Sectors), params)
+
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  GeoTap Plan\n")
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  Zoom:       %d\n", params.Zoom)
+	fmt.Fprintf(os.Stderr, "  Grid:       %d sectors\n", area.GridSize)
+	if area.Clip == geo.ClipAreaFile || area.Clip == geo.ClipRegion {
+		fmt.Fprintf(os.Stderr, "  Inside:     %d sectors (%.1f%% outside %s removed)\n", len(area.Sectors), 100*area.OceanRatio(), area.Clip)
+	} else if !params.IsCoordMode() {
+		fmt.Fprintf(os.Stderr, "  Land:       %d sectors (%.1f%% ocean removed)\n", len(area.Sectors), 100*area.OceanRatio())
+	}
+	for _, q := range params.Queries {
+		fmt.Fprintf(os.Stderr, "  Query:      %-20s %d sectors\n", q, len(area.Sectors))
+	}
+	fmt.Fprintf(os.Stderr, "  Jobs:       %d\n", est.Jobs)
+	if est.MaxRequests != est.MinRequests {
+		fmt.Fprintf(os.Stderr, "  Requests:   %d - %d (max-pages=%d)\n", est.MinRequests, est.MaxRequests, params.MaxPages)
+		fmt.Fprintf(os.Stderr, "  ETA:        ~%s - %s (concurrency=%d)\n", est.MinETA, est.MaxETA, params.Concurrency)
+	} else {
+		fmt.Fprintf(os.Stderr, "  Requests:   %d\n", est.MinRequests)
+		fmt.Fprintf(os.Stderr, "  ETA:        ~%s (concurrency=%d)\n", est.MinETA, params.Concurrency)
+	}
+	if params.Adaptive {
+		fmt.Fprintf(os.Stderr, "  Adaptive:   on (saturated sectors add requests, up to zoom %d)\n", params.MaxZoom)
+	}
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+
+	if geojsonPath != "" {
+		data, err := geo.AreaGeoJSON(area)
+		if err != nil {
+			return fmt.Errorf("encoding geojson: %w", err)
+		}
+		if err := os.WriteFile(geojsonPath, data, 0644); err != nil {
+			return fmt.Errorf("writing geojson: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Sectors written to %s\n", geojsonPath)
+	}
+
+	return nil
+}
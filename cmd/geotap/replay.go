@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/geo"
+	"github.com/rendis/geotap/internal/engine/scraper"
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+	"github.com/rendis/geotap/internal/tui"
+)
+
+// runReplay re-parses a recording into a new database. Filters come from the
+// recorded scan; -min-rating/-max-rating given on the command line override them.
+func runReplay(dir, outputDir string, flags model.SearchParams) error {
+	manifest, err := scraper.LoadRecordManifest(dir)
+	if err != nil {
+		return fmt.Errorf("loading recording: %w", err)
+	}
+	params := manifest.Params
+	params.RecordDir = ""
+	if flags.MinRating > 0 {
+		params.MinRating = flags.MinRating
+	}
+	if flags.MaxRating > 0 {
+		params.MaxRating = flags.MaxRating
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("creating output dir: %w", err)
+	}
+	ts := time.Now().Format("20060102_150405")
+	baseName := fmt.Sprintf("geotap_%s", ts)
+	params.DBPath = filepath.Join(outputDir, baseName+".db")
+	logPath := filepath.Join(outputDir, baseName+".log")
+
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log: %w", err)
+	}
+	defer logFile.Close()
+	logger := log.New(logFile, "", log.LstdFlags)
+	logger.Printf("=== Session replay: dir=%s responses=%d recorded=%s ===",
+		dir, manifest.Responses, manifest.CreatedAt.Format(time.RFC3339))
+
+	fmt.Fprintf(os.Stderr, "Log: %s\n", logPath)
+	fmt.Fprintf(os.Stderr, "Replaying: %d responses recorded %s\n", manifest.Responses, manifest.CreatedAt.Format("2006-01-02 15:04"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		fmt.Fprintln(os.Stderr, "\nShutting down gracefully...")
+		cancel()
+	}()
+
+	// Clip to the same area as the recorded scan
+	poly, err := geo.ResolveGeoFilter(params)
+	if err != nil {
+		return err
+	}
+
+	store, err := storage.NewStore(params.DBPath)
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+	defer store.Close()
+	if err := store.SaveParams(params); err != nil {
+		return err
+	}
+
+	startTime := time.Now()
+	session, err := store.StartSession(storage.SessionReplay, "cli", params)
+	if err != nil {
+		return err
+	}
+	stats, err := scraper.Replay(ctx, dir, params, store, logger, &scraper.RunOptions{
+		GeoFilter: poly,
+	})
+	if ferr := store.FinishSession(session, stats.Snapshot(), err); ferr != nil {
+		logger.Printf("SESSION err=%v", ferr)
+	}
+	if err != nil && err != context.Canceled {
+		return fmt.Errorf("replaying: %w", err)
+	}
+
+	printSummary(params, stats, store, time.Since(startTime).Truncate(time.Second), logPath)
+
+	tui.SaveRecent(params.DBPath)
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/geo"
+	"github.com/rendis/geotap/internal/engine/scraper"
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/tui"
+)
+
+func runResume(args []string) error {
+	var dbPath, proxyURL, proxiesFile string
+	var concurrency int
+
+	fs := flag.NewFlagSet("resume", flag.ExitOnError)
+	fs.StringVar(&dbPath, "db", "", "Path to .db file of an interrupted scan (required)")
+	fs.IntVar(&concurrency, "concurrency", 0, "Override max concurrent requests (default: original value)")
+	fs.StringVar(&proxyURL, "proxy", "", "HTTP/SOCKS5 proxy URL (required again when the scan used one)")
+	fs.StringVar(&proxiesFile, "proxies", "", "File with one HTTP/SOCKS5 proxy URL per line (required again when the scan used proxies)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: geotap resume [flags]\n\nFlags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  geotap resume -db ./projects/geotap_20260212_120000.db\n")
+		fmt.Fprintf(os.Stderr, "  geotap resume -db ./projects/geotap_20260212_120000.db -proxies proxies.txt\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("opening db: %w", err)
+	}
+
+	store, err := storage.NewStore(dbPath)
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+	defer store.Close()
+
+	params, err := store.ResumeParams()
+	if err != nil {
+		return err
+	}
+	params.DBPath = dbPath
+	if concurrency > 0 {
+		params.Concurrency = concurrency
+	}
+
+	counts, err := store.JobCounts()
+	if err != nil {
+		return err
+	}
+	if storage.Unfinished(counts) == 0 {
+		fmt.Fprintf(os.Stderr, "Nothing to resume: all %d jobs are done\n", counts[storage.JobDone])
+		return nil
+	}
+	if err := applyProxyFlags(&params, proxyURL, proxiesFile); err != nil {
+		return err
+	}
+
+	// Append to the scan's original log file
+	logPath := strings.TrimSuffix(dbPath, ".db") + ".log"
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log: %w", err)
+	}
+	defer logFile.Close()
+	logger := log.New(logFile, "", log.LstdFlags)
+	logger.Printf("=== Session resume: pending=%d interrupted=%d failed=%d done=%d concurrency=%d ===",
+		counts[storage.JobPending], counts[storage.JobInterrupted], counts[storage.JobFailed], counts[storage.JobDone], params.Concurrency)
+
+	fmt.Fprintf(os.Stderr, "Log: %s\n", logPath)
+
+	// Setup context with graceful shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		fmt.Fprintln(os.Stderr, "\nShutting down gracefully...")
+		cancel()
+	}()
+
+	// Rebuild the geographic filter used by the original scan
+	poly, err := geo.ResolveGeoFilter(params)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Resuming: %d pending, %d interrupted, %d failed, %d done (concurrency=%d)\n",
+		counts[storage.JobPending], counts[storage.JobInterrupted], counts[storage.JobFailed], counts[storage.JobDone], params.Concurrency)
+
+	startTime := time.Now()
+	session, err := store.StartSession(storage.SessionResume, "cli", params)
+	if err != nil {
+		return err
+	}
+	stats, err := scraper.Resume(ctx, params, store, logger, &scraper.RunOptions{
+		GeoFilter: poly,
+	})
+	if ferr := store.FinishSession(session, stats.Snapshot(), err); ferr != nil {
+		logger.Printf("SESSION err=%v", ferr)
+	}
+	if err != nil && err != context.Canceled {
+		return fmt.Errorf("scraping: %w", err)
+	}
+
+	duration := time.Since(startTime).Truncate(time.Second)
+	total, _ := store.Count()
+
+	logger.Printf("Done: found=%d stored=%d errors=%d rate_limits=%d total_in_db=%d",
+		stats.BusinessesFound.Load(), stats.BusinessesStored.Load(),
+		stats.Errors.Load(), stats.RateLimits.Load(), total)
+
+	printSummary(params, stats, store, duration, logPath)
+
+	tui.SaveRecent(params.DBPath)
+
+	return nil
+}
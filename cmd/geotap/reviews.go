@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/scraper"
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+)
+
+func runReviews(args []string) error {
+	var dbPath, sinceStr, proxyURL, proxiesFile, endpoint string
+	var limit, concurrency int
+	var refresh bool
+
+	fs := flag.NewFlagSet("reviews", flag.ExitOnError)
+	fs.StringVar(&dbPath, "db", "", "Path to .db file of a scan (required)")
+	fs.IntVar(&limit, "limit", 0, "Keep only the newest N reviews per place (0 = all)")
+	fs.StringVar(&sinceStr, "since", "", "Only reviews published on or after this date (YYYY-MM-DD)")
+	fs.BoolVar(&refresh, "refresh", false, "Fetch again for places already done, stopping at the first known review")
+	fs.IntVar(&concurrency, "concurrency", 0, "Max concurrent requests (default: the scan's value)")
+	fs.StringVar(&proxyURL, "proxy", "", "HTTP/SOCKS5 proxy URL (pass again when the scan used proxies)")
+	fs.StringVar(&proxiesFile, "proxies", "", "File with one HTTP/SOCKS5 proxy URL per line to rotate across")
+	fs.StringVar(&endpoint, "endpoint", "", "Override the review listing URL, e.g. a local fakemaps server (testing)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: geotap reviews [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Page through the reviews of every place in a scan, newest first, into the\n")
+		fmt.Fprintf(os.Stderr, "reviews table. Re-running continues where it stopped.\n\nFlags:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  geotap reviews -db ./projects/geotap_20260212_120000.db\n")
+		fmt.Fprintf(os.Stderr, "  geotap reviews -db data.db -limit 50\n")
+		fmt.Fprintf(os.Stderr, "  geotap reviews -db data.db -since 2026-01-01 -refresh\n")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if dbPath == "" {
+		return fmt.Errorf("-db is required")
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("opening db: %w", err)
+	}
+	if limit < 0 {
+		return fmt.Errorf("-limit must be >= 0")
+	}
+	var since time.Time
+	if sinceStr != "" {
+		t, err := time.Parse("2006-01-02", sinceStr)
+		if err != nil {
+			return fmt.Errorf("invalid -since %q: want YYYY-MM-DD", sinceStr)
+		}
+		since = t
+	}
+
+	store, err := storage.NewStore(dbPath)
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+	defer store.Close()
+
+	// Reuse the scan's language; databases from before resumable scans
+	// have no saved parameters and get the defaults. Proxies are not stored
+	// and come from the flags.
+	params, err := store.LoadParams()
+	if err != nil {
+		params = model.SearchParams{Lang: "en", Concurrency: 10}
+	}
+	params.DBPath = dbPath
+	if concurrency > 0 {
+		params.Concurrency = concurrency
+	}
+	if err := applyProxyFlags(&params, proxyURL, proxiesFile); err != nil {
+		return err
+	}
+	params.ReviewsEndpoint = endpoint
+
+	added, err := store.EnqueueReviewJobs(refresh)
+	if err != nil {
+		return err
+	}
+	counts, err := store.ReviewCounts()
+	if err != nil {
+		return err
+	}
+	if storage.Unfinished(counts) == 0 {
+		fmt.Fprintf(os.Stderr, "Nothing to fetch: all %d places are done (use -refresh for new reviews)\n", counts[storage.JobDone])
+		return nil
+	}
+
+	// Append to the scan's log file
+	logPath := strings.TrimSuffix(dbPath, ".db") + ".log"
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("opening log: %w", err)
+	}
+	defer logFile.Close()
+	logger := log.New(logFile, "", log.LstdFlags)
+	logger.Printf("=== Session reviews: new=%d pending=%d interrupted=%d failed=%d done=%d refresh=%v concurrency=%d ===",
+		added, counts[storage.JobPending], counts[storage.JobInterrupted], counts[storage.JobFailed], counts[storage.JobDone], refresh, params.Concurrency)
+
+	fmt.Fprintf(os.Stderr, "Log: %s\n", logPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		fmt.Fprintln(os.Stderr, "\nShutting down gracefully...")
+		cancel()
+	}()
+
+	fmt.Fprintf(os.Stderr, "Fetching reviews: %d places (%d new), %d already done (concurrency=%d)\n",
+		storage.Unfinished(counts), added, counts[storage.JobDone], params.Concurrency)
+
+	startTime := time.Now()
+	stats, err := scraper.FetchReviews(ctx, params, store, logger, &scraper.ReviewOptions{
+		Limit: limit,
+		Since: since,
+	})
+	if err != nil && err != context.Canceled {
+		return fmt.Errorf("fetching reviews: %w", err)
+	}
+	duration := time.Since(startTime).Truncate(time.Second)
+
+	logger.Printf("Done: reviews=%d pages=%d errors=%d rate_limits=%d",
+		stats.Reviews.Load(), stats.Pages.Load(), stats.Errors.Load(), stats.RateLimits.Load())
+
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  GeoTap Reviews Complete\n")
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+	fmt.Fprintf(os.Stderr, "  Places:     %d\n", stats.PlacesTotal.Load())
+	fmt.Fprintf(os.Stderr, "  Reviews:    %d new\n", stats.Reviews.Load())
+	fmt.Fprintf(os.Stderr, "  Pages:      %d\n", stats.Pages.Load())
+	fmt.Fprintf(os.Stderr, "  Errors:     %d\n", stats.Errors.Load())
+	if proxies := stats.Proxies(); len(proxies) > 0 {
+		fmt.Fprintf(os.Stderr, "  Proxies:    %d\n", len(proxies))
+		for _, p := range proxies {
+			fmt.Fprintf(os.Stderr, "    %-32s ok=%d fail=%d rl=%d benched=%d\n",
+				truncateMiddle(p.URL, 32), p.Successes.Load(), p.Failures.Load(), p.RateLimits.Load(), p.Benched.Load())
+		}
+	}
+	if counts, err := store.ReviewCounts(); err == nil {
+		if pending := storage.Unfinished(counts); pending > 0 {
+			fmt.Fprintf(os.Stderr, "  Unfinished: %d places (run 'geotap reviews -db %s' again)\n", pending, dbPath)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "  Duration:   %s\n", duration)
+	fmt.Fprintf(os.Stderr, "  Database:   %s\n", dbPath)
+	fmt.Fprintf(os.Stderr, "  Log:        %s\n", logPath)
+	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -12,7 +13,6 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/paulmach/orb"
 	"github.com/rendis/geotap/internal/engine/geo"
 	"github.com/rendis/geotap/internal/engine/scraper"
 	"github.com/rendis/geotap/internal/engine/storage"
@@ -22,26 +22,20 @@ import (
 
 func runScan(args []string) error {
 	var params model.SearchParams
-	var queriesStr, outputDir string
+	var queriesStr, outputDir, proxiesFile, replayDir, appendDB string
+	var discardUnfinished bool
 
 	fs := flag.NewFlagSet("scan", flag.ExitOnError)
-	fs.StringVar(&outputDir, "output", "", "Output directory for project files (required)")
-	fs.StringVar(&params.Country, "country", "", "Country name or ISO code")
-	fs.StringVar(&params.Region, "region", "", "Region/state (optional)")
-	fs.StringVar(&params.Province, "province", "", "Province (optional)")
-	fs.StringVar(&params.City, "city", "", "City (optional)")
-	fs.Float64Var(&params.Lat, "lat", 0, "Center latitude")
-	fs.Float64Var(&params.Lng, "lng", 0, "Center longitude")
-	fs.Float64Var(&params.Radius, "radius", 10, "Search radius in km")
-	fs.StringVar(&queriesStr, "queries", "", "Comma-separated search terms (required)")
-	fs.IntVar(&params.Zoom, "zoom", 0, "Zoom level 10-16 (default: auto)")
-	fs.IntVar(&params.Concurrency, "concurrency", 10, "Max concurrent requests")
-	fs.IntVar(&params.MaxPages, "max-pages", 1, "Max pagination pages per sector")
-	fs.Float64Var(&params.MinRating, "min-rating", 0, "Minimum star rating filter")
-	fs.Float64Var(&params.MaxRating, "max-rating", 0, "Maximum star rating filter")
-	fs.StringVar(&params.Lang, "lang", "en", "Search language")
+	fs.StringVar(&outputDir, "output", "", "Output directory for project files (required unless -db)")
+	fs.StringVar(&appendDB, "db", "", "Append a new scan session to this project database instead of creating one")
+	fs.BoolVar(&discardUnfinished, "discard-unfinished", false, "With -db, drop the unfinished jobs of an interrupted scan instead of refusing")
+	bindSearchFlags(fs, &params, &queriesStr)
 	fs.StringVar(&params.ProxyURL, "proxy", "", "HTTP/SOCKS5 proxy URL")
+	fs.StringVar(&proxiesFile, "proxies", "", "File with one HTTP/SOCKS5 proxy URL per line to rotate across")
 	fs.BoolVar(&params.Debug, "debug", false, "Dump raw responses")
+	fs.StringVar(&params.Endpoint, "endpoint", "", "Override the Maps search URL, e.g. a local fakemaps server (testing)")
+	fs.StringVar(&params.RecordDir, "record", "", "Archive raw responses with request metadata in this directory")
+	fs.StringVar(&replayDir, "replay", "", "Re-parse a -record directory into a new database, without network")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: geotap scan [flags]\n\nFlags:\n")
@@ -49,47 +43,88 @@ func runScan(args []string) error {
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  geotap scan -queries restaurants -country Chile -output ./projects\n")
 		fmt.Fprintf(os.Stderr, "  geotap scan -queries \"cafes,bars\" -lat 40.4168 -lng -3.7038 -radius 5 -output ./projects\n")
+		fmt.Fprintf(os.Stderr, "  geotap scan -queries restaurants -country Spain -zoom 10 -adaptive -max-zoom 14 -output ./projects\n")
+		fmt.Fprintf(os.Stderr, "  geotap scan -queries pharmacies -area territory.geojson -output ./projects\n")
+		fmt.Fprintf(os.Stderr, "  geotap scan -queries hotels -country Portugal -proxies proxies.txt -concurrency 100 -output ./projects\n")
+		fmt.Fprintf(os.Stderr, "  geotap scan -queries cafes -country Uruguay -record ./rec/uy-cafes -output ./projects\n")
+		fmt.Fprintf(os.Stderr, "  geotap scan -replay ./rec/uy-cafes -output ./projects\n")
+		fmt.Fprintf(os.Stderr, "  geotap scan -db ./projects/geotap_20260212_120000.db   (rescan with the project's search)\n")
 	}
 
 	if err := fs.Parse(args); err != nil {
 		return err
 	}
 
+	if replayDir != "" {
+		if outputDir == "" {
+			return fmt.Errorf("-output is required")
+		}
+		return runReplay(replayDir, outputDir, params)
+	}
+
 	// Validation
-	if queriesStr == "" {
+	switch {
+	case appendDB != "":
+		if outputDir != "" {
+			return fmt.Errorf("-db and -output are mutually exclusive")
+		}
+		if _, err := os.Stat(appendDB); err != nil {
+			return fmt.Errorf("opening db: %w", err)
+		}
+		if !discardUnfinished {
+			if err := checkNoUnfinished(appendDB); err != nil {
+				return err
+			}
+		}
+		if queriesStr == "" {
+			// Rescan: the project's search, with how to run it from the flags
+			if err := reuseProjectParams(fs, &params, appendDB, proxiesFile); err != nil {
+				return err
+			}
+			queriesStr = strings.Join(params.Queries, ",")
+		}
+	case queriesStr == "":
 		return fmt.Errorf("-queries is required")
-	}
-	if outputDir == "" {
+	case outputDir == "":
 		return fmt.Errorf("-output is required")
 	}
-	if !params.IsCoordMode() && params.Country == "" {
-		return fmt.Errorf("either -country or -lat/-lng is required")
+	if err := finalizeSearchParams(&params, queriesStr); err != nil {
+		return err
 	}
-
-	params.Queries = strings.Split(queriesStr, ",")
-	for i := range params.Queries {
-		params.Queries[i] = strings.TrimSpace(params.Queries[i])
+	if proxiesFile != "" {
+		proxies, err := scraper.LoadProxies(proxiesFile)
+		if err != nil {
+			return err
+		}
+		params.Proxies = proxies
 	}
-
-	// Smart zoom default
-	if params.Zoom == 0 {
-		if params.IsCoordMode() {
-			params.Zoom = 13
-		} else {
-			params.Zoom = 10
+	if params.RecordDir != "" {
+		abs, err := filepath.Abs(params.RecordDir)
+		if err != nil {
+			return fmt.Errorf("resolving -record: %w", err)
+		}
+		params.RecordDir = abs
+		if err := scraper.CheckRecordDir(abs, params); err != nil {
+			return err
 		}
 	}
 
-	// Create output directory
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("creating output dir: %w", err)
-	}
+	var logPath string
+	if appendDB != "" {
+		params.DBPath = appendDB
+		logPath = strings.TrimSuffix(appendDB, ".db") + ".log"
+	} else {
+		// Create output directory
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return fmt.Errorf("creating output dir: %w", err)
+		}
 
-	// Generate timestamped filenames
-	ts := time.Now().Format("20060102_150405")
-	baseName := fmt.Sprintf("geotap_%s", ts)
-	params.DBPath = filepath.Join(outputDir, baseName+".db")
-	logPath := filepath.Join(outputDir, baseName+".log")
+		// Generate timestamped filenames
+		ts := time.Now().Format("20060102_150405")
+		baseName := fmt.Sprintf("geotap_%s", ts)
+		params.DBPath = filepath.Join(outputDir, baseName+".db")
+		logPath = filepath.Join(outputDir, baseName+".log")
+	}
 
 	// Setup log file
 	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
@@ -98,8 +133,8 @@ func runScan(args []string) error {
 	}
 	defer logFile.Close()
 	logger := log.New(logFile, "", log.LstdFlags)
-	logger.Printf("=== Session start: queries=%v country=%s lat=%.4f lng=%.4f radius=%.1f zoom=%d concurrency=%d ===",
-		params.Queries, params.Country, params.Lat, params.Lng, params.Radius, params.Zoom, params.Concurrency)
+	logger.Printf("=== Session start: queries=%v country=%s area=%s lat=%.4f lng=%.4f radius=%.1f zoom=%d concurrency=%d adaptive=%t ===",
+		params.Queries, params.Country, params.AreaFile, params.Lat, params.Lng, params.Radius, params.Zoom, params.Concurrency, params.Adaptive)
 
 	fmt.Fprintf(os.Stderr, "Log: %s\n", logPath)
 
@@ -117,48 +152,11 @@ func runScan(args []string) error {
 
 	// Generate sectors
 	startTime := time.Now()
-	var sectors []model.Sector
-	var poly orb.MultiPolygon
-
-	if params.IsCoordMode() {
-		fmt.Fprintf(os.Stderr, "Mode: coordinate search (%.4f, %.4f, radius=%.1fkm)\n",
-			params.Lat, params.Lng, params.Radius)
-		sectors = geo.GenerateRadiusGrid(params.Lat, params.Lng, params.Radius, params.Zoom)
-		fmt.Fprintf(os.Stderr, "Grid: %d sectors within radius\n", len(sectors))
-	} else {
-		fmt.Fprintf(os.Stderr, "Mode: country search (%s)\n", params.Country)
-
-		bs, err := geo.NewBoundaryStore()
-		if err != nil {
-			return fmt.Errorf("loading boundaries: %w", err)
-		}
-
-		var minLat, minLng, maxLat, maxLng float64
-		if params.Region != "" {
-			fmt.Fprintf(os.Stderr, "Region: %s\n", params.Region)
-			minLat, minLng, maxLat, maxLng, err = geo.GeocodeRegion(params.Region, params.Country)
-			if err != nil {
-				return fmt.Errorf("geocoding region %q: %w", params.Region, err)
-			}
-		} else {
-			minLat, minLng, maxLat, maxLng, err = bs.GetCountryBounds(params.Country)
-			if err != nil {
-				return fmt.Errorf("getting bounds: %w", err)
-			}
-		}
-		fmt.Fprintf(os.Stderr, "Bounds: [%.2f, %.2f] - [%.2f, %.2f]\n", minLat, minLng, maxLat, maxLng)
-
-		allSectors := geo.GenerateGrid(minLat, minLng, maxLat, maxLng, params.Zoom)
-		fmt.Fprintf(os.Stderr, "Grid: %d total sectors\n", len(allSectors))
-
-		poly, err = bs.GetCountryPolygon(params.Country)
-		if err != nil {
-			return fmt.Errorf("getting polygon: %w", err)
-		}
-		sectors = geo.FilterLandSectors(allSectors, poly)
-		oceanPct := 100.0 * float64(len(allSectors)-len(sectors)) / float64(len(allSectors))
-		fmt.Fprintf(os.Stderr, "GeoFilter: %d land sectors (%.1f%% ocean removed)\n", len(sectors), oceanPct)
+	area, err := resolveArea(params)
+	if err != nil {
+		return err
 	}
+	sectors := area.Sectors
 
 	if len(sectors) == 0 {
 		return fmt.Errorf("no sectors to process")
@@ -170,6 +168,13 @@ func runScan(args []string) error {
 		return fmt.Errorf("opening store: %w", err)
 	}
 	defer store.Close()
+	if appendDB != "" {
+		// A new scan of the project runs every job again. Unfinished jobs
+		// were checked for above, or discarded on request
+		if err := store.ResetJobs(); err != nil {
+			return err
+		}
+	}
 
 	// Run scraper
 	totalJobs := len(params.Queries) * len(sectors)
@@ -178,9 +183,16 @@ func runScan(args []string) error {
 	logger.Printf("Scraping: %d jobs (%d queries x %d sectors), concurrency=%d",
 		totalJobs, len(params.Queries), len(sectors), params.Concurrency)
 
+	session, err := store.StartSession(storage.SessionScan, "cli", params)
+	if err != nil {
+		return err
+	}
 	stats, err := scraper.Run(ctx, sectors, params, store, logger, &scraper.RunOptions{
-		GeoFilter: poly,
+		GeoFilter: area.Filter,
 	})
+	if ferr := store.FinishSession(session, stats.Snapshot(), err); ferr != nil {
+		logger.Printf("SESSION err=%v", ferr)
+	}
 	if err != nil && err != context.Canceled {
 		return fmt.Errorf("scraping: %w", err)
 	}
@@ -192,27 +204,266 @@ func runScan(args []string) error {
 		stats.BusinessesFound.Load(), stats.BusinessesStored.Load(),
 		stats.Errors.Load(), stats.RateLimits.Load(), total)
 
-	// Print final summary
+	printSummary(params, stats, store, duration, logPath)
+	if appendDB != "" {
+		fmt.Fprintf(os.Stderr, "Compare with the previous scan: geotap diff -db %s\n", appendDB)
+	}
+
+	tui.SaveRecent(params.DBPath)
+
+	return nil
+}
+
+// checkNoUnfinished refuses a rescan of the project at dbPath while the
+// ledger of an interrupted scan has unfinished jobs, which the rescan would
+// drop.
+func checkNoUnfinished(dbPath string) error {
+	store, err := storage.NewStore(dbPath)
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+	defer store.Close()
+	counts, err := store.JobCounts()
+	if err != nil {
+		return err
+	}
+	if pending := storage.Unfinished(counts); pending > 0 {
+		return fmt.Errorf("project has %d unfinished jobs from an interrupted scan: run 'geotap resume -db %s' first, or pass -discard-unfinished to drop them", pending, dbPath)
+	}
+	return nil
+}
+
+// reuseProjectParams sets params to the search of the scan that created the
+// project at dbPath, for a rescan. Flags about how to run, rather than what
+// and where to scan, still apply when given. Proxies are not stored, so a
+// project scanned through proxies needs -proxy or -proxies again.
+func reuseProjectParams(fs *flag.FlagSet, params *model.SearchParams, dbPath, proxiesFile string) error {
+	store, err := storage.NewStore(dbPath)
+	if err != nil {
+		return fmt.Errorf("opening store: %w", err)
+	}
+	defer store.Close()
+	prev, err := store.LoadParams()
+	if err != nil {
+		return err
+	}
+	if len(prev.Queries) == 0 {
+		return fmt.Errorf("project has no saved queries: pass -queries and the area to rescan")
+	}
+
+	// A rescan is not recorded over the original's archive unless asked to
+	prev.RecordDir, prev.Debug = "", false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "concurrency":
+			prev.Concurrency = params.Concurrency
+		case "endpoint":
+			prev.Endpoint = params.Endpoint
+		case "geocoder-url":
+			prev.GeocoderURL = params.GeocoderURL
+		case "record":
+			prev.RecordDir = params.RecordDir
+		case "debug":
+			prev.Debug = params.Debug
+		}
+	})
+	if prev.UsesProxies() && params.ProxyURL == "" && proxiesFile == "" {
+		return errProxiesNotStored
+	}
+	prev.ProxyURL = params.ProxyURL
+	prev.Proxies = nil // from -proxies, read after this
+	*params = prev
+	return nil
+}
+
+// errProxiesNotStored is returned when a command continues a scan that ran
+// through proxies without being given them again.
+var errProxiesNotStored = errors.New("the scan ran through proxies, which the project does not store: pass -proxy or -proxies again")
+
+// applyProxyFlags replaces the proxies of params, loaded from a project, with
+// those of the -proxy and -proxies flags. Projects only keep proxies with
+// their credentials redacted.
+func applyProxyFlags(params *model.SearchParams, proxyURL, proxiesFile string) error {
+	saved := params.UsesProxies()
+	params.ProxyURL, params.Proxies = proxyURL, nil
+	if proxiesFile != "" {
+		proxies, err := scraper.LoadProxies(proxiesFile)
+		if err != nil {
+			return err
+		}
+		params.Proxies = proxies
+	}
+	if saved && !params.UsesProxies() {
+		return errProxiesNotStored
+	}
+	return nil
+}
+
+// bindSearchFlags registers the flags that define what and where to scan,
+// shared by the scan and plan commands.
+func bindSearchFlags(fs *flag.FlagSet, params *model.SearchParams, queriesStr *string) {
+	fs.StringVar(&params.Country, "country", "", "Country name or ISO code")
+	fs.StringVar(&params.Region, "region", "", "Region/state (optional)")
+	fs.StringVar(&params.Province, "province", "", "Province, used when -region is not set (optional)")
+	fs.StringVar(&params.City, "city", "", "City within the country/region (optional, geocoded online)")
+	fs.Float64Var(&params.Lat, "lat", 0, "Center latitude")
+	fs.Float64Var(&params.Lng, "lng", 0, "Center longitude")
+	fs.Float64Var(&params.Radius, "radius", 10, "Search radius in km")
+	fs.StringVar(&params.AreaFile, "area", "", "Polygon file to scan (GeoJSON, KML or WKT)")
+	fs.StringVar(queriesStr, "queries", "", "Comma-separated search terms (required)")
+	fs.IntVar(&params.Zoom, "zoom", 0, "Zoom level 10-16 (default: auto)")
+	fs.IntVar(&params.Concurrency, "concurrency", 10, "Max concurrent requests")
+	fs.IntVar(&params.MaxPages, "max-pages", 1, "Max pagination pages per sector")
+	fs.BoolVar(&params.Adaptive, "adaptive", false, "Split saturated sectors into quadrants at zoom+1")
+	fs.IntVar(&params.MaxZoom, "max-zoom", 16, "Deepest zoom for -adaptive subdivision")
+	fs.Float64Var(&params.MinRating, "min-rating", 0, "Minimum star rating filter")
+	fs.Float64Var(&params.MaxRating, "max-rating", 0, "Maximum star rating filter")
+	fs.StringVar(&params.Lang, "lang", "en", "Search language")
+	fs.StringVar(&params.GeocoderURL, "geocoder-url", "", "Nominatim base URL for region lookups (default: $GEOTAP_GEOCODER_URL or public instance)")
+}
+
+// finalizeSearchParams validates the parsed search flags and fills in defaults.
+func finalizeSearchParams(params *model.SearchParams, queriesStr string) error {
+	if queriesStr == "" {
+		return fmt.Errorf("-queries is required")
+	}
+	if !params.IsCoordMode() && params.Country == "" && params.AreaFile == "" {
+		return fmt.Errorf("either -country, -lat/-lng or -area is required")
+	}
+	if params.AreaFile != "" && (params.Country != "" || params.IsCoordMode()) {
+		return fmt.Errorf("-area cannot be combined with -country or -lat/-lng: the file is the scan area")
+	}
+	if params.Country == "" && (params.Region != "" || params.Province != "" || params.City != "") {
+		return fmt.Errorf("-region, -province and -city require -country")
+	}
+	if params.AreaFile != "" {
+		abs, err := filepath.Abs(params.AreaFile)
+		if err != nil {
+			return fmt.Errorf("resolving -area: %w", err)
+		}
+		params.AreaFile = abs
+	}
+	if params.Adaptive && (params.MaxZoom < 10 || params.MaxZoom > 21) {
+		return fmt.Errorf("-max-zoom must be between 10 and 21")
+	}
+
+	params.Queries = strings.Split(queriesStr, ",")
+	for i := range params.Queries {
+		params.Queries[i] = strings.TrimSpace(params.Queries[i])
+	}
+
+	// Smart zoom default
+	if params.Zoom == 0 {
+		switch {
+		case params.IsCoordMode():
+			params.Zoom = 13
+		case params.AreaFile != "":
+			params.Zoom = 12
+		default:
+			params.Zoom = 10
+		}
+	}
+	return nil
+}
+
+// resolveArea generates the scan sectors for params, reporting each step on stderr.
+func resolveArea(params model.SearchParams) (*geo.Area, error) {
+	switch {
+	case params.AreaFile != "":
+		fmt.Fprintf(os.Stderr, "Mode: custom area (%s)\n", filepath.Base(params.AreaFile))
+	case params.IsCoordMode():
+		fmt.Fprintf(os.Stderr, "Mode: coordinate search (%.4f, %.4f, radius=%.1fkm)\n",
+			params.Lat, params.Lng, params.Radius)
+	default:
+		fmt.Fprintf(os.Stderr, "Mode: country search (%s)\n", params.Country)
+		if name := params.RegionName(); name != "" {
+			fmt.Fprintf(os.Stderr, "Region: %s\n", name)
+		}
+		if params.City != "" {
+			fmt.Fprintf(os.Stderr, "City: %s\n", params.City)
+		}
+	}
+
+	area, err := geo.ResolveArea(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.IsCoordMode() {
+		fmt.Fprintf(os.Stderr, "Grid: %d sectors within radius\n", len(area.Sectors))
+	} else {
+		fmt.Fprintf(os.Stderr, "Bounds: [%.2f, %.2f] - [%.2f, %.2f]\n",
+			area.Bound.Min.Lat(), area.Bound.Min.Lon(), area.Bound.Max.Lat(), area.Bound.Max.Lon())
+		fmt.Fprintf(os.Stderr, "Grid: %d total sectors\n", area.GridSize)
+		switch area.Clip {
+		case geo.ClipAreaFile, geo.ClipRegion:
+			fmt.Fprintf(os.Stderr, "GeoFilter: %d sectors inside %s polygon (%.1f%% outside removed)\n",
+				len(area.Sectors), area.Clip, 100*area.OceanRatio())
+		case geo.ClipRegionBBox:
+			fmt.Fprintf(os.Stderr, "GeoFilter: no region boundary returned, using bbox clipped to country\n")
+			fmt.Fprintf(os.Stderr, "GeoFilter: %d land sectors (%.1f%% ocean removed)\n",
+				len(area.Sectors), 100*area.OceanRatio())
+		default:
+			fmt.Fprintf(os.Stderr, "GeoFilter: %d land sectors (%.1f%% ocean removed)\n",
+				len(area.Sectors), 100*area.OceanRatio())
+		}
+	}
+	return area, nil
+}
+
+// printSummary writes the final scan report to stderr.
+func printSummary(params model.SearchParams, stats *scraper.Stats, store *storage.Store, duration time.Duration, logPath string) {
+	total, _ := store.Count()
+
 	fmt.Fprintf(os.Stderr, "\n")
 	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
 	fmt.Fprintf(os.Stderr, "  GeoTap Complete\n")
 	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
 	fmt.Fprintf(os.Stderr, "  Query:      %s\n", strings.Join(params.Queries, ", "))
-	if params.Country != "" {
+	if params.AreaFile != "" {
+		fmt.Fprintf(os.Stderr, "  Area:       %s\n", filepath.Base(params.AreaFile))
+	} else if params.Country != "" {
 		fmt.Fprintf(os.Stderr, "  Country:    %s\n", params.Country)
 	} else {
 		fmt.Fprintf(os.Stderr, "  Center:     %.4f, %.4f (r=%.1fkm)\n", params.Lat, params.Lng, params.Radius)
 	}
-	fmt.Fprintf(os.Stderr, "  Sectors:    %d\n", len(sectors)*len(params.Queries))
+	fmt.Fprintf(os.Stderr, "  Sectors:    %d\n", stats.SectorsTotal.Load())
+	if n := stats.Subdivided.Load(); n > 0 {
+		fmt.Fprintf(os.Stderr, "  Subdivided: %d saturated sectors\n", n)
+	}
 	fmt.Fprintf(os.Stderr, "  Found:      %d\n", stats.BusinessesFound.Load())
 	fmt.Fprintf(os.Stderr, "  Stored:     %d (unique)\n", total)
 	fmt.Fprintf(os.Stderr, "  Errors:     %d\n", stats.Errors.Load())
+	if alerts := stats.Coverage().Alerts(); len(alerts) > 0 {
+		fmt.Fprintf(os.Stderr, "  Parser:     ⚠ %d fields dropped, response layout may have changed\n", len(alerts))
+		for _, a := range alerts {
+			fmt.Fprintf(os.Stderr, "    %s\n", a)
+		}
+	}
+	if proxies := stats.Proxies(); len(proxies) > 0 {
+		fmt.Fprintf(os.Stderr, "  Proxies:    %d\n", len(proxies))
+		for _, p := range proxies {
+			fmt.Fprintf(os.Stderr, "    %-32s ok=%d fail=%d rl=%d benched=%d\n",
+				truncateMiddle(p.URL, 32), p.Successes.Load(), p.Failures.Load(), p.RateLimits.Load(), p.Benched.Load())
+		}
+	}
+	if counts, err := store.JobCounts(); err == nil {
+		if pending := storage.Unfinished(counts); pending > 0 {
+			fmt.Fprintf(os.Stderr, "  Unfinished: %d jobs (run 'geotap resume -db %s')\n", pending, params.DBPath)
+		}
+	}
 	fmt.Fprintf(os.Stderr, "  Duration:   %s\n", duration)
 	fmt.Fprintf(os.Stderr, "  Database:   %s\n", params.DBPath)
 	fmt.Fprintf(os.Stderr, "  Log:        %s\n", logPath)
 	fmt.Fprintf(os.Stderr, "══════════════════════════════\n")
+}
 
-	tui.SaveRecent(params.DBPath)
-
-	return nil
+// truncateMiddle shortens s to n runes, keeping both ends.
+func truncateMiddle(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n || n < 5 {
+		return s
+	}
+	half := (n - 1) / 2
+	return string(r[:half]) + "…" + string(r[len(r)-(n-1-half):])
 }
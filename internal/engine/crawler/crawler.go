@@ -0,0 +1,285 @@
+// Package crawler visits the websites of scanned businesses and extracts
+// contact emails, social profile URLs and phone numbers. It uses a plain
+// HTTP client: unlike Google, business websites do not need the scraper's
+// browser fingerprinting, and the crawl has its own concurrency limit.
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	userAgent   = "Mozilla/5.0 (compatible; geotap-contacts/1.0; +https://github.com/rendis/geotap)"
+	maxBodySize = 2 << 20 // bytes read per page
+)
+
+// ErrRobots is returned when robots.txt disallows the website's homepage.
+var ErrRobots = errors.New("disallowed by robots.txt")
+
+// Options bounds a website crawl.
+type Options struct {
+	MaxPages    int           // pages fetched per website, homepage included (default 5)
+	MaxDepth    int           // link hops followed from the homepage (default 1, negative for the homepage only)
+	Timeout     time.Duration // per request (default 10s)
+	SiteTimeout time.Duration // per website, all pages (default 30s)
+}
+
+func (o *Options) defaults() {
+	if o.MaxPages <= 0 {
+		o.MaxPages = 5
+	}
+	if o.MaxDepth < 0 {
+		o.MaxDepth = 0
+	} else if o.MaxDepth == 0 {
+		o.MaxDepth = 1
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.SiteTimeout <= 0 {
+		o.SiteTimeout = 30 * time.Second
+	}
+}
+
+// Result is what a crawl found on one website. Contacts map each value to
+// the first page it was found on.
+type Result struct {
+	Pages   int
+	Emails  map[string]string
+	Socials map[string]Social // by profile URL
+	Phones  map[string]string
+}
+
+// Social is a social profile link.
+type Social struct {
+	Network string
+	Source  string
+}
+
+// Crawler fetches business websites. It is safe for concurrent use.
+type Crawler struct {
+	http *http.Client
+	opts Options
+
+	mu     sync.Mutex
+	robots map[string]*robots // by scheme://host
+}
+
+// New creates a crawler with opts; zero fields take their defaults.
+func New(opts Options) *Crawler {
+	opts.defaults()
+	return &Crawler{
+		http: &http.Client{
+			Timeout: opts.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("too many redirects")
+				}
+				return nil
+			},
+		},
+		opts:   opts,
+		robots: make(map[string]*robots),
+	}
+}
+
+// Crawl fetches website's homepage and follows its contact and about links
+// on the same site, breadth first, up to MaxDepth hops and MaxPages pages,
+// skipping paths robots.txt disallows. It fails only when the homepage
+// cannot be fetched.
+func (c *Crawler) Crawl(ctx context.Context, website string) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.SiteTimeout)
+	defer cancel()
+
+	home, err := parseWebsite(website)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		Emails:  make(map[string]string),
+		Socials: make(map[string]Social),
+		Phones:  make(map[string]string),
+	}
+	seen := map[string]bool{pageKey(home): true}
+	level := []*url.URL{home}
+
+	for depth := 0; len(level) > 0 && depth <= c.opts.MaxDepth; depth++ {
+		var next []*url.URL
+		for _, u := range level {
+			if res.Pages >= c.opts.MaxPages || ctx.Err() != nil {
+				return res, nil
+			}
+			if !c.allowed(ctx, u) {
+				if depth == 0 {
+					return nil, ErrRobots
+				}
+				continue
+			}
+
+			body, final, err := c.get(ctx, u)
+			if err != nil {
+				if depth == 0 {
+					return nil, err
+				}
+				continue
+			}
+			res.Pages++
+			if depth == 0 {
+				// Follow links relative to where the homepage redirected
+				home = final
+				seen[pageKey(final)] = true
+			}
+
+			p := extract(body)
+			source := final.String()
+			for _, e := range p.emails {
+				addFirst(res.Emails, e, source)
+			}
+			for _, t := range p.phones {
+				addFirst(res.Phones, t, source)
+			}
+			for profile, network := range p.socials {
+				if _, ok := res.Socials[profile]; !ok {
+					res.Socials[profile] = Social{Network: network, Source: source}
+				}
+			}
+
+			if depth == c.opts.MaxDepth {
+				continue
+			}
+			for _, l := range p.links {
+				v, err := final.Parse(l.href)
+				if err != nil || !sameSite(v, home) || seen[pageKey(v)] || !isContactLink(l, v) {
+					continue
+				}
+				v.Fragment = ""
+				seen[pageKey(v)] = true
+				next = append(next, v)
+			}
+		}
+		// Contact pages first, then about pages, in a stable order
+		sort.SliceStable(next, func(i, j int) bool {
+			return strings.Contains(next[i].Path, "conta") && !strings.Contains(next[j].Path, "conta")
+		})
+		level = next
+	}
+	return res, nil
+}
+
+// get fetches an HTML page and returns its body and final URL.
+func (c *Crawler) get(ctx context.Context, u *url.URL) (string, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		if mt, _, _ := mime.ParseMediaType(ct); mt != "text/html" && mt != "application/xhtml+xml" {
+			return "", nil, fmt.Errorf("not a page: %s", mt)
+		}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return "", nil, err
+	}
+	return string(body), resp.Request.URL, nil
+}
+
+// allowed reports whether robots.txt of u's host allows fetching u. The
+// rules are fetched once per host; a missing or unreadable robots.txt
+// allows everything.
+func (c *Crawler) allowed(ctx context.Context, u *url.URL) bool {
+	origin := u.Scheme + "://" + u.Host
+
+	c.mu.Lock()
+	r, ok := c.robots[origin]
+	c.mu.Unlock()
+	if !ok {
+		r = c.fetchRobots(ctx, origin)
+		c.mu.Lock()
+		c.robots[origin] = r
+		c.mu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return r.allowed(path)
+}
+
+func (c *Crawler) fetchRobots(ctx context.Context, origin string) *robots {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 512<<10))
+	if err != nil {
+		return nil
+	}
+	return parseRobots(string(body), "geotap")
+}
+
+// parseWebsite turns a stored website into an absolute http(s) URL.
+func parseWebsite(website string) (*url.URL, error) {
+	website = strings.TrimSpace(website)
+	if !strings.Contains(website, "://") {
+		website = "http://" + website
+	}
+	u, err := url.Parse(website)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid website %q", website)
+	}
+	return u, nil
+}
+
+// sameSite reports whether u is on home's host, ignoring "www.".
+func sameSite(u, home *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Host), "www.") == strings.TrimPrefix(strings.ToLower(home.Host), "www.")
+}
+
+// pageKey identifies a page for the visited set: host and path, without
+// query, fragment or trailing slash.
+func pageKey(u *url.URL) string {
+	return strings.TrimPrefix(strings.ToLower(u.Host), "www.") + strings.TrimRight(u.Path, "/")
+}
+
+func addFirst(m map[string]string, key, source string) {
+	if _, ok := m[key]; !ok {
+		m[key] = source
+	}
+}
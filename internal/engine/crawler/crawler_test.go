@@ -0,0 +1,268 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// site is a business website stand-in that records the paths it served.
+type site struct {
+	*httptest.Server
+	mu    sync.Mutex
+	paths []string
+}
+
+// newSite serves pages by path, text/html, and robots.txt when robots is
+// not empty; other paths are 404.
+func newSite(t *testing.T, robots string, pages map[string]string) *site {
+	t.Helper()
+	s := &site{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			if robots == "" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, robots)
+			return
+		}
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.mu.Lock()
+		s.paths = append(s.paths, r.URL.Path)
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *site) served() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.paths...)
+}
+
+func TestCrawl(t *testing.T) {
+	// Another site the homepage links to, which is never crawled
+	var otherHits atomic.Int64
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otherHits.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<a href="mailto:someone@other.test">mail</a>`)
+	}))
+	defer other.Close()
+
+	pages := map[string]string{
+		"/": `<a href="/about-us">About us</a>
+			<a href="/blog">Blog</a>
+			<a href="/contacto">Contacto</a>
+			<a href="` + other.URL + `/contact">Partner contact</a>
+			<a href="https://www.instagram.com/cafesol">IG</a>
+			<p>hola [at] cafe-sol [dot] es</p>`,
+		"/about-us":        `<p>Since 1990.</p><a href="/contacto/equipo">Equipo</a>`,
+		"/contacto":        `<a href="mailto:reservas@cafe-sol.es">Reservas</a><a href="tel:+34600000001">Call</a>`,
+		"/contacto/equipo": `<a href="mailto:ana@cafe-sol.es">Ana</a>`,
+		"/blog":            `<a href="mailto:blog@cafe-sol.es">Blog</a>`,
+	}
+	s := newSite(t, "", pages)
+
+	tests := []struct {
+		name   string
+		opts   Options
+		paths  []string
+		emails []string
+	}{
+		// Contact pages come before about pages, whatever the link order
+		{"default depth", Options{}, []string{"/", "/contacto", "/about-us"},
+			[]string{"hola@cafe-sol.es", "reservas@cafe-sol.es"}},
+		{"homepage only", Options{MaxDepth: -1}, []string{"/"},
+			[]string{"hola@cafe-sol.es"}},
+		{"two hops", Options{MaxDepth: 2}, []string{"/", "/contacto", "/about-us", "/contacto/equipo"},
+			[]string{"ana@cafe-sol.es", "hola@cafe-sol.es", "reservas@cafe-sol.es"}},
+		{"page limit", Options{MaxDepth: 2, MaxPages: 2}, []string{"/", "/contacto"},
+			[]string{"hola@cafe-sol.es", "reservas@cafe-sol.es"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.mu.Lock()
+			s.paths = nil
+			s.mu.Unlock()
+
+			res, err := New(tt.opts).Crawl(context.Background(), s.URL)
+			if err != nil {
+				t.Fatalf("Crawl: %v", err)
+			}
+			if got := s.served(); !reflect.DeepEqual(got, tt.paths) {
+				t.Errorf("fetched %v, want %v", got, tt.paths)
+			}
+			if res.Pages != len(tt.paths) {
+				t.Errorf("pages = %d, want %d", res.Pages, len(tt.paths))
+			}
+			var emails []string
+			for e := range res.Emails {
+				emails = append(emails, e)
+			}
+			if !sameSet(emails, tt.emails) {
+				t.Errorf("emails = %v, want %v", emails, tt.emails)
+			}
+			if res.Emails["hola@cafe-sol.es"] != s.URL {
+				t.Errorf("hola@ found on %q, want the homepage", res.Emails["hola@cafe-sol.es"])
+			}
+			if sc := res.Socials["https://instagram.com/cafesol"]; sc.Network != "instagram" {
+				t.Errorf("socials = %v, want the Instagram profile", res.Socials)
+			}
+		})
+	}
+	if n := otherHits.Load(); n != 0 {
+		t.Errorf("the other site was fetched %d times, want never", n)
+	}
+
+	res, _ := New(Options{}).Crawl(context.Background(), s.URL)
+	if src := res.Phones["+34600000001"]; src != s.URL+"/contacto" {
+		t.Errorf("phone found on %q, want the contact page", src)
+	}
+}
+
+func TestCrawlRobots(t *testing.T) {
+	pages := map[string]string{
+		"/":          `<a href="/contact">Contact</a><a href="/about">About</a>`,
+		"/contact":   `<a href="mailto:info@cafe-sol.es">Mail</a>`,
+		"/about":     `<p>About</p>`,
+		"/about/faq": `<p>FAQ</p>`,
+	}
+
+	t.Run("disallowed page", func(t *testing.T) {
+		s := newSite(t, "User-agent: *\nDisallow: /contact\n", pages)
+		res, err := New(Options{}).Crawl(context.Background(), s.URL)
+		if err != nil {
+			t.Fatalf("Crawl: %v", err)
+		}
+		if got := s.served(); !reflect.DeepEqual(got, []string{"/", "/about"}) {
+			t.Errorf("fetched %v, want /contact skipped", got)
+		}
+		if len(res.Emails) != 0 {
+			t.Errorf("emails = %v, want none", res.Emails)
+		}
+	})
+
+	t.Run("disallowed homepage", func(t *testing.T) {
+		s := newSite(t, "User-agent: geotap\nDisallow: /\n\nUser-agent: *\nAllow: /\n", pages)
+		if _, err := New(Options{}).Crawl(context.Background(), s.URL); !errors.Is(err, ErrRobots) {
+			t.Errorf("Crawl = %v, want ErrRobots", err)
+		}
+		if got := s.served(); len(got) != 0 {
+			t.Errorf("fetched %v, want nothing", got)
+		}
+	})
+
+	t.Run("homepage errors", func(t *testing.T) {
+		s := newSite(t, "", map[string]string{"/contact": "<p>hi</p>"})
+		if _, err := New(Options{}).Crawl(context.Background(), s.URL); err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("Crawl of a missing homepage = %v, want the status", err)
+		}
+	})
+}
+
+func TestParseRobots(t *testing.T) {
+	body := `# comment
+User-agent: Googlebot
+Disallow: /
+
+User-agent: geotap
+User-agent: other
+Disallow: /private
+Allow: /private/contact$
+Disallow: /*.pdf$
+Disallow: /tmp/*/cache
+
+User-agent: *
+Disallow: /everything
+`
+	r := parseRobots(body, "geotap")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/everything", true}, // the * group does not apply when ours exists
+		{"/private", false},
+		{"/private/team", false},
+		{"/private/contact", true},
+		{"/private/contact/more", false},
+		{"/menu.pdf", false},
+		{"/menu.pdf.html", true},
+		{"/tmp/a/b/cache/x", false},
+		{"/tmp/cache", true},
+	}
+	for _, tt := range tests {
+		if got := r.allowed(tt.path); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	star := parseRobots("User-agent: *\nDisallow: /admin\nDisallow:\n", "geotap")
+	if star.allowed("/admin/login") || !star.allowed("/contact") {
+		t.Errorf("the * group is not applied: %+v", star)
+	}
+	var none *robots
+	if !none.allowed("/anything") || !parseRobots("", "geotap").allowed("/anything") {
+		t.Error("a missing robots.txt disallows, want everything allowed")
+	}
+}
+
+func TestSiteCache(t *testing.T) {
+	s := newSite(t, "", map[string]string{"/": `<a href="mailto:info@cafe-sol.es">Mail</a>`})
+	c := New(Options{})
+	sites := &siteCache{results: make(map[string]*siteResult)}
+
+	// Branches of a chain share the website, written in different ways
+	var fetched atomic.Int64
+	var wg sync.WaitGroup
+	for _, website := range []string{s.URL, s.URL + "/", s.URL + "/?utm_source=maps"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, f, err := sites.crawl(context.Background(), c, website)
+			if f {
+				fetched.Add(1)
+			}
+			if err != nil || res.Emails["info@cafe-sol.es"] == "" {
+				t.Errorf("%s: crawl = %v, want the site's emails", website, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := fetched.Load(); n != 1 {
+		t.Errorf("crawled %d times, want once", n)
+	}
+}
+
+// sameSet reports whether a and b hold the same strings in any order.
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, s := range a {
+		seen[s] = true
+	}
+	for _, s := range b {
+		if !seen[s] {
+			return false
+		}
+	}
+	return true
+}
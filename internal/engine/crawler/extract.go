@@ -0,0 +1,164 @@
+package crawler
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	hrefRe      = regexp.MustCompile(`(?is)<a\s[^>]*?href\s*=\s*["']([^"']+)["'][^>]*>(.*?)</a>`)
+	emailRe     = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,24}`)
+	telephoneRe = regexp.MustCompile(`"telephone"\s*:\s*"([^"]+)"`)
+	tagRe       = regexp.MustCompile(`<[^>]*>`)
+	scriptRe    = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+)
+
+// socialHosts maps the hosts of supported social networks to their names.
+var socialHosts = map[string]string{
+	"instagram.com": "instagram",
+	"facebook.com":  "facebook",
+	"fb.com":        "facebook",
+	"linkedin.com":  "linkedin",
+	"twitter.com":   "x",
+	"x.com":         "x",
+	"tiktok.com":    "tiktok",
+	"youtube.com":   "youtube",
+}
+
+// socialNoise are first path segments of share buttons, embeds and tracking
+// pixels rather than a business's own profile.
+var socialNoise = map[string]bool{
+	"sharer": true, "share": true, "sharearticle": true, "intent": true, "plugins": true,
+	"tr": true, "dialog": true, "embed": true, "hashtag": true, "watch": true, "login": true,
+}
+
+// emailNoise are address suffixes that are assets or vendor placeholders,
+// not contact addresses.
+var emailNoise = []string{".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", "@example.com", "@sentry.io", "@wixpress.com", "@domain.com"}
+
+// contactWords mark links worth following from the homepage, in English,
+// Spanish, Portuguese, French, German and Italian.
+var contactWords = []string{"contact", "contacto", "contato", "kontakt", "impressum", "about", "nosotros", "quienes", "sobre", "a-propos", "chi-siamo", "legal", "aviso"}
+
+// link is an anchor of a page.
+type link struct {
+	href string
+	text string
+}
+
+// page is what was found on one fetched page.
+type page struct {
+	emails  []string
+	socials map[string]string // profile URL → network
+	phones  []string
+	links   []link
+}
+
+// extract finds the contacts and links of an HTML page.
+func extract(body string) page {
+	var p page
+	p.socials = map[string]string{}
+
+	for _, m := range hrefRe.FindAllStringSubmatch(body, -1) {
+		href := strings.TrimSpace(html.UnescapeString(m[1]))
+		lower := strings.ToLower(href)
+		switch {
+		case strings.HasPrefix(lower, "mailto:"):
+			addr, _, _ := strings.Cut(href[len("mailto:"):], "?")
+			if a, err := url.PathUnescape(addr); err == nil {
+				addr = a
+			}
+			for _, e := range strings.Split(addr, ",") {
+				if e = cleanEmail(e); e != "" {
+					p.emails = append(p.emails, e)
+				}
+			}
+		case strings.HasPrefix(lower, "tel:"):
+			tel := href[len("tel:"):]
+			if t, err := url.PathUnescape(tel); err == nil {
+				tel = t
+			}
+			if tel = strings.TrimSpace(tel); tel != "" {
+				p.phones = append(p.phones, tel)
+			}
+		default:
+			if u, network := socialProfile(href); u != "" {
+				p.socials[u] = network
+				continue
+			}
+			text := strings.TrimSpace(html.UnescapeString(tagRe.ReplaceAllString(m[2], " ")))
+			p.links = append(p.links, link{href: href, text: text})
+		}
+	}
+
+	// Addresses written out in the text, once scripts and tags are gone
+	text := html.UnescapeString(tagRe.ReplaceAllString(scriptRe.ReplaceAllString(body, " "), " "))
+	text = strings.NewReplacer(" [at] ", "@", " (at) ", "@", "[at]", "@", "(at)", "@", " [dot] ", ".", "[dot]", ".").Replace(text)
+	for _, e := range emailRe.FindAllString(text, -1) {
+		if e = cleanEmail(e); e != "" {
+			p.emails = append(p.emails, e)
+		}
+	}
+
+	// schema.org JSON-LD
+	for _, m := range telephoneRe.FindAllStringSubmatch(body, -1) {
+		p.phones = append(p.phones, strings.TrimSpace(m[1]))
+	}
+	return p
+}
+
+// cleanEmail lowercases an address and drops asset names and placeholders.
+func cleanEmail(e string) string {
+	e = strings.ToLower(strings.Trim(strings.TrimSpace(e), ".,;:"))
+	if !emailRe.MatchString(e) {
+		return ""
+	}
+	for _, n := range emailNoise {
+		if strings.HasSuffix(e, n) {
+			return ""
+		}
+	}
+	return e
+}
+
+// socialProfile returns the canonical profile URL and network of href, or
+// "" when it is not a social profile link.
+func socialProfile(href string) (string, string) {
+	u, err := url.Parse(href)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	// Country subdomains such as es.linkedin.com
+	if i := strings.IndexByte(host, '.'); i >= 0 && socialHosts[host] == "" {
+		host = host[i+1:]
+	}
+	network := socialHosts[host]
+	if network == "" {
+		return "", ""
+	}
+
+	path := strings.TrimRight(u.Path, "/")
+	if path == "" {
+		return "", ""
+	}
+	first, _, _ := strings.Cut(strings.ToLower(path[1:]), "/")
+	if socialNoise[strings.TrimSuffix(first, ".php")] {
+		return "", ""
+	}
+	return "https://" + host + path, network
+}
+
+// isContactLink reports whether a link looks like a contact or about page.
+func isContactLink(l link, u *url.URL) bool {
+	s := strings.ToLower(u.Path + " " + l.text)
+	for _, w := range contactWords {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package crawler
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	body := `<html><head>
+<script>var sentry = "abc@sentry.io"; var x = "not@an-address.js";</script>
+<script type="application/ld+json">{"@type": "Restaurant", "telephone": "+34 912 34 56 78"}</script>
+</head><body>
+<a href="mailto:Reservas@Cafe-Sol.es?subject=Mesa">Book</a>
+<a href="mailto:info%40cafe-sol.es,eventos@cafe-sol.es">Write</a>
+<a href="&#109;&#97;&#105;&#108;&#116;&#111;&#58;&#106;&#111;&#98;&#115;&#64;&#99;&#97;&#102;&#101;&#45;&#115;&#111;&#108;&#46;&#101;&#115;">Jobs</a>
+<a href="tel:+34%20600%2000%2000%2001">Call</a>
+<a href="https://www.instagram.com/cafesol/">Instagram</a>
+<a href="https://m.facebook.com/CafeSol">Facebook</a>
+<a href="https://es.linkedin.com/company/cafe-sol">LinkedIn</a>
+<a href="https://www.facebook.com/sharer/sharer.php?u=https://cafe-sol.es">Share</a>
+<a href="https://twitter.com/intent/tweet?text=hi">Tweet</a>
+<a href="https://www.youtube.com/watch?v=abc">Video</a>
+<a href="/contacto"><span>Contacto</span></a>
+<a href='/blog'>Blog</a>
+<img src="logo@2x.png">
+<p>Write to prensa [at] cafe-sol [dot] es or ventas(at)cafe-sol.es, not user@example.com.</p>
+</body></html>`
+
+	p := extract(body)
+
+	slices.Sort(p.emails)
+	p.emails = slices.Compact(p.emails)
+	wantEmails := []string{"eventos@cafe-sol.es", "info@cafe-sol.es", "jobs@cafe-sol.es", "prensa@cafe-sol.es", "reservas@cafe-sol.es", "ventas@cafe-sol.es"}
+	if !reflect.DeepEqual(p.emails, wantEmails) {
+		t.Errorf("emails = %v, want %v", p.emails, wantEmails)
+	}
+
+	wantSocials := map[string]string{
+		"https://instagram.com/cafesol":         "instagram",
+		"https://facebook.com/CafeSol":          "facebook",
+		"https://linkedin.com/company/cafe-sol": "linkedin",
+	}
+	if !reflect.DeepEqual(p.socials, wantSocials) {
+		t.Errorf("socials = %v, want %v", p.socials, wantSocials)
+	}
+
+	wantPhones := []string{"+34 600 00 00 01", "+34 912 34 56 78"}
+	if !reflect.DeepEqual(p.phones, wantPhones) {
+		t.Errorf("phones = %v, want %v", p.phones, wantPhones)
+	}
+
+	// Share buttons are plain links, which the crawl does not follow off site
+	wantLinks := []link{
+		{href: "https://www.facebook.com/sharer/sharer.php?u=https://cafe-sol.es", text: "Share"},
+		{href: "https://twitter.com/intent/tweet?text=hi", text: "Tweet"},
+		{href: "https://www.youtube.com/watch?v=abc", text: "Video"},
+		{href: "/contacto", text: "Contacto"},
+		{href: "/blog", text: "Blog"},
+	}
+	if !reflect.DeepEqual(p.links, wantLinks) {
+		t.Errorf("links = %+v, want %+v", p.links, wantLinks)
+	}
+}
+
+func TestSocialProfile(t *testing.T) {
+	tests := []struct {
+		href, url, network string
+	}{
+		{"https://www.instagram.com/cafesol/", "https://instagram.com/cafesol", "instagram"},
+		{"http://fb.com/cafesol", "https://fb.com/cafesol", "facebook"},
+		{"https://x.com/cafesol", "https://x.com/cafesol", "x"},
+		{"https://www.tiktok.com/@cafesol", "https://tiktok.com/@cafesol", "tiktok"},
+		{"https://www.facebook.com/tr?id=1", "", ""},
+		{"https://www.facebook.com/", "", ""},
+		{"https://www.linkedin.com/shareArticle?url=x", "", ""},
+		{"https://example.org/instagram", "", ""},
+		{"mailto:instagram.com", "", ""},
+	}
+	for _, tt := range tests {
+		u, network := socialProfile(tt.href)
+		if u != tt.url || network != tt.network {
+			t.Errorf("socialProfile(%q) = %q, %q, want %q, %q", tt.href, u, network, tt.url, tt.network)
+		}
+	}
+}
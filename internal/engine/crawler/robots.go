@@ -0,0 +1,120 @@
+package crawler
+
+import (
+	"bufio"
+	"strings"
+)
+
+// robots holds the Allow/Disallow rules of a robots.txt that apply to us:
+// the group for our user agent if there is one, the "*" group otherwise.
+type robots struct {
+	allow    []string
+	disallow []string
+}
+
+// parseRobots reads the rules of robots.txt for agent (matched as a
+// case-insensitive substring of the User-agent lines).
+func parseRobots(body, agent string) *robots {
+	agent = strings.ToLower(agent)
+	groups := map[string]*robots{} // "self" or "*"
+
+	var current []string // group keys of the User-agent lines being read
+	inRules := false
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "user-agent":
+			// A User-agent after rules starts a new group
+			if inRules {
+				current, inRules = nil, false
+			}
+			ua := strings.ToLower(value)
+			switch {
+			case ua == "*":
+				current = append(current, "*")
+			case ua != "" && strings.Contains(agent, ua):
+				current = append(current, "self")
+			}
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue // "Disallow:" with no path allows everything
+			}
+			for _, key := range current {
+				g := groups[key]
+				if g == nil {
+					g = &robots{}
+					groups[key] = g
+				}
+				if field == "allow" {
+					g.allow = append(g.allow, value)
+				} else {
+					g.disallow = append(g.disallow, value)
+				}
+			}
+		}
+	}
+
+	if g := groups["self"]; g != nil {
+		return g
+	}
+	if g := groups["*"]; g != nil {
+		return g
+	}
+	return &robots{}
+}
+
+// allowed reports whether path may be fetched. The longest matching rule
+// wins and Allow wins ties, as in Google's interpretation.
+func (r *robots) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	best, allow := -1, true
+	match := func(rules []string, isAllow bool) {
+		for _, rule := range rules {
+			if matchRule(rule, path) && (len(rule) > best || (len(rule) == best && isAllow)) {
+				best, allow = len(rule), isAllow
+			}
+		}
+	}
+	match(r.disallow, false)
+	match(r.allow, true)
+	return allow
+}
+
+// matchRule reports whether a robots.txt path rule matches path. A "*"
+// matches any run of characters and a trailing "$" anchors the rule at the
+// end of the path; otherwise a rule matches as a prefix.
+func matchRule(rule, path string) bool {
+	anchored := strings.HasSuffix(rule, "$")
+	rule = strings.TrimSuffix(rule, "$")
+
+	parts := strings.Split(rule, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
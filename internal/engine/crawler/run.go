@@ -0,0 +1,217 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+)
+
+// Stats holds the counters of a contacts crawl.
+type Stats struct {
+	PlacesTotal atomic.Int64
+	PlacesDone  atomic.Int64
+	Pages       atomic.Int64
+	Emails      atomic.Int64 // contacts found, by kind
+	Socials     atomic.Int64
+	Phones      atomic.Int64
+	Errors      atomic.Int64
+}
+
+// RunOptions provides optional settings for Run.
+type RunOptions struct {
+	Crawl Options
+	// Concurrency is the number of websites crawled at once (default 8).
+	Concurrency int
+	// SuppressStderr disables the built-in stderr progress reporter.
+	SuppressStderr bool
+	// Stats allows passing an external Stats for live progress tracking.
+	Stats *Stats
+}
+
+// Run crawls the website of every place in the store's contacts ledger that
+// is not done yet and stores what it finds. Call Store.EnqueueContactJobs
+// first to queue the scanned places. Places sharing a website (chains) are
+// crawled once per run.
+func Run(ctx context.Context, store *storage.Store, logger *log.Logger, opts *RunOptions) (*Stats, error) {
+	if opts == nil {
+		opts = &RunOptions{}
+	}
+	stats := opts.Stats
+	if stats == nil {
+		stats = &Stats{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+
+	jobs, err := store.UnfinishedContactJobs()
+	if err != nil {
+		return stats, err
+	}
+	stats.PlacesTotal.Store(int64(len(jobs)))
+	logger.Printf("CONTACTS %d places concurrency=%d", len(jobs), concurrency)
+
+	c := New(opts.Crawl)
+	sites := &siteCache{results: make(map[string]*siteResult)}
+
+	done := make(chan struct{})
+	var reporter sync.WaitGroup
+	reporter.Add(1)
+	go func() {
+		defer reporter.Done()
+		report(done, stats, logger, opts.SuppressStderr)
+	}()
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+loop:
+	for _, job := range jobs {
+		select {
+		case <-ctx.Done():
+			break loop
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			crawlPlace(ctx, c, sites, store, logger, job, stats)
+		}()
+	}
+	wg.Wait()
+	close(done)
+	reporter.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// crawlPlace crawls one place's website and stores its contacts.
+func crawlPlace(ctx context.Context, c *Crawler, sites *siteCache, store *storage.Store, logger *log.Logger, job storage.ContactJob, stats *Stats) {
+	defer stats.PlacesDone.Add(1)
+
+	res, fetched, err := sites.crawl(ctx, c, job.Website)
+	if err != nil {
+		if ctx.Err() != nil {
+			updateJob(store, logger, job, storage.JobInterrupted, 0, nil)
+			return
+		}
+		logger.Printf("CONTACTS_ERROR cid=%s website=%s err=%v", job.CID, job.Website, err)
+		stats.Errors.Add(1)
+		updateJob(store, logger, job, storage.JobFailed, 0, err)
+		return
+	}
+	if fetched {
+		stats.Pages.Add(int64(res.Pages))
+	}
+
+	var contacts []model.Contact
+	for e, src := range res.Emails {
+		contacts = append(contacts, model.Contact{CID: job.CID, Kind: model.ContactEmail, Value: e, Source: src})
+	}
+	for u, s := range res.Socials {
+		contacts = append(contacts, model.Contact{CID: job.CID, Kind: model.ContactSocial, Value: u, Network: s.Network, Source: s.Source})
+	}
+	for p, src := range res.Phones {
+		contacts = append(contacts, model.Contact{CID: job.CID, Kind: model.ContactPhone, Value: p, Source: src})
+	}
+	if _, err := store.InsertContacts(contacts); err != nil {
+		stats.Errors.Add(1)
+		updateJob(store, logger, job, storage.JobFailed, res.Pages, err)
+		return
+	}
+	stats.Emails.Add(int64(len(res.Emails)))
+	stats.Socials.Add(int64(len(res.Socials)))
+	stats.Phones.Add(int64(len(res.Phones)))
+	updateJob(store, logger, job, storage.JobDone, res.Pages, nil)
+}
+
+// siteCache crawls each website once per run, however many places share it.
+type siteCache struct {
+	mu      sync.Mutex
+	results map[string]*siteResult
+}
+
+type siteResult struct {
+	once sync.Once
+	res  *Result
+	err  error
+}
+
+// crawl returns the crawl of website, and whether this call fetched it.
+func (s *siteCache) crawl(ctx context.Context, c *Crawler, website string) (*Result, bool, error) {
+	key := website
+	if u, err := parseWebsite(website); err == nil {
+		key = pageKey(u)
+	}
+
+	s.mu.Lock()
+	r, ok := s.results[key]
+	if !ok {
+		r = &siteResult{}
+		s.results[key] = r
+	}
+	s.mu.Unlock()
+
+	fetched := false
+	r.once.Do(func() {
+		fetched = true
+		r.res, r.err = c.Crawl(ctx, website)
+	})
+	return r.res, fetched, r.err
+}
+
+// updateJob updates the contacts ledger, logging (but not failing on)
+// storage errors.
+func updateJob(store *storage.Store, logger *log.Logger, job storage.ContactJob, status string, pages int, jobErr error) {
+	var msg string
+	if jobErr != nil {
+		msg = jobErr.Error()
+	}
+	if err := store.UpdateContactJob(job.CID, status, pages, msg); err != nil {
+		logger.Printf("LEDGER cid=%s err=%v", job.CID, err)
+	}
+}
+
+// report prints progress to stderr every 2s and to the log every 10s until
+// done is closed.
+func report(done <-chan struct{}, stats *Stats, logger *log.Logger, suppressStderr bool) {
+	startTime := time.Now()
+	line := func() string {
+		return fmt.Sprintf("[%d/%d places] %d pages | %d emails | %d socials | %d phones | %d errors",
+			stats.PlacesDone.Load(), stats.PlacesTotal.Load(), stats.Pages.Load(),
+			stats.Emails.Load(), stats.Socials.Load(), stats.Phones.Load(), stats.Errors.Load())
+	}
+
+	ticker := time.NewTicker(2 * time.Second)
+	logTicker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	defer logTicker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !suppressStderr {
+				fmt.Fprintf(os.Stderr, "\r%s | %s", line(), time.Since(startTime).Truncate(time.Second))
+			}
+		case <-logTicker.C:
+			logger.Printf("PROGRESS places=%d/%d pages=%d emails=%d socials=%d phones=%d errors=%d elapsed=%s",
+				stats.PlacesDone.Load(), stats.PlacesTotal.Load(), stats.Pages.Load(), stats.Emails.Load(),
+				stats.Socials.Load(), stats.Phones.Load(), stats.Errors.Load(), time.Since(startTime).Truncate(time.Second))
+		case <-done:
+			if !suppressStderr {
+				fmt.Fprintf(os.Stderr, "\r%s | %s\n", line(), time.Since(startTime).Truncate(time.Second))
+			}
+			return
+		}
+	}
+}
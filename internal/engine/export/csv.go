@@ -0,0 +1,83 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// csvHeader are the CSV columns, one row per place.
+var csvHeader = []string{
+	"name", "rating", "review_count", "category", "categories",
+	"address", "city", "postal_code", "country_code",
+	"lat", "lng", "phone", "phone_raw", "phone_type", "phone_valid",
+	"website", "google_url", "description", "price_range", "queries", "opening_hours",
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, _ string) (Writer, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(csvHeader); err != nil {
+		return nil, fmt.Errorf("writing csv: %w", err)
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(b *model.Business) error {
+	err := cw.w.Write([]string{
+		b.Name,
+		fmt.Sprintf("%.1f", b.Rating),
+		fmt.Sprintf("%d", b.ReviewCount),
+		b.Category,
+		b.Categories,
+		b.Address,
+		b.City,
+		b.PostalCode,
+		b.CountryCode,
+		fmt.Sprintf("%.6f", b.Lat),
+		fmt.Sprintf("%.6f", b.Lng),
+		b.PhoneNumber.Normalized(),
+		b.Phone,
+		phoneType(b.PhoneNumber),
+		phoneValid(b.PhoneNumber),
+		b.Website,
+		b.GoogleURL,
+		b.Description,
+		b.PriceRange,
+		strings.Join(b.Queries, ", "),
+		b.Hours.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return nil
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	if err := cw.w.Error(); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return nil
+}
+
+// phoneType and phoneValid render the parsed phone columns of the CSV.
+func phoneType(p *model.PhoneNumber) string {
+	if p == nil {
+		return ""
+	}
+	return p.Type
+}
+
+func phoneValid(p *model.PhoneNumber) string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%t", p.Valid)
+}
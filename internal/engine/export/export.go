@@ -0,0 +1,172 @@
+// Package export writes scanned places to files for other tools:
+// spreadsheets, data pipelines, GIS, mapping and GPS apps.
+package export
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// Writer writes places to an export document one at a time.
+type Writer interface {
+	Write(b *model.Business) error
+	// Close finishes the document. It does not close the underlying writer.
+	Close() error
+}
+
+// Format is an export file format.
+type Format struct {
+	Name string // -format value
+	Ext  string // file extension, with the dot
+	// New starts a document on w. Only columnar formats use compression:
+	// they compress their own pages instead of being wrapped in a
+	// compressed stream.
+	New      func(w io.Writer, compression string) (Writer, error)
+	Columnar bool
+}
+
+// Formats are the supported export formats, CSV first.
+var Formats = []Format{
+	{"csv", ".csv", newCSVWriter, false},
+	{"geojson", ".geojson", newGeoJSONWriter, false},
+	{"kml", ".kml", newKMLWriter, false},
+	{"gpx", ".gpx", newGPXWriter, false},
+	{"jsonl", ".jsonl", newJSONLWriter, false},
+	{"parquet", ".parquet", newParquetWriter, true},
+}
+
+// Output compressions.
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+// compressionExts are the file extensions of the output compressions.
+var compressionExts = map[string]string{
+	Gzip: ".gz",
+	Zstd: ".zst",
+}
+
+// Names returns the names of the supported formats.
+func Names() []string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// Lookup returns the format called name.
+func Lookup(name string) (Format, error) {
+	for _, f := range Formats {
+		if f.Name == strings.ToLower(name) {
+			return f, nil
+		}
+	}
+	return Format{}, fmt.Errorf("unsupported format: %s (use %s)", name, strings.Join(Names(), ", "))
+}
+
+// CompressionOf returns the compression a file name's extension implies, ""
+// for none.
+func CompressionOf(path string) string {
+	for c, ext := range compressionExts {
+		if strings.HasSuffix(strings.ToLower(path), ext) {
+			return c
+		}
+	}
+	return ""
+}
+
+// FileExt returns the extension of a file in format f with compression:
+// ".csv.gz" for gzipped CSV, ".parquet" for Parquet whatever its codec.
+func (f Format) FileExt(compression string) string {
+	if f.Columnar {
+		return f.Ext
+	}
+	return f.Ext + compressionExts[compression]
+}
+
+// Create creates an export file at path in format f, compressed with
+// compression ("" for none, Gzip or Zstd). Closing the returned Writer
+// finishes the document and closes the file.
+func Create(path string, f Format, compression string) (Writer, error) {
+	if _, ok := compressionExts[compression]; compression != "" && !ok {
+		return nil, fmt.Errorf("unsupported compression: %s (use %s or %s)", compression, Gzip, Zstd)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating output: %w", err)
+	}
+	fw := &fileWriter{closers: []io.Closer{out}}
+
+	var w io.Writer = out
+	if !f.Columnar {
+		switch compression {
+		case Gzip:
+			gz := gzip.NewWriter(out)
+			w, fw.closers = gz, append([]io.Closer{gz}, fw.closers...)
+		case Zstd:
+			zw, err := zstd.NewWriter(out)
+			if err != nil {
+				out.Close()
+				return nil, fmt.Errorf("creating zstd writer: %w", err)
+			}
+			w, fw.closers = zw, append([]io.Closer{zw}, fw.closers...)
+		}
+	}
+
+	if fw.Writer, err = f.New(w, compression); err != nil {
+		fw.closeAll()
+		return nil, err
+	}
+	return fw, nil
+}
+
+// fileWriter is a Writer to a file, through a compressor when the output is
+// compressed.
+type fileWriter struct {
+	Writer
+	closers []io.Closer // compressor first, then the file
+}
+
+func (fw *fileWriter) Close() error {
+	err := fw.Writer.Close()
+	if cerr := fw.closeAll(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (fw *fileWriter) closeAll() error {
+	var err error
+	for _, c := range fw.closers {
+		if cerr := c.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("closing output: %w", cerr)
+		}
+	}
+	return err
+}
+
+// WriteFile exports businesses to a new, uncompressed file at path in
+// format f.
+func WriteFile(path string, f Format, businesses []model.Business) error {
+	w, err := Create(path, f, "")
+	if err != nil {
+		return err
+	}
+	for i := range businesses {
+		if err := w.Write(&businesses[i]); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
@@ -0,0 +1,65 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// geojsonWriter writes a FeatureCollection of Point features, one per
+// place, with every business field as a property.
+type geojsonWriter struct {
+	w     *bufio.Writer
+	count int
+}
+
+type geojsonFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geojsonPoint    `json:"geometry"`
+	Properties *model.Business `json:"properties"`
+}
+
+type geojsonPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"` // lng, lat
+}
+
+func newGeoJSONWriter(w io.Writer, _ string) (Writer, error) {
+	gw := &geojsonWriter{w: bufio.NewWriter(w)}
+	if _, err := gw.w.WriteString(`{"type":"FeatureCollection","features":[`); err != nil {
+		return nil, fmt.Errorf("writing geojson: %w", err)
+	}
+	return gw, nil
+}
+
+func (gw *geojsonWriter) Write(b *model.Business) error {
+	data, err := json.Marshal(geojsonFeature{
+		Type:       "Feature",
+		Geometry:   geojsonPoint{Type: "Point", Coordinates: [2]float64{b.Lng, b.Lat}},
+		Properties: b,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", b.CID, err)
+	}
+	sep := ",\n"
+	if gw.count == 0 {
+		sep = "\n"
+	}
+	gw.count++
+	gw.w.WriteString(sep)
+	if _, err := gw.w.Write(data); err != nil {
+		return fmt.Errorf("writing geojson: %w", err)
+	}
+	return nil
+}
+
+func (gw *geojsonWriter) Close() error {
+	gw.w.WriteString("\n]}\n")
+	if err := gw.w.Flush(); err != nil {
+		return fmt.Errorf("writing geojson: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+const gpxHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="geotap" xmlns="http://www.topografix.com/GPX/1/1">
+`
+
+// gpxWriter writes GPX 1.1 waypoints, one per place, with the address as
+// comment and the details GPS apps show as description.
+type gpxWriter struct {
+	w   *bufio.Writer
+	enc *xml.Encoder
+}
+
+// gpxWaypoint follows the element order of the GPX 1.1 wptType.
+type gpxWaypoint struct {
+	XMLName xml.Name  `xml:"wpt"`
+	Lat     string    `xml:"lat,attr"`
+	Lon     string    `xml:"lon,attr"`
+	Name    string    `xml:"name"`
+	Comment string    `xml:"cmt,omitempty"`
+	Desc    string    `xml:"desc,omitempty"`
+	Links   []gpxLink `xml:"link"`
+	Sym     string    `xml:"sym"`
+	Type    string    `xml:"type,omitempty"`
+}
+
+type gpxLink struct {
+	Href string `xml:"href,attr"`
+	Text string `xml:"text,omitempty"`
+}
+
+func newGPXWriter(w io.Writer, _ string) (Writer, error) {
+	gw := &gpxWriter{w: bufio.NewWriter(w)}
+	if _, err := gw.w.WriteString(gpxHeader); err != nil {
+		return nil, fmt.Errorf("writing gpx: %w", err)
+	}
+	gw.enc = xml.NewEncoder(gw.w)
+	gw.enc.Indent("  ", "  ")
+	return gw, nil
+}
+
+func (gw *gpxWriter) Write(b *model.Business) error {
+	var desc []string
+	if b.Rating > 0 {
+		desc = append(desc, fmt.Sprintf("★ %.1f (%d)", b.Rating, b.ReviewCount))
+	}
+	if p := b.PhoneNumber.Normalized(); p != "" {
+		desc = append(desc, p)
+	}
+	if b.Website != "" {
+		desc = append(desc, b.Website)
+	}
+	if len(b.Hours) > 0 {
+		desc = append(desc, b.Hours.String())
+	}
+
+	wpt := gpxWaypoint{
+		Lat:     formatCoord(b.Lat),
+		Lon:     formatCoord(b.Lng),
+		Name:    b.Name,
+		Comment: b.Address,
+		Desc:    strings.Join(desc, "\n"),
+		Sym:     "Waypoint",
+		Type:    b.Category,
+	}
+	if b.GoogleURL != "" {
+		wpt.Links = append(wpt.Links, gpxLink{b.GoogleURL, "Google Maps"})
+	}
+	if b.Website != "" {
+		wpt.Links = append(wpt.Links, gpxLink{b.Website, "Website"})
+	}
+	if err := gw.enc.Encode(wpt); err != nil {
+		return fmt.Errorf("writing gpx: %w", err)
+	}
+	return nil
+}
+
+func (gw *gpxWriter) Close() error {
+	gw.w.WriteString("\n</gpx>\n")
+	if err := gw.w.Flush(); err != nil {
+		return fmt.Errorf("writing gpx: %w", err)
+	}
+	return nil
+}
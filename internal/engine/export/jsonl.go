@@ -0,0 +1,37 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// jsonlWriter writes JSON Lines: one object per place with every business
+// field, typed as in the explorer's JSON panel.
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer, _ string) (Writer, error) {
+	bw := bufio.NewWriter(w)
+	return &jsonlWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+}
+
+func (jw *jsonlWriter) Write(b *model.Business) error {
+	// Encode ends each object with a newline
+	if err := jw.enc.Encode(b); err != nil {
+		return fmt.Errorf("writing jsonl: %w", err)
+	}
+	return nil
+}
+
+func (jw *jsonlWriter) Close() error {
+	if err := jw.w.Flush(); err != nil {
+		return fmt.Errorf("writing jsonl: %w", err)
+	}
+	return nil
+}
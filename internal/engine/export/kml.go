@@ -0,0 +1,160 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// kmlHeader opens the document and defines the placemark style: a pin and
+// a balloon with the place name over its description.
+const kmlHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+<Document>
+  <name>geotap</name>
+  <Style id="place">
+    <IconStyle>
+      <Icon><href>https://maps.google.com/mapfiles/kml/paddle/purple-circle.png</href></Icon>
+    </IconStyle>
+    <BalloonStyle>
+      <text><![CDATA[<div style="font-family:Arial,sans-serif;font-size:13px;max-width:320px">
+<div style="font-size:16px;font-weight:bold;color:#7C3AED;margin-bottom:4px">$[name]</div>
+$[description]
+</div>]]></text>
+    </BalloonStyle>
+  </Style>
+`
+
+// kmlWriter writes a Document of Placemarks, one per place, with the
+// business fields as ExtendedData for tools that read them as columns.
+type kmlWriter struct {
+	w   *bufio.Writer
+	enc *xml.Encoder
+}
+
+type kmlPlacemark struct {
+	XMLName     xml.Name  `xml:"Placemark"`
+	Name        string    `xml:"name"`
+	StyleURL    string    `xml:"styleUrl"`
+	Description kmlCDATA  `xml:"description"`
+	Data        []kmlData `xml:"ExtendedData>Data"`
+	Coordinates string    `xml:"Point>coordinates"`
+}
+
+type kmlCDATA struct {
+	Text string `xml:",cdata"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+func newKMLWriter(w io.Writer, _ string) (Writer, error) {
+	kw := &kmlWriter{w: bufio.NewWriter(w)}
+	if _, err := kw.w.WriteString(kmlHeader); err != nil {
+		return nil, fmt.Errorf("writing kml: %w", err)
+	}
+	kw.enc = xml.NewEncoder(kw.w)
+	kw.enc.Indent("  ", "  ")
+	return kw, nil
+}
+
+func (kw *kmlWriter) Write(b *model.Business) error {
+	pm := kmlPlacemark{
+		Name:        b.Name,
+		StyleURL:    "#place",
+		Description: kmlCDATA{balloon(b)},
+		Coordinates: formatCoord(b.Lng) + "," + formatCoord(b.Lat),
+	}
+	fields := []struct{ name, value string }{
+		{"category", b.Category},
+		{"rating", formatNumber(b.Rating)},
+		{"review_count", formatNumber(float64(b.ReviewCount))},
+		{"address", b.Address},
+		{"city", b.City},
+		{"postal_code", b.PostalCode},
+		{"country_code", b.CountryCode},
+		{"phone", b.PhoneNumber.Normalized()},
+		{"website", b.Website},
+		{"price_range", b.PriceRange},
+		{"opening_hours", b.Hours.String()},
+		{"queries", strings.Join(b.Queries, ", ")},
+		{"google_url", b.GoogleURL},
+		{"cid", b.CID},
+	}
+	for _, f := range fields {
+		if f.value != "" {
+			pm.Data = append(pm.Data, kmlData{f.name, f.value})
+		}
+	}
+	if err := kw.enc.Encode(pm); err != nil {
+		return fmt.Errorf("writing kml: %w", err)
+	}
+	return nil
+}
+
+func (kw *kmlWriter) Close() error {
+	kw.w.WriteString("\n</Document>\n</kml>\n")
+	if err := kw.w.Flush(); err != nil {
+		return fmt.Errorf("writing kml: %w", err)
+	}
+	return nil
+}
+
+// balloon renders the HTML description of a place shown in its balloon:
+// category, rating, address, contact links, hours and a Google Maps link.
+func balloon(b *model.Business) string {
+	var lines []string
+	if b.Category != "" {
+		lines = append(lines, `<div style="color:#6B7280">`+html.EscapeString(b.Category)+`</div>`)
+	}
+	if b.Rating > 0 {
+		line := fmt.Sprintf("★ %.1f", b.Rating)
+		if b.ReviewCount > 0 {
+			line += fmt.Sprintf(" (%d reviews)", b.ReviewCount)
+		}
+		if b.PriceRange != "" {
+			line += " · " + b.PriceRange
+		}
+		lines = append(lines, `<div style="color:#B45309">`+html.EscapeString(line)+`</div>`)
+	}
+	if b.Address != "" {
+		lines = append(lines, "<div>"+html.EscapeString(b.Address)+"</div>")
+	}
+	if p := b.PhoneNumber.Normalized(); p != "" {
+		lines = append(lines, fmt.Sprintf(`<div><a href="tel:%s">%s</a></div>`, html.EscapeString(p), html.EscapeString(b.Phone)))
+	}
+	if b.Website != "" {
+		lines = append(lines, fmt.Sprintf(`<div><a href="%[1]s">%[1]s</a></div>`, html.EscapeString(b.Website)))
+	}
+	if days := b.Hours.ByDay(); len(days) > 0 {
+		for i, d := range days {
+			days[i] = html.EscapeString(d)
+		}
+		lines = append(lines, `<div style="margin-top:6px;font-family:monospace;white-space:pre">`+strings.Join(days, "<br>")+`</div>`)
+	}
+	if b.GoogleURL != "" {
+		lines = append(lines, fmt.Sprintf(`<div style="margin-top:6px"><a href="%s">Open in Google Maps</a></div>`, html.EscapeString(b.GoogleURL)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatCoord renders a coordinate with the precision the CSV uses.
+func formatCoord(f float64) string {
+	return strconv.FormatFloat(f, 'f', 6, 64)
+}
+
+// formatNumber renders a rating or count, "" for zero.
+func formatNumber(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
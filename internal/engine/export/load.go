@@ -0,0 +1,170 @@
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/rendis/geotap/internal/engine/geo"
+	"github.com/rendis/geotap/internal/engine/phone"
+	"github.com/rendis/geotap/internal/engine/scraper"
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+)
+
+// Load reads the places of a scan, one per CID, with the queries that found
+// them and their weekly schedules, plus the time zones of enriched places
+// by CID.
+func Load(dbPath string) ([]model.Business, map[string]string, error) {
+	var businesses []model.Business
+	timezones := make(map[string]string)
+	err := Stream(dbPath, func(b *model.Business, tz string) error {
+		businesses = append(businesses, *b)
+		if tz != "" {
+			timezones[b.CID] = tz
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return businesses, timezones, nil
+}
+
+// openDB opens the project database at dbPath read-only, so exporting an
+// archived or shared copy never writes to it. Databases of an older schema
+// have to be migrated first.
+func openDB(dbPath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.CheckVersion(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: run 'geotap migrate -db %s' first", err, dbPath)
+	}
+	return db, nil
+}
+
+// Check reports whether the database at dbPath can be read by Stream.
+func Check(dbPath string) error {
+	db, err := openDB(dbPath)
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+// Stream calls fn for each place of a scan in name order, as Load reads
+// them, with the time zone of the place when it was enriched. Places are
+// read one row at a time, so memory stays flat whatever the size of the
+// scan. The business passed to fn is only valid during the call. Stream
+// stops at the first error fn returns.
+func Stream(dbPath string, fn func(b *model.Business, tz string) error) error {
+	db, err := openDB(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	hours, err := db.Prepare(`
+		SELECT day, COALESCE(open, ''), COALESCE(close, ''), overnight, all_day, closed
+		FROM opening_hours WHERE cid = ? ORDER BY day, ord`)
+	if err != nil {
+		return fmt.Errorf("preparing stmt: %w", err)
+	}
+	defer hours.Close()
+
+	rows, err := db.Query(`
+		SELECT p.name, COALESCE(p.rating, 0), COALESCE(p.review_count, 0), COALESCE(p.category, ''),
+		       COALESCE(p.address, ''), COALESCE(p.price_range, ''), p.lat, p.lng, p.cid,
+		       COALESCE(p.phone, ''), COALESCE(p.website, ''), COALESCE(p.google_url, ''),
+		       COALESCE(p.description, ''), COALESCE(p.place_id, ''), COALESCE(p.open_hours, ''),
+		       COALESCE(p.thumbnail, ''), COALESCE(p.categories, ''), COALESCE(p.city, ''),
+		       COALESCE(p.postal_code, ''), COALESCE(p.country_code, ''),
+		       COALESCE((SELECT group_concat(q.query, char(31) ORDER BY q.found_at, q.rowid)
+		                 FROM place_queries q WHERE q.cid = p.cid), ''),
+		       ph.cid IS NOT NULL, COALESCE(ph.raw, ''), COALESCE(ph.e164, ''), COALESCE(ph.region, ''),
+		       COALESCE(ph.valid, 0), COALESCE(ph.type, ''),
+		       COALESCE(d.timezone, '')
+		FROM places p
+		LEFT JOIN phones ph ON ph.cid = p.cid
+		LEFT JOIN place_details d ON d.cid = p.cid
+		ORDER BY p.name`)
+	if err != nil {
+		return fmt.Errorf("querying places: %w", err)
+	}
+	defer rows.Close()
+
+	// Databases from before the phones table have their phones read in the
+	// scanned country when a place has no country code
+	scanRegion := sync.OnceValue(func() string {
+		params, err := storage.ReadParams(db)
+		if err != nil {
+			return ""
+		}
+		return geo.CountryISO2(params.Country)
+	})
+
+	for rows.Next() {
+		var b model.Business
+		var queries, tz string
+		var hasPhone bool
+		var p model.PhoneNumber
+		err := rows.Scan(
+			&b.Name, &b.Rating, &b.ReviewCount, &b.Category, &b.Address, &b.PriceRange,
+			&b.Lat, &b.Lng, &b.CID, &b.Phone, &b.Website, &b.GoogleURL, &b.Description, &b.PlaceID,
+			&b.OpenHours, &b.Thumbnail, &b.Categories, &b.City, &b.PostalCode, &b.CountryCode,
+			&queries, &hasPhone, &p.Raw, &p.E164, &p.Region, &p.Valid, &p.Type, &tz,
+		)
+		if err != nil {
+			return fmt.Errorf("scanning place: %w", err)
+		}
+
+		if queries != "" {
+			b.Queries = strings.Split(queries, "\x1f")
+			b.Query = b.Queries[0]
+		}
+		if hasPhone {
+			b.PhoneNumber = &p
+		} else if b.Phone != "" {
+			b.PhoneNumber = phone.ForPlace(b.Phone, b.CountryCode, scanRegion())
+		}
+		// Databases from before the opening_hours table only have the raw
+		// hours
+		if b.Hours, err = loadSchedule(hours, b.CID); err != nil {
+			return err
+		}
+		if b.Hours == nil {
+			b.Hours = scraper.ParseSchedule(b.OpenHours)
+		}
+
+		if err := fn(&b, tz); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// loadSchedule reads the stored weekly schedule of a place, nil when it has
+// none.
+func loadSchedule(stmt *sql.Stmt, cid string) (model.Schedule, error) {
+	rows, err := stmt.Query(cid)
+	if err != nil {
+		return nil, fmt.Errorf("querying opening hours: %w", err)
+	}
+	defer rows.Close()
+
+	var s model.Schedule
+	for rows.Next() {
+		var sp model.OpeningSpan
+		if err := rows.Scan(&sp.Day, &sp.Open, &sp.Close, &sp.Overnight, &sp.AllDay, &sp.Closed); err != nil {
+			return nil, fmt.Errorf("scanning opening hours: %w", err)
+		}
+		s = append(s, sp)
+	}
+	return s, rows.Err()
+}
@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+)
+
+// newTestDB returns a project database with a place, plus the rows inserts
+// add.
+func newTestDB(t *testing.T, inserts ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	s, err := storage.NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	_, err = s.InsertBatch([]model.Business{
+		{Name: "Cafe Sol", CID: "1", Lat: 40.42, Lng: -3.70, Rating: 4.5, Phone: "+34 912 34 56 78", Query: "cafes"},
+	}, storage.Origin{})
+	s.Close()
+	if err != nil {
+		t.Fatalf("InsertBatch: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("opening db: %v", err)
+	}
+	defer db.Close()
+	for _, q := range inserts {
+		if _, err := db.Exec(q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+	return path
+}
+
+func TestStream(t *testing.T) {
+	// Places migrated from old databases may have NULL fields
+	path := newTestDB(t, "INSERT INTO places (cid, name, lat, lng) VALUES ('2', 'Bar Luna', 40.43, -3.71)")
+
+	var names []string
+	err := Stream(path, func(b *model.Business, tz string) error {
+		names = append(names, b.Name)
+		if b.CID == "1" && (b.Rating != 4.5 || b.Query != "cafes" || b.PhoneNumber == nil || b.PhoneNumber.E164 != "+34912345678") {
+			t.Errorf("place 1 = %+v", *b)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if len(names) != 2 || names[0] != "Bar Luna" || names[1] != "Cafe Sol" {
+		t.Errorf("streamed %v, want both places by name", names)
+	}
+}
+
+func TestStreamScanError(t *testing.T) {
+	// A row that does not scan fails the export instead of going missing
+	path := newTestDB(t, "INSERT INTO places (cid, name, lat, lng) VALUES ('2', 'Bar Luna', 'north', -3.71)")
+
+	n := 0
+	err := Stream(path, func(b *model.Business, tz string) error {
+		n++
+		return nil
+	})
+	if err == nil {
+		t.Fatalf("Stream = nil after %d places, want the scan error", n)
+	}
+}
+
+func TestStreamReadOnly(t *testing.T) {
+	// A project a version behind, as an archived copy would be
+	path := newTestDB(t, "DELETE FROM schema_version WHERE version = (SELECT MAX(version) FROM schema_version)")
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading db: %v", err)
+	}
+
+	err = Stream(path, func(b *model.Business, tz string) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "geotap migrate") {
+		t.Errorf("Stream = %v, want to be told to migrate", err)
+	}
+	if err := Check(path); err == nil {
+		t.Error("Check = nil, want the old schema refused")
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading db: %v", err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("exporting wrote to the database")
+	}
+
+	// Once migrated, it exports without writing either
+	s, err := storage.NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	s.Close()
+	if before, err = os.ReadFile(path); err != nil {
+		t.Fatalf("reading db: %v", err)
+	}
+	if err := os.Chmod(path, 0o444); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	n := 0
+	if err := Stream(path, func(b *model.Business, tz string) error { n++; return nil }); err != nil || n != 1 {
+		t.Errorf("Stream of a read-only file = %d places, %v, want 1", n, err)
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(before, after) {
+		t.Error("exporting wrote to the database")
+	}
+}
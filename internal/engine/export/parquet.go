@@ -0,0 +1,145 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// parquetRowGroup is the number of places per row group, which bounds the
+// rows the writer holds in memory.
+const parquetRowGroup = 64 * 1024
+
+// parquetBatch is the number of places buffered before they are handed to
+// the Parquet writer.
+const parquetBatch = 1024
+
+// parquetRow is the Parquet schema: one row per place, with numbers as
+// typed columns and missing values as nulls.
+type parquetRow struct {
+	CID          string   `parquet:"cid"`
+	Name         string   `parquet:"name"`
+	Rating       *float64 `parquet:"rating,optional"`
+	ReviewCount  *int64   `parquet:"review_count,optional"`
+	Category     *string  `parquet:"category,optional,dict"`
+	Categories   *string  `parquet:"categories,optional"`
+	Address      *string  `parquet:"address,optional"`
+	City         *string  `parquet:"city,optional,dict"`
+	PostalCode   *string  `parquet:"postal_code,optional"`
+	CountryCode  *string  `parquet:"country_code,optional,dict"`
+	Lat          float64  `parquet:"lat"`
+	Lng          float64  `parquet:"lng"`
+	Phone        *string  `parquet:"phone,optional"`
+	PhoneRaw     *string  `parquet:"phone_raw,optional"`
+	PhoneType    *string  `parquet:"phone_type,optional,dict"`
+	PhoneValid   *bool    `parquet:"phone_valid,optional"`
+	Website      *string  `parquet:"website,optional"`
+	GoogleURL    *string  `parquet:"google_url,optional"`
+	Description  *string  `parquet:"description,optional"`
+	PriceRange   *string  `parquet:"price_range,optional,dict"`
+	PlaceID      *string  `parquet:"place_id,optional"`
+	Thumbnail    *string  `parquet:"thumbnail,optional"`
+	Queries      []string `parquet:"queries,list"`
+	OpeningHours *string  `parquet:"opening_hours,optional"`
+}
+
+// parquetWriter writes places in row groups of parquetRowGroup rows,
+// compressed with Snappy unless another codec is asked for.
+type parquetWriter struct {
+	w     *parquet.GenericWriter[parquetRow]
+	batch []parquetRow
+}
+
+func newParquetWriter(w io.Writer, compression string) (Writer, error) {
+	var codec parquet.WriterOption
+	switch compression {
+	case "":
+		codec = parquet.Compression(&parquet.Snappy)
+	case Gzip:
+		codec = parquet.Compression(&parquet.Gzip)
+	case Zstd:
+		codec = parquet.Compression(&parquet.Zstd)
+	default:
+		return nil, fmt.Errorf("unsupported parquet compression: %s", compression)
+	}
+	pw := parquet.NewGenericWriter[parquetRow](w,
+		codec,
+		parquet.MaxRowsPerRowGroup(parquetRowGroup),
+		parquet.CreatedBy("geotap", "", ""),
+	)
+	return &parquetWriter{w: pw, batch: make([]parquetRow, 0, parquetBatch)}, nil
+}
+
+func (pw *parquetWriter) Write(b *model.Business) error {
+	row := parquetRow{
+		CID:          b.CID,
+		Name:         b.Name,
+		Category:     nullString(b.Category),
+		Categories:   nullString(b.Categories),
+		Address:      nullString(b.Address),
+		City:         nullString(b.City),
+		PostalCode:   nullString(b.PostalCode),
+		CountryCode:  nullString(b.CountryCode),
+		Lat:          b.Lat,
+		Lng:          b.Lng,
+		Phone:        nullString(b.PhoneNumber.Normalized()),
+		PhoneRaw:     nullString(b.Phone),
+		Website:      nullString(b.Website),
+		GoogleURL:    nullString(b.GoogleURL),
+		Description:  nullString(b.Description),
+		PriceRange:   nullString(b.PriceRange),
+		PlaceID:      nullString(b.PlaceID),
+		Thumbnail:    nullString(b.Thumbnail),
+		Queries:      b.Queries,
+		OpeningHours: nullString(b.Hours.String()),
+	}
+	// Rows outlive the call, so they point to copies
+	if b.Rating > 0 {
+		rating := b.Rating
+		row.Rating = &rating
+	}
+	if b.ReviewCount > 0 {
+		n := int64(b.ReviewCount)
+		row.ReviewCount = &n
+	}
+	if p := b.PhoneNumber; p != nil {
+		row.PhoneType = nullString(p.Type)
+		valid := p.Valid
+		row.PhoneValid = &valid
+	}
+
+	pw.batch = append(pw.batch, row)
+	if len(pw.batch) == cap(pw.batch) {
+		return pw.flush()
+	}
+	return nil
+}
+
+func (pw *parquetWriter) flush() error {
+	if _, err := pw.w.Write(pw.batch); err != nil {
+		return fmt.Errorf("writing parquet: %w", err)
+	}
+	pw.batch = pw.batch[:0]
+	return nil
+}
+
+func (pw *parquetWriter) Close() error {
+	if err := pw.flush(); err != nil {
+		return err
+	}
+	if err := pw.w.Close(); err != nil {
+		return fmt.Errorf("writing parquet: %w", err)
+	}
+	return nil
+}
+
+// nullString returns nil for an empty string, so it is stored as null.
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
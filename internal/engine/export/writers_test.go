@@ -0,0 +1,168 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenPlaces has a place with characters XML must escape and one with
+// only the fields every scan fills in.
+var goldenPlaces = []model.Business{
+	{
+		Name:        "Tapas & Vinos <La Latina>",
+		Rating:      4.6,
+		ReviewCount: 1287,
+		Category:    "Tapas bar",
+		Address:     "Calle de la Cava Baja, 12 & 14, 28005 Madrid",
+		PriceRange:  "€€",
+		Lat:         40.4118,
+		Lng:         -3.7089,
+		CID:         "1711231875390658011",
+		Phone:       "912 34 56 78",
+		Website:     "https://tapas.example/?lang=es&ref=maps",
+		GoogleURL:   "https://www.google.com/maps/place/?q=place_id:ChIJ2fW0o",
+		PlaceID:     "ChIJ2fW0o",
+		City:        "Madrid",
+		PostalCode:  "28005",
+		CountryCode: "ES",
+		Query:       "tapas",
+		Queries:     []string{"tapas", "bars"},
+		Hours: model.Schedule{
+			{Day: 1, Closed: true},
+			{Day: 5, Open: "13:00", Close: "16:00"},
+			{Day: 5, Open: "20:00", Close: "01:00", Overnight: true},
+		},
+		PhoneNumber: &model.PhoneNumber{Raw: "912 34 56 78", E164: "+34912345678", Region: "ES", Valid: true, Type: model.PhoneLandline},
+	},
+	{Name: "Kiosko", Lat: -33.45, Lng: -70.666667, CID: "42", Query: "kiosks"},
+}
+
+func TestWritersGolden(t *testing.T) {
+	for _, name := range []string{"geojson", "kml", "gpx"} {
+		t.Run(name, func(t *testing.T) {
+			f, err := Lookup(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			w, err := f.New(&buf, "")
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			for i := range goldenPlaces {
+				if err := w.Write(&goldenPlaces[i]); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			golden := filepath.Join("testdata", "golden", "places"+f.Ext)
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("%s output differs from %s (go test -run TestWritersGolden -update rewrites it):\n%s", name, golden, got)
+			}
+		})
+	}
+}
+
+func TestWritersWellFormed(t *testing.T) {
+	write := func(name string) []byte {
+		t.Helper()
+		f, _ := Lookup(name)
+		var buf bytes.Buffer
+		w, err := f.New(&buf, "")
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		for i := range goldenPlaces {
+			w.Write(&goldenPlaces[i])
+		}
+		w.Close()
+		return buf.Bytes()
+	}
+
+	// GeoJSON coordinates are lng, lat; the name survives as is
+	var fc struct {
+		Features []struct {
+			Geometry struct {
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties model.Business `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(write("geojson"), &fc); err != nil {
+		t.Fatalf("geojson does not parse: %v", err)
+	}
+	if len(fc.Features) != 2 {
+		t.Fatalf("geojson has %d features, want 2", len(fc.Features))
+	}
+	if c := fc.Features[0].Geometry.Coordinates; len(c) != 2 || c[0] != -3.7089 || c[1] != 40.4118 {
+		t.Errorf("geojson coordinates = %v, want lng, lat", c)
+	}
+	if p := fc.Features[0].Properties; p.Name != goldenPlaces[0].Name || len(p.Hours) != 3 {
+		t.Errorf("geojson properties = %+v", p)
+	}
+
+	// KML and GPX parse as XML with the escaped text read back intact
+	for _, tt := range []struct {
+		format, element, want string
+	}{
+		{"kml", "name", "Tapas & Vinos <La Latina>"},
+		{"kml", "coordinates", "-3.708900,40.411800"},
+		{"kml", "value", "https://tapas.example/?lang=es&ref=maps"},
+		{"gpx", "name", "Tapas & Vinos <La Latina>"},
+		{"gpx", "cmt", "Calle de la Cava Baja, 12 & 14, 28005 Madrid"},
+	} {
+		if !xmlHasText(t, write(tt.format), tt.element, tt.want) {
+			t.Errorf("%s has no <%s> reading %q", tt.format, tt.element, tt.want)
+		}
+	}
+	if gpx := string(write("gpx")); !strings.Contains(gpx, `<wpt lat="40.411800" lon="-3.708900">`) {
+		t.Errorf("gpx waypoint does not carry lat and lon attributes:\n%s", gpx)
+	}
+}
+
+// xmlHasText decodes doc, failing the test when it is not well-formed, and
+// reports whether some element named local has text want.
+func xmlHasText(t *testing.T, doc []byte, local, want string) bool {
+	t.Helper()
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	found := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return found
+		}
+		if err != nil {
+			t.Fatalf("not well-formed XML: %v\n%s", err, doc)
+		}
+		if se, ok := tok.(xml.StartElement); ok && se.Name.Local == local {
+			var text string
+			if err := dec.DecodeElement(&text, &se); err != nil {
+				t.Fatalf("decoding <%s>: %v", local, err)
+			}
+			found = found || text == want
+		}
+	}
+}
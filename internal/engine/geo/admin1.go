@@ -0,0 +1,182 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/paulmach/orb/geojson"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Admin-1 (state/province) boundaries are too large to embed (~40 MB for
+// Natural Earth 10m), so they are read from a data file when present:
+// $GEOTAP_ADMIN1, or admin1.geojson in the geotap config directory. The file
+// is expected to be Natural Earth's ne_10m_admin_1_states_provinces.
+const admin1EnvVar = "GEOTAP_ADMIN1"
+
+var (
+	admin1Once sync.Once
+	admin1Data map[string][]*geojson.Feature // key: lowercase ADM0_A3
+	admin1Err  error
+)
+
+// Admin1Path returns the admin-1 data file geotap looks for, "" when
+// $GEOTAP_ADMIN1 is unset and the user has no config directory.
+func Admin1Path() string {
+	if p := os.Getenv(admin1EnvVar); p != "" {
+		return p
+	}
+	cfg, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cfg, "geotap", "admin1.geojson")
+}
+
+// loadAdmin1 reads the admin-1 file once per process. A missing file is not
+// an error: region lookups simply fall back to online geocoding.
+func loadAdmin1() (map[string][]*geojson.Feature, error) {
+	admin1Once.Do(func() {
+		path := Admin1Path()
+		if path == "" {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				admin1Err = fmt.Errorf("reading admin-1 boundaries: %w", err)
+			}
+			return
+		}
+
+		fc := &geojson.FeatureCollection{}
+		if err := json.Unmarshal(data, fc); err != nil {
+			admin1Err = fmt.Errorf("parsing admin-1 boundaries: %w", err)
+			return
+		}
+
+		admin1Data = make(map[string][]*geojson.Feature)
+		for _, f := range fc.Features {
+			adm0 := strings.ToLower(propString(f, "adm0_a3"))
+			if adm0 == "" {
+				continue
+			}
+			admin1Data[adm0] = append(admin1Data[adm0], f)
+		}
+	})
+	return admin1Data, admin1Err
+}
+
+// RegionEntry holds display info for a first-level administrative division.
+type RegionEntry struct {
+	Name   string // local name (canonical)
+	NameEN string // English name
+	NameES string // Spanish name
+	Type   string // e.g. "State", "Province", "Autonomous Community"
+	Code   string // ISO 3166-2 code, e.g. "ES-CT"
+}
+
+// HasAdmin1 reports whether admin-1 boundaries are available offline.
+func (bs *BoundaryStore) HasAdmin1() bool {
+	data, err := loadAdmin1()
+	return err == nil && len(data) > 0
+}
+
+// ListRegionEntries returns the admin-1 divisions of a country sorted by name.
+// It returns nil when the country is unknown or no admin-1 data is installed.
+func (bs *BoundaryStore) ListRegionEntries(country string) []RegionEntry {
+	features := bs.countryRegions(country)
+	entries := make([]RegionEntry, 0, len(features))
+	for _, f := range features {
+		entries = append(entries, RegionEntry{
+			Name:   propString(f, "name"),
+			NameEN: propString(f, "name_en"),
+			NameES: propString(f, "name_es"),
+			Type:   propString(f, "type_en"),
+			Code:   propString(f, "iso_3166_2"),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+// GetRegion looks up an admin-1 division of a country by name (local, English,
+// Spanish or alternate, accent-insensitive) or ISO 3166-2 code. ok is false
+// when the region is unknown or no admin-1 data is installed.
+func (bs *BoundaryStore) GetRegion(country, region string) (*Region, bool) {
+	q := foldName(region)
+	if q == "" {
+		return nil, false
+	}
+	for _, f := range bs.countryRegions(country) {
+		if !regionMatches(f, q) {
+			continue
+		}
+		poly := appendPolygons(nil, f.Geometry)
+		if len(poly) == 0 {
+			return nil, false
+		}
+		return &Region{
+			Name:    propString(f, "name"),
+			Bound:   poly.Bound(),
+			Polygon: poly,
+		}, true
+	}
+	return nil, false
+}
+
+func (bs *BoundaryStore) countryRegions(country string) []*geojson.Feature {
+	f, ok := bs.features[strings.ToLower(strings.TrimSpace(country))]
+	if !ok {
+		return nil
+	}
+	data, err := loadAdmin1()
+	if err != nil {
+		return nil
+	}
+	// ISO_A3 is "-99" for a few countries (France, Norway); ADM0_A3 is always set
+	adm0, _ := f.Properties["ADM0_A3"].(string)
+	return data[strings.ToLower(adm0)]
+}
+
+func regionMatches(f *geojson.Feature, q string) bool {
+	for _, key := range []string{"name", "name_en", "name_es", "name_local", "iso_3166_2"} {
+		if v := propString(f, key); v != "" && foldName(v) == q {
+			return true
+		}
+	}
+	for _, alt := range strings.Split(propString(f, "name_alt"), "|") {
+		if alt != "" && foldName(alt) == q {
+			return true
+		}
+	}
+	return false
+}
+
+// propString reads a string property, accepting both the lowercase keys of
+// current Natural Earth releases and the uppercase keys of older ones.
+func propString(f *geojson.Feature, key string) string {
+	if v, ok := f.Properties[key].(string); ok {
+		return v
+	}
+	v, _ := f.Properties[strings.ToUpper(key)].(string)
+	return v
+}
+
+// foldName lowercases s and strips diacritics so "Cataluña" matches "cataluna".
+func foldName(s string) string {
+	t := transform.Chain(norm.NFD, transform.RemoveFunc(func(r rune) bool {
+		return unicode.Is(unicode.Mn, r)
+	}), norm.NFC)
+	result, _, _ := transform.String(t, strings.ToLower(strings.TrimSpace(s)))
+	return result
+}
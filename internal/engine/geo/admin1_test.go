@@ -0,0 +1,32 @@
+package geo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAdmin1Path(t *testing.T) {
+	t.Setenv(admin1EnvVar, "")
+	cfg := t.TempDir()
+	t.Setenv("HOME", cfg)
+	t.Setenv("XDG_CONFIG_HOME", cfg)
+	t.Setenv("AppData", cfg)
+	if got := Admin1Path(); !filepath.IsAbs(got) || filepath.Base(got) != "admin1.geojson" {
+		t.Errorf("Admin1Path = %q, want admin1.geojson in the config dir", got)
+	}
+
+	t.Setenv(admin1EnvVar, "/data/ne_10m_admin_1_states_provinces.geojson")
+	if got := Admin1Path(); got != "/data/ne_10m_admin_1_states_provinces.geojson" {
+		t.Errorf("Admin1Path = %q, want $%s", got, admin1EnvVar)
+	}
+
+	// Without a config dir there is no file to look for, rather than one
+	// relative to the working directory
+	t.Setenv(admin1EnvVar, "")
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("AppData", "")
+	if got := Admin1Path(); got != "" {
+		t.Errorf("Admin1Path without a config dir = %q, want none", got)
+	}
+}
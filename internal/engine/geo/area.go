@@ -0,0 +1,214 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// Area is the resolved scan coverage for a set of search parameters.
+type Area struct {
+	Bound    orb.Bound
+	GridSize int              // sectors in the bounding grid before land filtering
+	Sectors  []model.Sector   // sectors left to scan
+	Filter   orb.MultiPolygon // polygon businesses are clipped to (nil = no clipping)
+	Clip     string           // what Filter represents, one of the Clip* constants
+}
+
+// Area clipping sources.
+const (
+	ClipNone       = ""
+	ClipCountry    = "country"
+	ClipRegion     = "region"
+	ClipRegionBBox = "region-bbox" // region bbox grid, clipped by the country polygon
+	ClipAreaFile   = "area"
+)
+
+// OceanRatio returns the fraction of grid sectors discarded by the land filter.
+func (a *Area) OceanRatio() float64 {
+	if a.GridSize == 0 {
+		return 0
+	}
+	return float64(a.GridSize-len(a.Sectors)) / float64(a.GridSize)
+}
+
+// ResolveArea runs the grid pipeline for params: bounds → GenerateGrid or
+// GenerateRadiusGrid → FilterLandSectors.
+func ResolveArea(params model.SearchParams) (*Area, error) {
+	if params.AreaFile != "" {
+		poly, err := LoadAreaFile(params.AreaFile)
+		if err != nil {
+			return nil, err
+		}
+		bound := poly.Bound()
+		grid := GenerateGrid(bound.Min.Lat(), bound.Min.Lon(), bound.Max.Lat(), bound.Max.Lon(), params.Zoom)
+		return &Area{
+			Bound:    bound,
+			GridSize: len(grid),
+			Sectors:  FilterLandSectors(grid, poly),
+			Filter:   poly,
+			Clip:     ClipAreaFile,
+		}, nil
+	}
+
+	if params.IsCoordMode() {
+		sectors := GenerateRadiusGrid(params.Lat, params.Lng, params.Radius, params.Zoom)
+		return &Area{
+			Bound:    radiusBound(params.Lat, params.Lng, params.Radius),
+			GridSize: len(sectors),
+			Sectors:  sectors,
+		}, nil
+	}
+
+	bs, err := NewBoundaryStore()
+	if err != nil {
+		return nil, fmt.Errorf("loading boundaries: %w", err)
+	}
+
+	countryPoly, err := bs.GetCountryPolygon(params.Country)
+	if err != nil {
+		return nil, fmt.Errorf("getting polygon: %w", err)
+	}
+
+	region, err := resolveRegion(bs, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var bound orb.Bound
+	poly, clip := countryPoly, ClipCountry
+	if region != nil {
+		bound = region.Bound
+		// Without a boundary geometry, fall back to the bbox grid clipped by the country
+		if len(region.Polygon) > 0 {
+			poly, clip = region.Polygon, ClipRegion
+		} else {
+			clip = ClipRegionBBox
+		}
+	} else {
+		minLat, minLng, maxLat, maxLng, err := bs.GetCountryBounds(params.Country)
+		if err != nil {
+			return nil, fmt.Errorf("getting bounds: %w", err)
+		}
+		bound = orb.Bound{Min: orb.Point{minLng, minLat}, Max: orb.Point{maxLng, maxLat}}
+	}
+
+	grid := GenerateGrid(bound.Min.Lat(), bound.Min.Lon(), bound.Max.Lat(), bound.Max.Lon(), params.Zoom)
+	return &Area{
+		Bound:    bound,
+		GridSize: len(grid),
+		Sectors:  FilterLandSectors(grid, poly),
+		Filter:   poly,
+		Clip:     clip,
+	}, nil
+}
+
+// ResolveGeoFilter returns only the clipping polygon ResolveArea would use,
+// without generating the grid.
+func ResolveGeoFilter(params model.SearchParams) (orb.MultiPolygon, error) {
+	if params.AreaFile != "" {
+		return LoadAreaFile(params.AreaFile)
+	}
+	if params.Country == "" {
+		return nil, nil
+	}
+	bs, err := NewBoundaryStore()
+	if err != nil {
+		return nil, fmt.Errorf("loading boundaries: %w", err)
+	}
+	region, err := resolveRegion(bs, params)
+	if err != nil {
+		return nil, err
+	}
+	if region != nil && len(region.Polygon) > 0 {
+		return region.Polygon, nil
+	}
+	poly, err := bs.GetCountryPolygon(params.Country)
+	if err != nil {
+		return nil, fmt.Errorf("getting polygon: %w", err)
+	}
+	return poly, nil
+}
+
+// resolveRegion resolves the region, province and city of params to a
+// boundary. Regions and provinces are looked up in the offline admin-1 data
+// first; cities, and anything not found offline, go through the online
+// geocoder. It returns nil when params name no sub-national area.
+func resolveRegion(bs *BoundaryStore, params model.SearchParams) (*Region, error) {
+	name := params.RegionName()
+	online := NewGeocoder(params.GeocoderURL)
+
+	var gc Geocoder
+	q := params.City
+	switch {
+	case q == "" && name == "":
+		return nil, nil
+	case q == "":
+		q = name
+		gc = ChainGeocoder{&OfflineGeocoder{Store: bs}, online}
+	default:
+		if name != "" {
+			q += ", " + name
+		}
+		gc = online
+	}
+
+	region, err := gc.GeocodeRegion(q, params.Country)
+	if err != nil {
+		return nil, fmt.Errorf("geocoding region %q: %w", q, err)
+	}
+	return region, nil
+}
+
+// SectorBound returns the rectangle covered by a sector.
+func SectorBound(s model.Sector) orb.Bound {
+	latHalf := s.Span / 2
+	lngHalf := latHalf / cosDeg(s.Lat)
+	return orb.Bound{
+		Min: orb.Point{s.Lng - lngHalf, s.Lat - latHalf},
+		Max: orb.Point{s.Lng + lngHalf, s.Lat + latHalf},
+	}
+}
+
+// AreaGeoJSON encodes the area's sectors (and clipping polygon, if any) as a
+// GeoJSON FeatureCollection for inspection in QGIS or geojson.io.
+func AreaGeoJSON(a *Area) ([]byte, error) {
+	fc := geojson.NewFeatureCollection()
+
+	if len(a.Filter) > 0 {
+		f := geojson.NewFeature(a.Filter)
+		f.Properties["kind"] = "area"
+		fc.Append(f)
+	}
+
+	for _, s := range a.Sectors {
+		f := geojson.NewFeature(SectorBound(s).ToPolygon())
+		f.Properties["kind"] = "sector"
+		f.Properties["row"] = s.Row
+		f.Properties["col"] = s.Col
+		f.Properties["zoom"] = s.Zoom
+		f.Properties["lat"] = s.Lat
+		f.Properties["lng"] = s.Lng
+		fc.Append(f)
+	}
+
+	return json.Marshal(fc)
+}
+
+func radiusBound(lat, lng, radiusKm float64) orb.Bound {
+	latDeg := radiusKm / 111.0
+	lngDeg := radiusKm / (111.0 * cosDeg(lat))
+	return orb.Bound{
+		Min: orb.Point{lng - lngDeg, lat - latDeg},
+		Max: orb.Point{lng + lngDeg, lat + latDeg},
+	}
+}
+
+func cosDeg(deg float64) float64 {
+	return math.Cos(deg * math.Pi / 180.0)
+}
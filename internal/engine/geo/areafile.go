@@ -0,0 +1,204 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/geojson"
+)
+
+// LoadAreaFile reads a Polygon/MultiPolygon scan area from a GeoJSON, KML or
+// WKT file. The format is chosen by extension and falls back to sniffing the
+// content. All polygons found in the file are merged into one MultiPolygon.
+func LoadAreaFile(path string) (orb.MultiPolygon, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading area file: %w", err)
+	}
+
+	var mp orb.MultiPolygon
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".geojson", ".json":
+		mp, err = parseGeoJSONArea(data)
+	case ".kml":
+		mp, err = parseKMLArea(data)
+	case ".wkt":
+		mp, err = parseWKTArea(data)
+	default:
+		trimmed := bytes.TrimSpace(data)
+		switch {
+		case bytes.HasPrefix(trimmed, []byte("{")):
+			mp, err = parseGeoJSONArea(data)
+		case bytes.HasPrefix(trimmed, []byte("<")):
+			mp, err = parseKMLArea(data)
+		default:
+			mp, err = parseWKTArea(data)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+	if len(mp) == 0 {
+		return nil, fmt.Errorf("no polygon found in %s", filepath.Base(path))
+	}
+	return mp, nil
+}
+
+func parseGeoJSONArea(data []byte) (orb.MultiPolygon, error) {
+	var probe struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+
+	var geoms []orb.Geometry
+	switch probe.Type {
+	case "FeatureCollection":
+		fc, err := geojson.UnmarshalFeatureCollection(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fc.Features {
+			geoms = append(geoms, f.Geometry)
+		}
+	case "Feature":
+		f, err := geojson.UnmarshalFeature(data)
+		if err != nil {
+			return nil, err
+		}
+		geoms = append(geoms, f.Geometry)
+	default:
+		g, err := geojson.UnmarshalGeometry(data)
+		if err != nil {
+			return nil, err
+		}
+		geoms = append(geoms, g.Geometry())
+	}
+
+	var mp orb.MultiPolygon
+	for _, g := range geoms {
+		mp = appendPolygons(mp, g)
+	}
+	return mp, nil
+}
+
+func parseWKTArea(data []byte) (orb.MultiPolygon, error) {
+	g, err := wkt.Unmarshal(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	return appendPolygons(nil, g), nil
+}
+
+// parseKMLArea collects every <Polygon> in a KML document, including those
+// nested in <MultiGeometry>, Folders and Placemarks.
+func parseKMLArea(data []byte) (orb.MultiPolygon, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	var mp orb.MultiPolygon
+	var current orb.Polygon
+	var inOuter, inInner, inCoords bool
+	var coords strings.Builder
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "Polygon":
+				current = nil
+			case "outerBoundaryIs":
+				inOuter = true
+			case "innerBoundaryIs":
+				inInner = true
+			case "coordinates":
+				inCoords = true
+				coords.Reset()
+			}
+		case xml.CharData:
+			if inCoords {
+				coords.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "coordinates":
+				inCoords = false
+				if !inOuter && !inInner {
+					continue
+				}
+				ring, err := parseKMLCoordinates(coords.String())
+				if err != nil {
+					return nil, err
+				}
+				if inOuter {
+					current = append(orb.Polygon{ring}, current...)
+				} else {
+					current = append(current, ring)
+				}
+			case "outerBoundaryIs":
+				inOuter = false
+			case "innerBoundaryIs":
+				inInner = false
+			case "Polygon":
+				if len(current) > 0 {
+					mp = append(mp, current)
+				}
+				current = nil
+			}
+		}
+	}
+	return mp, nil
+}
+
+// parseKMLCoordinates parses a KML "lng,lat[,alt] lng,lat[,alt] ..." tuple list.
+func parseKMLCoordinates(s string) (orb.Ring, error) {
+	var ring orb.Ring
+	for _, tuple := range strings.Fields(s) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid KML coordinate %q", tuple)
+		}
+		lng, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid KML longitude %q", parts[0])
+		}
+		lat, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid KML latitude %q", parts[1])
+		}
+		ring = append(ring, orb.Point{lng, lat})
+	}
+	return ring, nil
+}
+
+// appendPolygons adds the polygonal parts of g to mp, ignoring points and lines.
+func appendPolygons(mp orb.MultiPolygon, g orb.Geometry) orb.MultiPolygon {
+	switch g := g.(type) {
+	case orb.Polygon:
+		return append(mp, g)
+	case orb.MultiPolygon:
+		return append(mp, g...)
+	case orb.Collection:
+		for _, c := range g {
+			mp = appendPolygons(mp, c)
+		}
+	}
+	return mp
+}
@@ -0,0 +1,77 @@
+package geo
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+func TestLoadAreaFile(t *testing.T) {
+	triangle := func(x, y float64) orb.Polygon {
+		return orb.Polygon{{{x, y}, {x + 1, y}, {x + 1, y + 1}, {x, y}}}
+	}
+	withHole := func(p orb.Polygon, hole orb.Ring) orb.Polygon {
+		return append(p, hole)
+	}
+
+	tests := []struct {
+		file string
+		want orb.MultiPolygon
+	}{
+		{"holes.geojson", orb.MultiPolygon{{
+			{{-70.70, -33.50}, {-70.60, -33.50}, {-70.60, -33.40}, {-70.70, -33.40}, {-70.70, -33.50}},
+			{{-70.67, -33.47}, {-70.63, -33.47}, {-70.63, -33.43}, {-70.67, -33.43}, {-70.67, -33.47}},
+		}}},
+		// Every feature's polygons are merged; the point is ignored
+		{"districts.geojson", orb.MultiPolygon{triangle(0, 0), triangle(2, 2), triangle(4, 4)}},
+		{"polygon.json", orb.MultiPolygon{triangle(0, 0)}},
+		// Polygons of every Placemark, in a MultiGeometry or a Folder, with
+		// the outline first whatever the order in the file
+		{"zones.kml", orb.MultiPolygon{
+			triangle(0, 0),
+			triangle(2, 2),
+			withHole(triangle(4, 4), orb.Ring{{4.2, 4.2}, {4.4, 4.2}, {4.4, 4.4}, {4.2, 4.2}}),
+		}},
+		{"islands.wkt", orb.MultiPolygon{
+			triangle(0, 0),
+			withHole(triangle(2, 2), orb.Ring{{2.2, 2.1}, {2.8, 2.1}, {2.8, 2.7}, {2.2, 2.1}}),
+		}},
+		// No known extension: the content tells the format
+		{"area.txt", orb.MultiPolygon{triangle(0, 0)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := LoadAreaFile(filepath.Join("testdata", "area", tt.file))
+			if err != nil {
+				t.Fatalf("LoadAreaFile: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadAreaFile = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAreaFileRejects(t *testing.T) {
+	tests := []struct {
+		file, err string
+	}{
+		{"empty.geojson", "no polygon found in empty.geojson"},
+		{"line.wkt", "no polygon found in line.wkt"},
+		{"points.kml", "no polygon found in points.kml"},
+		{"blank.wkt", "parsing blank.wkt"},
+		{"bad-coordinates.kml", `invalid KML coordinate "1"`},
+		{"missing.geojson", "reading area file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			mp, err := LoadAreaFile(filepath.Join("testdata", "area", tt.file))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("LoadAreaFile = %v, %v, want an error with %q", mp, err, tt.err)
+			}
+		})
+	}
+}
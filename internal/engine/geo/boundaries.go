@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/geojson"
@@ -124,6 +125,42 @@ func (bs *BoundaryStore) ValidateCountry(input string) (string, bool) {
 	return name, true
 }
 
+// ISO2 returns the ISO 3166-1 alpha-2 code of a country given by name or
+// ISO code, or "" when it is unknown.
+func (bs *BoundaryStore) ISO2(country string) string {
+	f, ok := bs.features[strings.ToLower(strings.TrimSpace(country))]
+	if !ok {
+		return ""
+	}
+	// ISO_A2 is "-99" for a few countries (France, Norway); ISO_A2_EH is not
+	for _, key := range []string{"ISO_A2_EH", "ISO_A2"} {
+		if code, _ := f.Properties[key].(string); len(code) == 2 {
+			return code
+		}
+	}
+	return ""
+}
+
+var (
+	defaultStoreOnce sync.Once
+	defaultStore     *BoundaryStore
+)
+
+// CountryISO2 is ISO2 on a boundary store loaded once per process, for
+// callers that only need the code.
+func CountryISO2(country string) string {
+	if country == "" {
+		return ""
+	}
+	defaultStoreOnce.Do(func() {
+		defaultStore, _ = NewBoundaryStore()
+	})
+	if defaultStore == nil {
+		return ""
+	}
+	return defaultStore.ISO2(country)
+}
+
 // ListCountries returns all available country names.
 func (bs *BoundaryStore) ListCountries() []string {
 	seen := make(map[string]bool)
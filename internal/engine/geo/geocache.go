@@ -0,0 +1,92 @@
+package geo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// CachedGeocoder stores successful lookups of Next on disk, one JSON file per
+// normalized query, so repeated scans of a region resolve the same boundary
+// without a network round-trip.
+type CachedGeocoder struct {
+	Next Geocoder
+	Dir  string
+	// Namespace separates cache entries of different providers (e.g. the
+	// Nominatim base URL) so a local stand-in never serves public results.
+	Namespace string
+}
+
+// NewCachedGeocoder wraps next with an on-disk cache in dir.
+func NewCachedGeocoder(next Geocoder, dir, namespace string) *CachedGeocoder {
+	return &CachedGeocoder{Next: next, Dir: dir, Namespace: namespace}
+}
+
+type cachedRegion struct {
+	Name    string            `json:"name"`
+	Bound   [4]float64        `json:"bbox"` // [minLng, minLat, maxLng, maxLat]
+	Polygon *geojson.Geometry `json:"polygon,omitempty"`
+}
+
+// GeocodeRegion implements Geocoder. Cache read/write failures are ignored:
+// the cache only saves requests, it never changes results.
+func (c *CachedGeocoder) GeocodeRegion(region, country string) (*Region, error) {
+	path := c.path(region, country)
+
+	if data, err := os.ReadFile(path); err == nil {
+		var cr cachedRegion
+		if json.Unmarshal(data, &cr) == nil {
+			return cr.region(), nil
+		}
+	}
+
+	r, err := c.Next.GeocodeRegion(region, country)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := cachedRegion{
+		Name:  r.Name,
+		Bound: [4]float64{r.Bound.Min.Lon(), r.Bound.Min.Lat(), r.Bound.Max.Lon(), r.Bound.Max.Lat()},
+	}
+	if len(r.Polygon) > 0 {
+		cr.Polygon = geojson.NewGeometry(r.Polygon)
+	}
+	if data, err := json.Marshal(cr); err == nil {
+		if os.MkdirAll(c.Dir, 0755) == nil {
+			os.WriteFile(path, data, 0644)
+		}
+	}
+	return r, nil
+}
+
+func (c *CachedGeocoder) path(region, country string) string {
+	sum := sha256.Sum256([]byte(c.Namespace + "\n" + foldName(region) + "\n" + foldName(country)))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+func (cr cachedRegion) region() *Region {
+	r := &Region{
+		Name:  cr.Name,
+		Bound: orb.Bound{Min: orb.Point{cr.Bound[0], cr.Bound[1]}, Max: orb.Point{cr.Bound[2], cr.Bound[3]}},
+	}
+	if cr.Polygon != nil {
+		r.Polygon = appendPolygons(nil, cr.Polygon.Geometry())
+	}
+	return r
+}
+
+// geocodeCacheDir returns the directory geocoding results are cached in, ""
+// when the user has no config directory.
+func geocodeCacheDir() string {
+	cfg, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cfg, "geotap", "geocode")
+}
@@ -2,68 +2,220 @@ package geo
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// DefaultNominatimURL is the public OSM Nominatim instance.
+const DefaultNominatimURL = "https://nominatim.openstreetmap.org"
+
+// geocoderURLEnvVar overrides the Nominatim base URL, e.g. for a self-hosted
+// instance or a local stand-in server.
+const geocoderURLEnvVar = "GEOTAP_GEOCODER_URL"
+
+// ErrRegionNotFound is returned by a Geocoder that has no match for a query.
+var ErrRegionNotFound = errors.New("region not found")
+
+// Geocoder resolves a region name within a country to its boundary.
+type Geocoder interface {
+	GeocodeRegion(region, country string) (*Region, error)
+}
+
+// Region is a geocoded administrative area.
+type Region struct {
+	Name    string
+	Bound   orb.Bound
+	Polygon orb.MultiPolygon // boundary geometry; nil when the geocoder only returned a bbox
+}
+
+// NewGeocoder returns the online geocoder for baseURL: Nominatim behind the
+// on-disk cache. An empty baseURL uses $GEOTAP_GEOCODER_URL, or the public
+// Nominatim instance. Without a user config directory lookups go uncached,
+// rather than into the working directory.
+func NewGeocoder(baseURL string) Geocoder {
+	if baseURL == "" {
+		baseURL = os.Getenv(geocoderURLEnvVar)
+	}
+	if baseURL == "" {
+		baseURL = DefaultNominatimURL
+	}
+	dir := geocodeCacheDir()
+	if dir == "" {
+		return nominatimFor(baseURL)
+	}
+	return NewCachedGeocoder(nominatimFor(baseURL), dir, baseURL)
+}
+
+// --- Nominatim ---
+
+// NominatimGeocoder queries a Nominatim /search endpoint, at most once per
+// Interval as required by the OSM usage policy.
+type NominatimGeocoder struct {
+	BaseURL   string
+	UserAgent string
+	Interval  time.Duration
+	Client    *http.Client
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+var (
+	nominatimMu        sync.Mutex
+	nominatimInstances = make(map[string]*NominatimGeocoder)
 )
 
+// nominatimFor returns a process-wide instance per base URL so concurrent
+// lookups share one throttle.
+func nominatimFor(baseURL string) *NominatimGeocoder {
+	nominatimMu.Lock()
+	defer nominatimMu.Unlock()
+	g, ok := nominatimInstances[baseURL]
+	if !ok {
+		g = NewNominatimGeocoder(baseURL)
+		nominatimInstances[baseURL] = g
+	}
+	return g
+}
+
+// NewNominatimGeocoder creates a Nominatim client with geotap's defaults.
+func NewNominatimGeocoder(baseURL string) *NominatimGeocoder {
+	return &NominatimGeocoder{
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		UserAgent: "geotap/0.1 (geographic data scanner)",
+		Interval:  time.Second,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
 type nominatimResult struct {
-	BoundingBox []string `json:"boundingbox"` // [minLat, maxLat, minLng, maxLng]
-	DisplayName string   `json:"display_name"`
+	BoundingBox []string        `json:"boundingbox"` // [minLat, maxLat, minLng, maxLng]
+	DisplayName string          `json:"display_name"`
+	GeoJSON     json.RawMessage `json:"geojson"`
 }
 
-// GeocodeRegion returns the bounding box for a region within a country
-// using the OSM Nominatim API.
-func GeocodeRegion(region, country string) (minLat, minLng, maxLat, maxLng float64, err error) {
+// GeocodeRegion returns the boundary of a region within a country. The
+// polygon is requested with polygon_geojson; when the result has no areal
+// geometry only the bounding box is filled in.
+func (g *NominatimGeocoder) GeocodeRegion(region, country string) (*Region, error) {
 	q := region
 	if country != "" {
 		q = region + ", " + country
 	}
 
-	u := "https://nominatim.openstreetmap.org/search?" + url.Values{
-		"q":      {q},
-		"format": {"json"},
-		"limit":  {"1"},
+	u := g.BaseURL + "/search?" + url.Values{
+		"q":                 {q},
+		"format":            {"json"},
+		"limit":             {"1"},
+		"polygon_geojson":   {"1"},
+		"polygon_threshold": {"0.001"}, // simplify to ~100m; full-resolution borders are megabytes
 	}.Encode()
 
-	client := &http.Client{Timeout: 10 * time.Second}
 	req, err := http.NewRequest("GET", u, nil)
 	if err != nil {
-		return 0, 0, 0, 0, fmt.Errorf("creating request: %w", err)
+		return nil, fmt.Errorf("creating request: %w", err)
 	}
-	req.Header.Set("User-Agent", "geotap/0.1 (geographic data scanner)")
+	req.Header.Set("User-Agent", g.UserAgent)
 
-	resp, err := client.Do(req)
+	g.wait()
+	resp, err := g.Client.Do(req)
 	if err != nil {
-		return 0, 0, 0, 0, fmt.Errorf("geocoding request failed: %w", err)
+		return nil, fmt.Errorf("geocoding request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, 0, 0, 0, fmt.Errorf("geocoding returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("geocoding returned status %d", resp.StatusCode)
 	}
 
 	var results []nominatimResult
 	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return 0, 0, 0, 0, fmt.Errorf("decoding geocoding response: %w", err)
+		return nil, fmt.Errorf("decoding geocoding response: %w", err)
 	}
 
 	if len(results) == 0 {
-		return 0, 0, 0, 0, fmt.Errorf("region %q not found", q)
+		return nil, fmt.Errorf("%w: %q", ErrRegionNotFound, q)
 	}
 
-	bb := results[0].BoundingBox
+	return parseNominatimResult(results[0])
+}
+
+// wait blocks until Interval has passed since the previous request.
+func (g *NominatimGeocoder) wait() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if d := g.Interval - time.Since(g.last); d > 0 {
+		time.Sleep(d)
+	}
+	g.last = time.Now()
+}
+
+func parseNominatimResult(r nominatimResult) (*Region, error) {
+	bb := r.BoundingBox
 	if len(bb) < 4 {
-		return 0, 0, 0, 0, fmt.Errorf("invalid bounding box from geocoder")
+		return nil, fmt.Errorf("invalid bounding box from geocoder")
 	}
 
 	// Nominatim returns [minLat, maxLat, minLng, maxLng] as strings
-	minLat, _ = strconv.ParseFloat(bb[0], 64)
-	maxLat, _ = strconv.ParseFloat(bb[1], 64)
-	minLng, _ = strconv.ParseFloat(bb[2], 64)
-	maxLng, _ = strconv.ParseFloat(bb[3], 64)
+	minLat, _ := strconv.ParseFloat(bb[0], 64)
+	maxLat, _ := strconv.ParseFloat(bb[1], 64)
+	minLng, _ := strconv.ParseFloat(bb[2], 64)
+	maxLng, _ := strconv.ParseFloat(bb[3], 64)
+
+	region := &Region{
+		Name:  r.DisplayName,
+		Bound: orb.Bound{Min: orb.Point{minLng, minLat}, Max: orb.Point{maxLng, maxLat}},
+	}
+
+	// Cities and points of interest may come back as a Point: keep the bbox only
+	if len(r.GeoJSON) > 0 {
+		if g, err := geojson.UnmarshalGeometry(r.GeoJSON); err == nil {
+			region.Polygon = appendPolygons(nil, g.Geometry())
+		}
+	}
+
+	return region, nil
+}
+
+// --- Offline ---
+
+// OfflineGeocoder resolves regions from the admin-1 boundaries installed
+// next to the embedded country data. It never touches the network.
+type OfflineGeocoder struct {
+	Store *BoundaryStore
+}
 
-	return minLat, minLng, maxLat, maxLng, nil
+// GeocodeRegion implements Geocoder.
+func (g *OfflineGeocoder) GeocodeRegion(region, country string) (*Region, error) {
+	if r, ok := g.Store.GetRegion(country, region); ok {
+		return r, nil
+	}
+	return nil, fmt.Errorf("%w: %q in %q (offline)", ErrRegionNotFound, region, country)
+}
+
+// ChainGeocoder tries each geocoder in order and returns the first match.
+type ChainGeocoder []Geocoder
+
+// GeocodeRegion implements Geocoder. Lookups only fall through on
+// ErrRegionNotFound; any other error is returned as is.
+func (c ChainGeocoder) GeocodeRegion(region, country string) (*Region, error) {
+	err := fmt.Errorf("%w: %q", ErrRegionNotFound, region)
+	for _, g := range c {
+		var r *Region
+		r, err = g.GeocodeRegion(region, country)
+		if err == nil || !errors.Is(err, ErrRegionNotFound) {
+			return r, err
+		}
+	}
+	return nil, err
 }
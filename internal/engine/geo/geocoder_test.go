@@ -0,0 +1,297 @@
+package geo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// nominatimStandIn is a local Nominatim /search endpoint serving canned
+// results by query, recording when each request arrived.
+type nominatimStandIn struct {
+	*httptest.Server
+	results map[string]string // q → JSON response body
+
+	mu    sync.Mutex
+	times []time.Time
+	reqs  []*http.Request
+}
+
+func newNominatimStandIn(t *testing.T, results map[string]string) *nominatimStandIn {
+	t.Helper()
+	s := &nominatimStandIn{results: results}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.times = append(s.times, time.Now())
+		s.reqs = append(s.reqs, r)
+		s.mu.Unlock()
+
+		if r.URL.Path != "/search" {
+			http.NotFound(w, r)
+			return
+		}
+		body, ok := s.results[r.URL.Query().Get("q")]
+		if !ok {
+			body = "[]"
+		}
+		if body == "500" {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *nominatimStandIn) requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.times)
+}
+
+const (
+	// A region with its boundary polygon
+	polygonResult = `[{
+		"display_name": "Castilla y León, España",
+		"boundingbox": ["40.0", "43.0", "-7.0", "-2.0"],
+		"geojson": {"type": "Polygon", "coordinates": [[[-7,40],[-2,40],[-2,43],[-7,43],[-7,40]]]}
+	}]`
+	// A city returned as a point: only the bbox is usable
+	pointResult = `[{
+		"display_name": "Valladolid, España",
+		"boundingbox": ["41.55", "41.70", "-4.80", "-4.65"],
+		"geojson": {"type": "Point", "coordinates": [-4.72, 41.65]}
+	}]`
+)
+
+func newTestNominatim(baseURL string) *NominatimGeocoder {
+	g := NewNominatimGeocoder(baseURL)
+	g.Interval = 0
+	return g
+}
+
+func TestNominatimParse(t *testing.T) {
+	srv := newNominatimStandIn(t, map[string]string{
+		"Castilla y León, Spain": polygonResult,
+		"Valladolid, Spain":      pointResult,
+		"Nowhere, Spain":         `[{"display_name": "Nowhere", "boundingbox": ["1", "2"]}]`,
+	})
+	g := newTestNominatim(srv.URL)
+
+	tests := []struct {
+		region      string
+		wantName    string
+		wantPolygon bool
+		wantMinLng  float64
+		wantMaxLat  float64
+	}{
+		{"Castilla y León", "Castilla y León, España", true, -7, 43},
+		{"Valladolid", "Valladolid, España", false, -4.80, 41.70},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region, func(t *testing.T) {
+			r, err := g.GeocodeRegion(tt.region, "Spain")
+			if err != nil {
+				t.Fatalf("GeocodeRegion: %v", err)
+			}
+			if r.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", r.Name, tt.wantName)
+			}
+			if got := len(r.Polygon) > 0; got != tt.wantPolygon {
+				t.Errorf("has polygon = %t, want %t", got, tt.wantPolygon)
+			}
+			if r.Bound.Min.Lon() != tt.wantMinLng || r.Bound.Max.Lat() != tt.wantMaxLat {
+				t.Errorf("bound = %v, want min lng %v, max lat %v", r.Bound, tt.wantMinLng, tt.wantMaxLat)
+			}
+		})
+	}
+
+	t.Run("request", func(t *testing.T) {
+		srv.mu.Lock()
+		req := srv.reqs[0]
+		srv.mu.Unlock()
+		if ua := req.Header.Get("User-Agent"); ua == "" || ua == "Go-http-client/1.1" {
+			t.Errorf("User-Agent = %q, want geotap's", ua)
+		}
+		if q := req.URL.Query(); q.Get("polygon_geojson") != "1" || q.Get("format") != "json" {
+			t.Errorf("query = %v, want polygon_geojson=1 and format=json", q)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := g.GeocodeRegion("Atlantis", "Spain")
+		if !errors.Is(err, ErrRegionNotFound) {
+			t.Errorf("err = %v, want ErrRegionNotFound", err)
+		}
+	})
+
+	t.Run("invalid bbox", func(t *testing.T) {
+		if _, err := g.GeocodeRegion("Nowhere", "Spain"); err == nil {
+			t.Error("err = nil, want invalid bounding box")
+		}
+	})
+}
+
+func TestNominatimThrottle(t *testing.T) {
+	srv := newNominatimStandIn(t, map[string]string{"Valladolid, Spain": pointResult})
+	g := NewNominatimGeocoder(srv.URL)
+	g.Interval = 50 * time.Millisecond
+
+	// Concurrent lookups share the throttle
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := g.GeocodeRegion("Valladolid", "Spain"); err != nil {
+				t.Errorf("GeocodeRegion: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.times) != 4 {
+		t.Fatalf("requests = %d, want 4", len(srv.times))
+	}
+	for i := 1; i < len(srv.times); i++ {
+		// Allow for the request leaving the client before it is timestamped
+		if gap := srv.times[i].Sub(srv.times[i-1]); gap < g.Interval-10*time.Millisecond {
+			t.Errorf("gap between requests %d and %d = %v, want >= %v", i-1, i, gap, g.Interval)
+		}
+	}
+}
+
+// staticGeocoder returns a fixed region or error and counts its lookups.
+type staticGeocoder struct {
+	region *Region
+	err    error
+	calls  int
+}
+
+func (g *staticGeocoder) GeocodeRegion(region, country string) (*Region, error) {
+	g.calls++
+	return g.region, g.err
+}
+
+func TestChainGeocoder(t *testing.T) {
+	found := &Region{Name: "found"}
+	notFound := func() *staticGeocoder { return &staticGeocoder{err: ErrRegionNotFound} }
+	failing := func() *staticGeocoder { return &staticGeocoder{err: errors.New("network down")} }
+	ok := func() *staticGeocoder { return &staticGeocoder{region: found} }
+
+	tests := []struct {
+		name      string
+		chain     []*staticGeocoder
+		wantName  string
+		wantErr   bool
+		wantCalls []int
+	}{
+		{"first match", []*staticGeocoder{ok(), ok()}, "found", false, []int{1, 0}},
+		{"falls through not found", []*staticGeocoder{notFound(), ok()}, "found", false, []int{1, 1}},
+		{"stops at other errors", []*staticGeocoder{failing(), ok()}, "", true, []int{1, 0}},
+		{"none found", []*staticGeocoder{notFound(), notFound()}, "", true, []int{1, 1}},
+		{"empty", nil, "", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chain ChainGeocoder
+			for _, g := range tt.chain {
+				chain = append(chain, g)
+			}
+			r, err := chain.GeocodeRegion("León", "Spain")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+			if err == nil && r.Name != tt.wantName {
+				t.Errorf("name = %q, want %q", r.Name, tt.wantName)
+			}
+			for i, g := range tt.chain {
+				if g.calls != tt.wantCalls[i] {
+					t.Errorf("geocoder %d called %d times, want %d", i, g.calls, tt.wantCalls[i])
+				}
+			}
+		})
+	}
+
+	t.Run("nominatim not found", func(t *testing.T) {
+		srv := newNominatimStandIn(t, nil)
+		chain := ChainGeocoder{newTestNominatim(srv.URL), ok()}
+		if r, err := chain.GeocodeRegion("León", "Spain"); err != nil || r != found {
+			t.Errorf("GeocodeRegion = %v, %v, want the second geocoder's region", r, err)
+		}
+	})
+
+	t.Run("nominatim error", func(t *testing.T) {
+		srv := newNominatimStandIn(t, map[string]string{"León, Spain": "500"})
+		second := ok()
+		chain := ChainGeocoder{newTestNominatim(srv.URL), second}
+		if _, err := chain.GeocodeRegion("León", "Spain"); err == nil || errors.Is(err, ErrRegionNotFound) {
+			t.Errorf("err = %v, want the status error", err)
+		}
+		if second.calls != 0 {
+			t.Errorf("second geocoder called %d times, want 0", second.calls)
+		}
+	})
+}
+
+func TestCachedGeocoder(t *testing.T) {
+	srv := newNominatimStandIn(t, map[string]string{"Castilla y León, Spain": polygonResult})
+	dir := t.TempDir()
+	c := NewCachedGeocoder(newTestNominatim(srv.URL), dir, srv.URL)
+
+	first, err := c.GeocodeRegion("Castilla y León", "Spain")
+	if err != nil {
+		t.Fatalf("first lookup: %v", err)
+	}
+	// Names are folded, so a differently written query hits the same entry
+	second, err := c.GeocodeRegion("castilla y leon", "spain")
+	if err != nil {
+		t.Fatalf("second lookup: %v", err)
+	}
+	if n := srv.requests(); n != 1 {
+		t.Errorf("requests = %d, want 1 (second lookup from cache)", n)
+	}
+	if second.Name != first.Name || second.Bound != first.Bound {
+		t.Errorf("cached region = %q %v, want %q %v", second.Name, second.Bound, first.Name, first.Bound)
+	}
+	if len(second.Polygon) != len(first.Polygon) || len(second.Polygon) == 0 {
+		t.Errorf("cached polygon has %d polygons, want %d", len(second.Polygon), len(first.Polygon))
+	}
+
+	// Another provider does not share the entry
+	other := NewCachedGeocoder(newTestNominatim(srv.URL), dir, "elsewhere")
+	if _, err := other.GeocodeRegion("Castilla y León", "Spain"); err != nil {
+		t.Fatalf("other namespace: %v", err)
+	}
+	if n := srv.requests(); n != 2 {
+		t.Errorf("requests = %d, want 2 (other namespace misses the cache)", n)
+	}
+
+	// Misses are not cached
+	if _, err := c.GeocodeRegion("Atlantis", "Spain"); !errors.Is(err, ErrRegionNotFound) {
+		t.Fatalf("err = %v, want ErrRegionNotFound", err)
+	}
+	if _, err := c.GeocodeRegion("Atlantis", "Spain"); !errors.Is(err, ErrRegionNotFound) {
+		t.Fatalf("err = %v, want ErrRegionNotFound", err)
+	}
+	if n := srv.requests(); n != 4 {
+		t.Errorf("requests = %d, want 4 (misses go to the server)", n)
+	}
+}
+
+func TestNewGeocoderWithoutConfigDir(t *testing.T) {
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("AppData", "")
+
+	if _, ok := NewGeocoder("http://127.0.0.1:1").(*NominatimGeocoder); !ok {
+		t.Error("NewGeocoder without a config dir is cached, want plain Nominatim")
+	}
+}
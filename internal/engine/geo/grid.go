@@ -30,6 +30,7 @@ func GenerateGrid(minLat, minLng, maxLat, maxLng float64, zoom int) []model.Sect
 				Lat:  lat,
 				Lng:  lng,
 				Span: span,
+				Zoom: zoom,
 				Row:  row,
 				Col:  col,
 			})
@@ -41,6 +42,29 @@ func GenerateGrid(minLat, minLng, maxLat, maxLng float64, zoom int) []model.Sect
 	return sectors
 }
 
+// SplitSector divides a sector into its four quadrants at the next zoom level.
+// Child rows/cols are derived from the parent's (2*row+dr, 2*col+dc) so they stay
+// unique across the subdivision tree.
+func SplitSector(s model.Sector) []model.Sector {
+	half := s.Span / 2
+	lngSpan := s.Span / math.Cos(s.Lat*math.Pi/180.0)
+
+	children := make([]model.Sector, 0, 4)
+	for dr := 0; dr < 2; dr++ {
+		for dc := 0; dc < 2; dc++ {
+			children = append(children, model.Sector{
+				Lat:  s.Lat + (float64(dr)-0.5)*half,
+				Lng:  s.Lng + (float64(dc)-0.5)*lngSpan/2,
+				Span: half,
+				Zoom: s.Zoom + 1,
+				Row:  s.Row*2 + dr,
+				Col:  s.Col*2 + dc,
+			})
+		}
+	}
+	return children
+}
+
 // GenerateRadiusGrid creates a grid of sectors around a center point within a radius (km).
 func GenerateRadiusGrid(centerLat, centerLng, radiusKm float64, zoom int) []model.Sector {
 	// Convert radius to approximate degrees
@@ -0,0 +1,73 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/rendis/geotap/internal/model"
+)
+
+func TestSplitSector(t *testing.T) {
+	parents := []model.Sector{
+		{Lat: -33.45, Lng: -70.66, Span: ZoomToSpanDegrees(14), Zoom: 14, Row: 3, Col: 7},
+		{Lat: 0, Lng: 0, Span: ZoomToSpanDegrees(10), Zoom: 10},
+		{Lat: 64.1, Lng: -21.9, Span: ZoomToSpanDegrees(12), Zoom: 12, Row: 1, Col: 0},
+	}
+	for _, p := range parents {
+		// A quadrant is measured on the parent's longitude scale, which is
+		// the one SplitSector lays the children out on
+		lngSpan := p.Span / math.Cos(p.Lat*math.Pi/180.0)
+		quadrant := func(s model.Sector) orb.Bound {
+			return orb.Bound{
+				Min: orb.Point{s.Lng - lngSpan/4, s.Lat - s.Span/2},
+				Max: orb.Point{s.Lng + lngSpan/4, s.Lat + s.Span/2},
+			}
+		}
+		parent := orb.Bound{
+			Min: orb.Point{p.Lng - lngSpan/2, p.Lat - p.Span/2},
+			Max: orb.Point{p.Lng + lngSpan/2, p.Lat + p.Span/2},
+		}
+
+		children := SplitSector(p)
+		if len(children) != 4 {
+			t.Fatalf("SplitSector(%+v) = %d children, want 4", p, len(children))
+		}
+		var union orb.Bound
+		var area float64
+		cells := make(map[[2]int]bool)
+		for i, c := range children {
+			if c.Zoom != p.Zoom+1 || c.Span != p.Span/2 {
+				t.Errorf("child %d zoom %d span %v, want %d and %v", i, c.Zoom, c.Span, p.Zoom+1, p.Span/2)
+			}
+			if c.Row/2 != p.Row || c.Col/2 != p.Col {
+				t.Errorf("child %d at %d,%d, want under the parent's %d,%d", i, c.Row, c.Col, p.Row, p.Col)
+			}
+			cells[[2]int{c.Row, c.Col}] = true
+
+			b := quadrant(c)
+			if i == 0 {
+				union = b
+			} else {
+				union = union.Union(b)
+			}
+			area += (b.Max[0] - b.Min[0]) * (b.Max[1] - b.Min[1])
+		}
+		if len(cells) != 4 {
+			t.Errorf("children share cells: %v", cells)
+		}
+
+		// The children span the parent, and their areas add up to its area,
+		// so they neither leave gaps nor overlap
+		const eps = 1e-9
+		for i := 0; i < 2; i++ {
+			if math.Abs(union.Min[i]-parent.Min[i]) > eps || math.Abs(union.Max[i]-parent.Max[i]) > eps {
+				t.Errorf("children cover %v, want the parent's %v", union, parent)
+			}
+		}
+		parentArea := (parent.Max[0] - parent.Min[0]) * (parent.Max[1] - parent.Min[1])
+		if math.Abs(area-parentArea) > eps*parentArea {
+			t.Errorf("children area %v, want the parent's %v", area, parentArea)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package phone normalizes business phone numbers to E.164 with a built-in
+// table of calling codes and national numbering plans, so numbers displayed
+// in each country's local format can be matched across sources.
+package phone
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// extensionRe matches a trailing extension such as " ext. 12" or " x12".
+var extensionRe = regexp.MustCompile(`(?i)\s*(?:;ext=|ext\.?|extension|anexo|int\.|x|#)\s*\d{1,6}\s*$`)
+
+// codes is the set of country calling codes. Calling codes are prefix-free,
+// so the first match reading one to three digits is the code.
+var codes = func() map[string]bool {
+	m := make(map[string]bool)
+	for _, cc := range callingCodes {
+		m[cc] = true
+	}
+	return m
+}()
+
+// Known reports whether region is an ISO 3166-1 alpha-2 code with a calling
+// code.
+func Known(region string) bool {
+	return normalizeRegion(region) != ""
+}
+
+// Parse reads a phone number in international notation ("+34 912 34 56 78",
+// "0034 …") or, failing that, in the national notation of region (an ISO
+// 3166-1 alpha-2 code). Trunk prefixes, punctuation and extensions are
+// dropped. The result is nil when raw is empty; a number that does not fit
+// the numbering plan is returned with Valid unset and no E164.
+func Parse(raw, region string) *model.PhoneNumber {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	p := &model.PhoneNumber{Raw: raw}
+
+	digits, intl := digitsOf(extensionRe.ReplaceAllString(raw, ""))
+	region = normalizeRegion(region)
+	defaultCC := callingCodes[region]
+
+	// International access codes: 011 in North America, 00 elsewhere
+	if !intl {
+		switch {
+		case defaultCC == "1" && strings.HasPrefix(digits, "011"):
+			digits, intl = digits[3:], true
+		case defaultCC != "1" && strings.HasPrefix(digits, "00"):
+			digits, intl = digits[2:], true
+		}
+	}
+
+	cc, nsn := defaultCC, digits
+	if intl {
+		cc = callingCodeOf(digits)
+		if cc == "" {
+			return p
+		}
+		nsn = digits[len(cc):]
+		region = regionOf(cc, nsn, region)
+	}
+	if region == "" || nsn == "" {
+		return p
+	}
+
+	pl := planOf(region)
+	nsn = nationalNumber(nsn, cc, pl, intl)
+	// Without its trunk prefix a number may turn out to belong to another
+	// region sharing the calling code, like a Russian one read in Kazakhstan
+	if r := regionOf(cc, nsn, region); r != region {
+		region, pl = r, planOf(r)
+	}
+	p.Region = region
+	if !pl.validLength(nsn, cc) {
+		return p
+	}
+	p.Valid = true
+	p.E164 = "+" + cc + nsn
+	p.Type = pl.lineType(nsn)
+	return p
+}
+
+// ForPlace parses a place's phone in the place's country, or in fallback (an
+// ISO 3166-1 alpha-2 code, typically the scanned country) when the place's
+// country code is missing or unknown.
+func ForPlace(raw, countryCode, fallback string) *model.PhoneNumber {
+	if Known(countryCode) {
+		return Parse(raw, countryCode)
+	}
+	return Parse(raw, fallback)
+}
+
+// nationalNumber strips what may precede the national significant number:
+// a trunk prefix, also seen after the calling code as in "+44 (0)20 …", or
+// in national notation the calling code written without "+".
+func nationalNumber(nsn, cc string, pl plan, intl bool) string {
+	if pl.trunk != "" && strings.HasPrefix(nsn, pl.trunk) && pl.validLength(nsn[len(pl.trunk):], cc) {
+		return nsn[len(pl.trunk):]
+	}
+	if !intl && !pl.validLength(nsn, cc) && strings.HasPrefix(nsn, cc) && pl.validLength(nsn[len(cc):], cc) {
+		return nsn[len(cc):]
+	}
+	return nsn
+}
+
+// callingCodeOf returns the calling code digits start with, or "".
+func callingCodeOf(digits string) string {
+	for n := 1; n <= 3 && n <= len(digits); n++ {
+		if codes[digits[:n]] {
+			return digits[:n]
+		}
+	}
+	return ""
+}
+
+// digitZeros are the zero code points of the digit sets phones are
+// displayed in: ASCII, Arabic-Indic, Extended Arabic-Indic and fullwidth.
+var digitZeros = []rune{'0', '٠', '۰', '０'}
+
+// digitsOf keeps the digits of s as ASCII and reports whether a "+" came
+// before the first of them.
+func digitsOf(s string) (string, bool) {
+	var b strings.Builder
+	plus := false
+	for _, r := range s {
+		if (r == '+' || r == '＋') && b.Len() == 0 {
+			plus = true
+			continue
+		}
+		for _, zero := range digitZeros {
+			if r >= zero && r <= zero+9 {
+				b.WriteByte(byte('0' + r - zero))
+				break
+			}
+		}
+	}
+	return b.String(), plus
+}
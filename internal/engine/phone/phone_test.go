@@ -0,0 +1,100 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+func TestParse(t *testing.T) {
+	const (
+		mobile   = model.PhoneMobile
+		landline = model.PhoneLandline
+		tollFree = model.PhoneTollFree
+	)
+	tests := []struct {
+		raw, region string
+		e164        string // "" when invalid
+		wantRegion  string
+		typ         string
+	}{
+		// International notation
+		{"+34 912 34 56 78", "", "+34912345678", "ES", landline},
+		{"+34 612 34 56 78", "ES", "+34612345678", "ES", mobile},
+		{"0034 912 34 56 78", "ES", "+34912345678", "ES", landline},
+		{"011 44 20 7946 0958", "US", "+442079460958", "GB", landline},
+		{"+44 (0)20 7946 0958", "", "+442079460958", "GB", landline},
+		{"+1 416-555-0123", "CA", "+14165550123", "CA", ""},
+		{"+1 212-555-0123", "ES", "+12125550123", "US", ""},
+		{"＋３４ ９１２ ３４ ５６ ７８", "", "+34912345678", "ES", landline},
+
+		// National notation with trunk prefixes and extensions
+		{"020 7946 0958", "GB", "+442079460958", "GB", landline},
+		{"07700 900123", "gb", "+447700900123", "GB", mobile},
+		{"(212) 555-0123 ext. 12", "US", "+12125550123", "US", ""},
+		{"1 800 555 0199", "US", "+18005550199", "US", tollFree},
+		{"06 1 234 5678", "HU", "+3612345678", "HU", landline},
+		{"34 912 34 56 78", "ES", "+34912345678", "ES", landline},
+		{"(011) 4321-5678", "AR", "+541143215678", "AR", landline},
+		{"٠٤ ١٢٣ ٤٥٦٧", "AE", "+97141234567", "AE", landline},
+
+		// Australian service numbers are longer or shorter than geographic ones
+		{"(02) 9876 5432", "AU", "+61298765432", "AU", landline},
+		{"0412 345 678", "AU", "+61412345678", "AU", mobile},
+		{"1800 123 456", "AU", "+611800123456", "AU", tollFree},
+		{"+61 1800 123 456", "", "+611800123456", "AU", tollFree},
+		{"1300 123 456", "AU", "+611300123456", "AU", landline},
+		{"13 12 34", "AU", "+61131234", "AU", landline},
+		{"1800 123 45", "AU", "", "AU", ""},
+		{"1800 123 456", "IE", "+3531800123456", "IE", tollFree},
+		{"1-800-123-456", "IL", "+9721800123456", "IL", tollFree},
+
+		// +7 is shared by Russia and Kazakhstan
+		{"+7 727 258 1234", "KZ", "+77272581234", "KZ", landline},
+		{"+7 701 123 4567", "KZ", "+77011234567", "KZ", mobile},
+		{"+7 495 123 4567", "KZ", "+74951234567", "RU", landline},
+		{"+7 727 258 1234", "RU", "+77272581234", "KZ", landline},
+		{"+7 727 258 1234", "", "+77272581234", "KZ", landline},
+		{"+7 916 123 4567", "", "+79161234567", "RU", mobile},
+		{"8 (727) 258-12-34", "KZ", "+77272581234", "KZ", landline},
+		{"8 (495) 123-45-67", "KZ", "+74951234567", "RU", landline},
+		{"8 (727) 258-12-34", "RU", "+77272581234", "KZ", landline},
+
+		// Invalid: wrong length, unknown calling code, no region to read in
+		{"912 34 56", "ES", "", "ES", ""},
+		{"+999 1234 5678", "", "", "", ""},
+		{"912 34 56 78", "", "", "", ""},
+		{"912 34 56 78", "XX", "", "", ""},
+	}
+	for _, tt := range tests {
+		p := Parse(tt.raw, tt.region)
+		if p == nil {
+			t.Errorf("Parse(%q, %q) = nil", tt.raw, tt.region)
+			continue
+		}
+		if p.Raw != tt.raw || p.E164 != tt.e164 || p.Valid != (tt.e164 != "") || p.Region != tt.wantRegion || p.Type != tt.typ {
+			t.Errorf("Parse(%q, %q) = %+v, want e164 %q region %q type %q", tt.raw, tt.region, *p, tt.e164, tt.wantRegion, tt.typ)
+		}
+	}
+
+	if p := Parse("  ", "ES"); p != nil {
+		t.Errorf("Parse of a blank number = %+v, want nil", *p)
+	}
+}
+
+func TestForPlace(t *testing.T) {
+	tests := []struct {
+		raw, country, fallback string
+		want                   string
+	}{
+		{"020 7946 0958", "GB", "ES", "+442079460958"},
+		{"020 7946 0958", "", "GB", "+442079460958"},
+		{"020 7946 0958", "ZZ", "GB", "+442079460958"},
+		{"912 34 56 78", "ES", "GB", "+34912345678"},
+	}
+	for _, tt := range tests {
+		if got := ForPlace(tt.raw, tt.country, tt.fallback); got.E164 != tt.want {
+			t.Errorf("ForPlace(%q, %q, %q) = %q, want %q", tt.raw, tt.country, tt.fallback, got.E164, tt.want)
+		}
+	}
+}
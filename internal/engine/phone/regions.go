@@ -0,0 +1,269 @@
+package phone
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// callingCodes maps ISO 3166-1 alpha-2 codes to country calling codes.
+var callingCodes = map[string]string{
+	"AD": "376", "AE": "971", "AF": "93", "AG": "1", "AI": "1", "AL": "355", "AM": "374", "AO": "244",
+	"AR": "54", "AS": "1", "AT": "43", "AU": "61", "AW": "297", "AX": "358", "AZ": "994", "BA": "387",
+	"BB": "1", "BD": "880", "BE": "32", "BF": "226", "BG": "359", "BH": "973", "BI": "257", "BJ": "229",
+	"BL": "590", "BM": "1", "BN": "673", "BO": "591", "BQ": "599", "BR": "55", "BS": "1", "BT": "975",
+	"BW": "267", "BY": "375", "BZ": "501", "CA": "1", "CC": "61", "CD": "243", "CF": "236", "CG": "242",
+	"CH": "41", "CI": "225", "CK": "682", "CL": "56", "CM": "237", "CN": "86", "CO": "57", "CR": "506",
+	"CU": "53", "CV": "238", "CW": "599", "CX": "61", "CY": "357", "CZ": "420", "DE": "49", "DJ": "253",
+	"DK": "45", "DM": "1", "DO": "1", "DZ": "213", "EC": "593", "EE": "372", "EG": "20", "EH": "212",
+	"ER": "291", "ES": "34", "ET": "251", "FI": "358", "FJ": "679", "FK": "500", "FM": "691", "FO": "298",
+	"FR": "33", "GA": "241", "GB": "44", "GD": "1", "GE": "995", "GF": "594", "GG": "44", "GH": "233",
+	"GI": "350", "GL": "299", "GM": "220", "GN": "224", "GP": "590", "GQ": "240", "GR": "30", "GT": "502",
+	"GU": "1", "GW": "245", "GY": "592", "HK": "852", "HN": "504", "HR": "385", "HT": "509", "HU": "36",
+	"ID": "62", "IE": "353", "IL": "972", "IM": "44", "IN": "91", "IO": "246", "IQ": "964", "IR": "98",
+	"IS": "354", "IT": "39", "JE": "44", "JM": "1", "JO": "962", "JP": "81", "KE": "254", "KG": "996",
+	"KH": "855", "KI": "686", "KM": "269", "KN": "1", "KP": "850", "KR": "82", "KW": "965", "KY": "1",
+	"KZ": "7", "LA": "856", "LB": "961", "LC": "1", "LI": "423", "LK": "94", "LR": "231", "LS": "266",
+	"LT": "370", "LU": "352", "LV": "371", "LY": "218", "MA": "212", "MC": "377", "MD": "373", "ME": "382",
+	"MF": "590", "MG": "261", "MH": "692", "MK": "389", "ML": "223", "MM": "95", "MN": "976", "MO": "853",
+	"MP": "1", "MQ": "596", "MR": "222", "MS": "1", "MT": "356", "MU": "230", "MV": "960", "MW": "265",
+	"MX": "52", "MY": "60", "MZ": "258", "NA": "264", "NC": "687", "NE": "227", "NF": "672", "NG": "234",
+	"NI": "505", "NL": "31", "NO": "47", "NP": "977", "NR": "674", "NU": "683", "NZ": "64", "OM": "968",
+	"PA": "507", "PE": "51", "PF": "689", "PG": "675", "PH": "63", "PK": "92", "PL": "48", "PM": "508",
+	"PR": "1", "PS": "970", "PT": "351", "PW": "680", "PY": "595", "QA": "974", "RE": "262", "RO": "40",
+	"RS": "381", "RU": "7", "RW": "250", "SA": "966", "SB": "677", "SC": "248", "SD": "249", "SE": "46",
+	"SG": "65", "SH": "290", "SI": "386", "SJ": "47", "SK": "421", "SL": "232", "SM": "378", "SN": "221",
+	"SO": "252", "SR": "597", "SS": "211", "ST": "239", "SV": "503", "SX": "1", "SY": "963", "SZ": "268",
+	"TC": "1", "TD": "235", "TG": "228", "TH": "66", "TJ": "992", "TK": "690", "TL": "670", "TM": "993",
+	"TN": "216", "TO": "676", "TR": "90", "TT": "1", "TV": "688", "TW": "886", "TZ": "255", "UA": "380",
+	"UG": "256", "US": "1", "UY": "598", "UZ": "998", "VA": "39", "VC": "1", "VE": "58", "VG": "1",
+	"VI": "1", "VN": "84", "VU": "678", "WF": "681", "WS": "685", "XK": "383", "YE": "967", "YT": "262",
+	"ZA": "27", "ZM": "260", "ZW": "263",
+}
+
+// mainRegions picks the country an international number belongs to when
+// several share its calling code and the default region is not one of them.
+var mainRegions = map[string]string{
+	"1": "US", "7": "RU", "39": "IT", "44": "GB", "47": "NO", "61": "AU",
+	"212": "MA", "262": "RE", "358": "FI", "590": "GP", "599": "CW",
+}
+
+// plan is the part of a national numbering plan needed to validate numbers
+// and tell mobiles apart. Prefixes apply to the national significant number
+// (without trunk prefix); "." in a prefix matches any digit.
+type plan struct {
+	trunk    string   // national prefix dialled before the number, "" when none
+	lengths  []int    // valid national significant number lengths
+	mobile   []string // nil when mobiles cannot be told from landlines
+	tollFree []string
+	// services are number ranges, such as toll-free ones, whose length
+	// differs from geographic and mobile numbers. The first match applies.
+	services []service
+}
+
+// service is a number range with lengths of its own.
+type service struct {
+	prefix  string
+	lengths []int
+}
+
+// nanp is the plan shared by the countries of the North American Numbering
+// Plan, where mobile and landline numbers share area codes.
+var nanp = plan{trunk: "1", lengths: []int{10}, tollFree: []string{"800", "833", "844", "855", "866", "877", "888"}}
+
+// plans holds the numbering plans geotap knows in detail. Other countries
+// fall back to a trunk prefix of "0" (unless listed in noTrunk) and any
+// length E.164 allows.
+var plans = map[string]plan{
+	"US": nanp, "CA": nanp, "PR": nanp, "DO": nanp, "JM": nanp, "TT": nanp, "BS": nanp, "BB": nanp,
+
+	"GB": {trunk: "0", lengths: []int{9, 10}, mobile: []string{"71", "72", "73", "74", "75", "77", "78", "79"}, tollFree: []string{"800", "808"}},
+	"IE": {trunk: "0", lengths: []int{7, 8, 9}, mobile: []string{"83", "85", "86", "87", "89"}, tollFree: []string{"1800"},
+		services: []service{{"1800", []int{10}}, {"1850", []int{10}}, {"1890", []int{10}}}},
+	"ES": {lengths: []int{9}, mobile: []string{"6", "71", "72", "73", "74"}, tollFree: []string{"800", "900"}},
+	"PT": {lengths: []int{9}, mobile: []string{"91", "92", "93", "96"}, tollFree: []string{"800"}},
+	"FR": {trunk: "0", lengths: []int{9}, mobile: []string{"6", "7"}, tollFree: []string{"80"}},
+	"DE": {trunk: "0", lengths: []int{6, 7, 8, 9, 10, 11}, mobile: []string{"15", "16", "17"}, tollFree: []string{"800"}},
+	"IT": {lengths: []int{6, 7, 8, 9, 10, 11}, mobile: []string{"3"}, tollFree: []string{"800", "803"}},
+	"NL": {trunk: "0", lengths: []int{9}, mobile: []string{"6"}, tollFree: []string{"800"}},
+	"BE": {trunk: "0", lengths: []int{8, 9}, mobile: []string{"46", "47", "48", "49"}, tollFree: []string{"800"}},
+	"CH": {trunk: "0", lengths: []int{9}, mobile: []string{"74", "75", "76", "77", "78", "79"}, tollFree: []string{"800"}},
+	"AT": {trunk: "0", lengths: []int{4, 5, 6, 7, 8, 9, 10, 11, 12, 13}, mobile: []string{"6"}, tollFree: []string{"800"}},
+	"PL": {lengths: []int{9}, mobile: []string{"45", "5", "6", "7", "88"}, tollFree: []string{"800"}},
+	"SE": {trunk: "0", lengths: []int{7, 8, 9, 10}, mobile: []string{"70", "72", "73", "76", "79"}, tollFree: []string{"20"}},
+	"NO": {lengths: []int{8}, mobile: []string{"4", "9"}, tollFree: []string{"80"}},
+	"DK": {lengths: []int{8}, tollFree: []string{"80"}},
+	"FI": {trunk: "0", lengths: []int{5, 6, 7, 8, 9, 10, 11, 12}, mobile: []string{"4", "50"}, tollFree: []string{"800"}},
+	"GR": {lengths: []int{10}, mobile: []string{"69"}, tollFree: []string{"800"}},
+	"CZ": {lengths: []int{9}, mobile: []string{"6", "7"}, tollFree: []string{"800"}},
+	"SK": {trunk: "0", lengths: []int{9}, mobile: []string{"9"}, tollFree: []string{"800"}},
+	"HU": {trunk: "06", lengths: []int{8, 9}, mobile: []string{"20", "30", "31", "50", "70"}, tollFree: []string{"80"}},
+	"RO": {trunk: "0", lengths: []int{9}, mobile: []string{"7"}, tollFree: []string{"800"}},
+	"HR": {trunk: "0", lengths: []int{8, 9}, mobile: []string{"9"}, tollFree: []string{"800"}},
+	"UA": {trunk: "0", lengths: []int{9}, mobile: []string{"39", "50", "63", "66", "67", "68", "73", "9"}, tollFree: []string{"800"}},
+	"RU": {trunk: "8", lengths: []int{10}, mobile: []string{"9"}, tollFree: []string{"800"}},
+	"KZ": {trunk: "8", lengths: []int{10}, mobile: []string{"70", "747", "75", "76", "77"}, tollFree: []string{"800"}},
+	"TR": {trunk: "0", lengths: []int{10}, mobile: []string{"5"}, tollFree: []string{"800"}},
+
+	"MX": {lengths: []int{10}, tollFree: []string{"800"}},
+	"AR": {trunk: "0", lengths: []int{10, 11}, mobile: []string{"9"}, tollFree: []string{"800"}},
+	"BR": {trunk: "0", lengths: []int{10, 11}, mobile: []string{"..9"}, tollFree: []string{"800"}},
+	"CL": {lengths: []int{9}, mobile: []string{"9"}, tollFree: []string{"800"}},
+	"CO": {lengths: []int{10}, mobile: []string{"3"}, tollFree: []string{"1800"}},
+	"PE": {trunk: "0", lengths: []int{8, 9}, mobile: []string{"9"}, tollFree: []string{"800"}},
+	"UY": {trunk: "0", lengths: []int{8}, mobile: []string{"9"}, tollFree: []string{"800"}},
+	"VE": {trunk: "0", lengths: []int{10}, mobile: []string{"4"}, tollFree: []string{"800"}},
+	"EC": {trunk: "0", lengths: []int{8, 9}, mobile: []string{"9"}, tollFree: []string{"1800"},
+		services: []service{{"1800", []int{10, 11}}}},
+	"BO": {trunk: "0", lengths: []int{8}, mobile: []string{"6", "7"}, tollFree: []string{"800"}},
+	"PY": {trunk: "0", lengths: []int{9}, mobile: []string{"9"}, tollFree: []string{"800"}},
+	"CR": {lengths: []int{8}, mobile: []string{"6", "7", "8"}, tollFree: []string{"800"}},
+	"PA": {lengths: []int{7, 8}, mobile: []string{"6"}, tollFree: []string{"800"}},
+	"GT": {lengths: []int{8}, mobile: []string{"3", "4", "5"}, tollFree: []string{"1800"}},
+
+	"AU": {trunk: "0", lengths: []int{9}, mobile: []string{"4"}, tollFree: []string{"180"},
+		services: []service{{"1800", []int{10}}, {"1802", []int{7}}, {"1300", []int{10}}, {"13", []int{6}}}},
+	"NZ": {trunk: "0", lengths: []int{8, 9, 10}, mobile: []string{"2"}, tollFree: []string{"800"}},
+	"JP": {trunk: "0", lengths: []int{9, 10}, mobile: []string{"70", "80", "90"}, tollFree: []string{"120", "800"}},
+	"KR": {trunk: "0", lengths: []int{8, 9, 10}, mobile: []string{"1"}, tollFree: []string{"80"}},
+	"CN": {trunk: "0", lengths: []int{10, 11}, mobile: []string{"13", "14", "15", "16", "17", "18", "19"}, tollFree: []string{"800", "400"}},
+	"IN": {trunk: "0", lengths: []int{10}, mobile: []string{"6", "7", "8", "9"}, tollFree: []string{"1800"},
+		services: []service{{"1800", []int{8, 9, 10, 11, 12, 13}}}},
+	"PH": {trunk: "0", lengths: []int{9, 10}, mobile: []string{"9"}, tollFree: []string{"1800"},
+		services: []service{{"1800", []int{11, 12, 13}}}},
+	"ID": {trunk: "0", lengths: []int{8, 9, 10, 11, 12}, mobile: []string{"8"}, tollFree: []string{"800"}},
+	"TH": {trunk: "0", lengths: []int{8, 9}, mobile: []string{"6", "8", "9"}, tollFree: []string{"1800"},
+		services: []service{{"1800", []int{10}}}},
+	"VN": {trunk: "0", lengths: []int{9, 10}, mobile: []string{"3", "5", "7", "8", "9"}, tollFree: []string{"1800"},
+		services: []service{{"1800", []int{8, 9, 10}}}},
+	"MY": {trunk: "0", lengths: []int{8, 9, 10}, mobile: []string{"1"}, tollFree: []string{"1800"}},
+	"SG": {lengths: []int{8}, mobile: []string{"8", "9"}, tollFree: []string{"800"}},
+	"HK": {lengths: []int{8}, mobile: []string{"5", "6", "9"}, tollFree: []string{"800"}},
+	"TW": {trunk: "0", lengths: []int{8, 9}, mobile: []string{"9"}, tollFree: []string{"80"}},
+
+	"ZA": {trunk: "0", lengths: []int{9}, mobile: []string{"6", "7", "8"}, tollFree: []string{"80"}},
+	"IL": {trunk: "0", lengths: []int{8, 9}, mobile: []string{"5"}, tollFree: []string{"1800"},
+		services: []service{{"1700", []int{10}}, {"1800", []int{10}}}},
+	"AE": {trunk: "0", lengths: []int{8, 9}, mobile: []string{"5"}, tollFree: []string{"800"}},
+	"SA": {trunk: "0", lengths: []int{9}, mobile: []string{"5"}, tollFree: []string{"800"}},
+	"EG": {trunk: "0", lengths: []int{9, 10}, mobile: []string{"1"}, tollFree: []string{"800"}},
+	"MA": {trunk: "0", lengths: []int{9}, mobile: []string{"6", "7"}, tollFree: []string{"80"}},
+	"NG": {trunk: "0", lengths: []int{8, 10}, mobile: []string{"70", "80", "81", "90", "91"}, tollFree: []string{"800"}},
+	"KE": {trunk: "0", lengths: []int{9}, mobile: []string{"1", "7"}, tollFree: []string{"800"}},
+}
+
+// noTrunk lists the countries without plan details whose national numbers
+// are dialled without a trunk prefix.
+var noTrunk = map[string]bool{
+	"AD": true, "BH": true, "BZ": true, "CY": true, "EE": true, "HN": true, "IS": true, "KW": true,
+	"LU": true, "LV": true, "MC": true, "MO": true, "MT": true, "NI": true, "OM": true, "QA": true,
+	"SM": true, "SV": true, "VA": true,
+}
+
+// planOf returns the numbering plan of a region, detailed or generic.
+func planOf(region string) plan {
+	if p, ok := plans[region]; ok {
+		return p
+	}
+	if noTrunk[region] {
+		return plan{}
+	}
+	return plan{trunk: "0"}
+}
+
+// hasPrefix reports whether nsn starts with one of prefixes.
+func hasPrefix(nsn string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if len(nsn) < len(p) {
+			continue
+		}
+		match := true
+		for i := 0; i < len(p); i++ {
+			if p[i] != '.' && p[i] != nsn[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// validLength reports whether nsn has a length the plan allows, or its
+// service range allows. Plans without lengths accept what E.164 allows: at
+// most 15 digits with the calling code, and at least 4 national digits.
+func (p plan) validLength(nsn, cc string) bool {
+	lengths := p.lengths
+	for _, s := range p.services {
+		if strings.HasPrefix(nsn, s.prefix) {
+			lengths = s.lengths
+			break
+		}
+	}
+	if lengths == nil {
+		return len(nsn) >= 4 && len(cc)+len(nsn) <= 15
+	}
+	return slices.Contains(lengths, len(nsn))
+}
+
+// lineType infers the model.Phone* type of a valid national number.
+func (p plan) lineType(nsn string) string {
+	switch {
+	case hasPrefix(nsn, p.tollFree):
+		return model.PhoneTollFree
+	case p.mobile == nil:
+		return ""
+	case hasPrefix(nsn, p.mobile):
+		return model.PhoneMobile
+	}
+	return model.PhoneLandline
+}
+
+// leadingDigits tells apart regions sharing a calling code by the first
+// digits of their national numbers, where the code is split that way.
+// Numbers matching none belong to the code's main region.
+var leadingDigits = map[string]map[string][]string{
+	"7": {"KZ": {"6", "7"}},
+}
+
+// regionOf returns the region of a number with calling code cc and
+// national number nsn, read with def as the default region: the region its
+// leading digits belong to, else def when it has cc, else cc's main region.
+func regionOf(cc, nsn, def string) string {
+	split := leadingDigits[cc]
+	for region, prefixes := range split {
+		if hasPrefix(nsn, prefixes) {
+			return region
+		}
+	}
+	if _, ok := split[def]; !ok && callingCodes[def] == cc {
+		return def
+	}
+	return regionsOf(cc)[0]
+}
+
+// regionsOf returns the regions sharing a calling code, main region first.
+func regionsOf(cc string) []string {
+	var regions []string
+	if main, ok := mainRegions[cc]; ok {
+		regions = append(regions, main)
+	}
+	for region, code := range callingCodes {
+		if code == cc && region != mainRegions[cc] {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// normalizeRegion uppercases a region code, returning "" for unknown ones.
+func normalizeRegion(region string) string {
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if _, ok := callingCodes[region]; !ok {
+		return ""
+	}
+	return region
+}
@@ -2,7 +2,6 @@ package scraper
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io"
 	"math/rand/v2"
@@ -18,14 +17,21 @@ import (
 )
 
 const (
-	searchBaseURL = "https://www.google.com/search"
+	searchBaseURL  = "https://www.google.com/search"
+	detailsBaseURL = "https://www.google.com/maps"
+	reviewsBaseURL = "https://www.google.com/maps/rpc/listugcposts"
 
 	maxRetries   = 3
-	baseBackoff  = 2 * time.Second
-	maxBackoff   = 30 * time.Second
 	jitterFactor = 0.5
 )
 
+// Rate-limit backoff, doubling per attempt. Variables so tests against a
+// local fake server need not wait seconds.
+var (
+	baseBackoff = 2 * time.Second
+	maxBackoff  = 30 * time.Second
+)
+
 var userAgents = []string{
 	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
 	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
@@ -43,8 +49,12 @@ func (e *RateLimitError) Error() string {
 
 type Client struct {
 	http       *http.Client
+	baseURL    string
+	detailsURL string
+	reviewsURL string
 	lang       string
 	zoom       int
+	retries    int // attempts per request on rate limit
 	rateLimits atomic.Int64
 }
 
@@ -55,12 +65,23 @@ func NewClient(lang, proxyURL string, zoom int) *Client {
 		{Name: "CONSENT", Value: "YES+ES.es+V14+BX", Path: "/", Domain: ".google.com"},
 	})
 
-	dialer := &net.Dialer{
-		Timeout:   10 * time.Second,
-		KeepAlive: 30 * time.Second,
+	dialer := &tunnelDialer{
+		dialer: &net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+	}
+	if proxyURL != "" {
+		if parsed, err := url.Parse(proxyURL); err == nil {
+			// Tunnel through the proxy in the dialer so the utls handshake
+			// below is end-to-end with Google, not terminated by net/http
+			dialer.proxy = parsed
+		}
 	}
 
 	transport := &http.Transport{
+		// Plain http:// endpoints, such as fakemaps, go through the proxy too
+		DialContext: dialer.DialContext,
 		DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
 			conn, err := dialer.DialContext(ctx, network, addr)
 			if err != nil {
@@ -106,16 +127,6 @@ func NewClient(lang, proxyURL string, zoom int) *Client {
 		DisableKeepAlives:   false,
 	}
 
-	if proxyURL != "" {
-		proxyParsed, err := url.Parse(proxyURL)
-		if err == nil {
-			transport.Proxy = http.ProxyURL(proxyParsed)
-			// When using proxy, fall back to standard TLS (proxy handles connection)
-			transport.DialTLSContext = nil
-			transport.TLSClientConfig = &tls.Config{}
-		}
-	}
-
 	return &Client{
 		http: &http.Client{
 			Transport: transport,
@@ -125,27 +136,65 @@ func NewClient(lang, proxyURL string, zoom int) *Client {
 				return http.ErrUseLastResponse
 			},
 		},
-		lang: lang,
-		zoom: zoom,
+		baseURL:    searchBaseURL,
+		detailsURL: detailsBaseURL,
+		reviewsURL: reviewsBaseURL,
+		lang:       lang,
+		zoom:       zoom,
+		retries:    maxRetries,
 	}
 }
 
+// SetBaseURL points the client at another tbm=map endpoint, such as a local
+// fake server. Plain http:// URLs skip the utls handshake.
+func (c *Client) SetBaseURL(u string) {
+	c.baseURL = u
+}
+
+// SetDetailsURL points the client at another place details endpoint.
+func (c *Client) SetDetailsURL(u string) {
+	c.detailsURL = u
+}
+
+// SetReviewsURL points the client at another review listing endpoint.
+func (c *Client) SetReviewsURL(u string) {
+	c.reviewsURL = u
+}
+
 // SearchMap performs a Maps search (tbm=map) with retry and exponential backoff.
-func (c *Client) SearchMap(sector model.Sector, query string, offset int) ([]byte, error) {
-	pb := BuildPB(sector.Lat, sector.Lng, c.zoom, offset)
+// Cancelling ctx aborts the in-flight request and any pending backoff.
+func (c *Client) SearchMap(ctx context.Context, sector model.Sector, query string, offset int) ([]byte, error) {
+	return c.get(ctx, c.searchURL(sector, query, offset))
+}
 
+// PlaceDetails fetches the detail page of a place by CID, or by Place ID
+// when the CID is unknown, with the same retry and backoff as SearchMap.
+func (c *Client) PlaceDetails(ctx context.Context, cid, placeID string) ([]byte, error) {
 	params := url.Values{}
-	params.Set("tbm", "map")
-	params.Set("authuser", "0")
+	if cid != "" {
+		params.Set("cid", cid)
+	} else {
+		params.Set("q", "place_id:"+placeID)
+	}
 	params.Set("hl", c.lang)
-	params.Set("q", query)
-	params.Set("pb", pb)
+	return c.get(ctx, c.detailsURL+"?"+params.Encode())
+}
 
-	reqURL := searchBaseURL + "?" + params.Encode()
+// Reviews fetches one page of a place's reviews, newest first. token is the
+// continuation token of the previous page, empty for the first one.
+func (c *Client) Reviews(ctx context.Context, cid, token string) ([]byte, error) {
+	params := url.Values{}
+	params.Set("authuser", "0")
+	params.Set("hl", c.lang)
+	params.Set("pb", BuildReviewsPB(cid, token))
+	return c.get(ctx, c.reviewsURL+"?"+params.Encode())
+}
 
+// get fetches reqURL, retrying rate limits with exponential backoff.
+func (c *Client) get(ctx context.Context, reqURL string) ([]byte, error) {
 	var lastErr error
-	for attempt := range maxRetries {
-		body, err := c.doRequest(reqURL)
+	for attempt := range c.retries {
+		body, err := c.doRequest(ctx, reqURL)
 		if err == nil {
 			c.rateLimits.Store(0)
 			return body, nil
@@ -158,25 +207,48 @@ func (c *Client) SearchMap(sector model.Sector, query string, offset int) ([]byt
 		}
 
 		c.rateLimits.Add(1)
+		if attempt == c.retries-1 {
+			break
+		}
 
 		backoff := baseBackoff * time.Duration(1<<uint(attempt))
 		if backoff > maxBackoff {
 			backoff = maxBackoff
 		}
 		jitter := time.Duration(float64(backoff) * jitterFactor * rand.Float64())
-		time.Sleep(backoff + jitter)
+		if err := sleepCtx(ctx, backoff+jitter); err != nil {
+			return nil, err
+		}
 	}
 
 	return nil, lastErr
 }
 
+// searchURL builds the tbm=map request URL for a sector, query and result offset.
+func (c *Client) searchURL(sector model.Sector, query string, offset int) string {
+	zoom := c.zoom
+	if sector.Zoom > 0 {
+		zoom = sector.Zoom
+	}
+	pb := BuildPB(sector.Lat, sector.Lng, zoom, offset)
+
+	params := url.Values{}
+	params.Set("tbm", "map")
+	params.Set("authuser", "0")
+	params.Set("hl", c.lang)
+	params.Set("q", query)
+	params.Set("pb", pb)
+
+	return c.baseURL + "?" + params.Encode()
+}
+
 // ConsecutiveRateLimits returns how many consecutive rate limits have occurred across all workers.
 func (c *Client) ConsecutiveRateLimits() int64 {
 	return c.rateLimits.Load()
 }
 
-func (c *Client) doRequest(reqURL string) ([]byte, error) {
-	req, err := http.NewRequest("GET", reqURL, nil)
+func (c *Client) doRequest(ctx context.Context, reqURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("building request: %w", err)
 	}
@@ -212,3 +284,15 @@ func (c *Client) doRequest(reqURL string) ([]byte, error) {
 
 	return body, nil
 }
+
+// sleepCtx waits for d or until ctx is cancelled, whichever comes first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,211 @@
+package scraper
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+)
+
+// Drift detection works on windows of parsed businesses: once a window is
+// full, each field's fill rate in it is compared against the rate over all
+// earlier windows of the scan and the project's earlier scans of the same
+// queries. A drop is flagged when it is both large (below half the
+// baseline) and significant (binomial z-score <= -driftZ).
+const (
+	coverageWindow    = 200
+	coverageMinWindow = 50 // smallest trailing window Flush still checks
+	driftZ            = 4.0
+)
+
+// coverageField is a Business field whose fill rate is tracked.
+type coverageField struct {
+	name   string
+	filled func(b *model.Business) bool
+	// floor is the fill rate the field has whenever the parser indices are
+	// right; a scan far below it is flagged even without an earlier baseline.
+	// Zero for fields whose availability depends on the query.
+	floor float64
+}
+
+var coverageFields = []coverageField{
+	{name: "cid", filled: func(b *model.Business) bool { return b.CID != "" }, floor: 0.9},
+	{name: "place_id", filled: func(b *model.Business) bool { return b.PlaceID != "" }, floor: 0.9},
+	{name: "coords", filled: func(b *model.Business) bool { return b.Lat != 0 || b.Lng != 0 }, floor: 0.9},
+	{name: "category", filled: func(b *model.Business) bool { return b.Category != "" }, floor: 0.7},
+	{name: "address", filled: func(b *model.Business) bool { return b.Address != "" }, floor: 0.5},
+	{name: "rating", filled: func(b *model.Business) bool { return b.Rating > 0 }},
+	{name: "review_count", filled: func(b *model.Business) bool { return b.ReviewCount > 0 }},
+	{name: "phone", filled: func(b *model.Business) bool { return b.Phone != "" }},
+	{name: "website", filled: func(b *model.Business) bool { return b.Website != "" }},
+	{name: "price_range", filled: func(b *model.Business) bool { return b.PriceRange != "" }},
+	{name: "description", filled: func(b *model.Business) bool { return b.Description != "" }},
+	{name: "open_hours", filled: func(b *model.Business) bool { return b.OpenHours != "" }},
+	{name: "thumbnail", filled: func(b *model.Business) bool { return b.Thumbnail != "" }},
+	{name: "city", filled: func(b *model.Business) bool { return b.City != "" }},
+	{name: "postal_code", filled: func(b *model.Business) bool { return b.PostalCode != "" }},
+	{name: "country_code", filled: func(b *model.Business) bool { return b.CountryCode != "" }},
+}
+
+// DriftAlert reports a field whose fill rate dropped abnormally during a scan.
+type DriftAlert struct {
+	Field    string
+	Rate     float64 // fill rate in the window that triggered the alert
+	Baseline float64 // rate over earlier windows and scans, or the field's floor
+	Samples  int     // businesses in the triggering window
+}
+
+func (a DriftAlert) String() string {
+	return fmt.Sprintf("%s %.0f%% (expected ~%.0f%%)", a.Field, 100*a.Rate, 100*a.Baseline)
+}
+
+// Coverage tracks per-field fill rates of the businesses parsed during a
+// scan and flags abnormal drops, which usually mean Google shifted the
+// response layout under the parser's hardcoded indices. The zero value is
+// ready to use and safe for concurrent use.
+type Coverage struct {
+	mu      sync.Mutex
+	total   int
+	filled  []int // per coverageFields entry, over closed windows
+	window  int
+	wFilled []int // per coverageFields entry, in the current window
+	pFilled []int // per coverageFields entry, in earlier scans
+	pTotal  []int
+	flagged []bool
+	alerts  []DriftAlert
+}
+
+func (c *Coverage) init() {
+	if c.filled == nil {
+		c.filled = make([]int, len(coverageFields))
+		c.wFilled = make([]int, len(coverageFields))
+		c.pFilled = make([]int, len(coverageFields))
+		c.pTotal = make([]int, len(coverageFields))
+		c.flagged = make([]bool, len(coverageFields))
+	}
+}
+
+// SetBaseline adds the fill counts of earlier scans to the baseline windows
+// are compared against, so a field that drops between scans is flagged
+// from the first window.
+func (c *Coverage) SetBaseline(report []storage.FieldCoverage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+
+	for _, r := range report {
+		for f, field := range coverageFields {
+			if field.name == r.Field {
+				c.pFilled[f] += r.Filled
+				c.pTotal[f] += r.Total
+			}
+		}
+	}
+}
+
+// Observe adds parsed businesses to the tally and returns any new alerts.
+func (c *Coverage) Observe(businesses []model.Business) []DriftAlert {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+
+	var alerts []DriftAlert
+	for i := range businesses {
+		for f, field := range coverageFields {
+			if field.filled(&businesses[i]) {
+				c.wFilled[f]++
+			}
+		}
+		c.window++
+		if c.window == coverageWindow {
+			alerts = append(alerts, c.closeWindow()...)
+		}
+	}
+	return alerts
+}
+
+// Flush checks the trailing partial window, so that scans smaller than one
+// window are still compared against the baseline. It returns any new alerts.
+func (c *Coverage) Flush() []DriftAlert {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+
+	if c.window < coverageMinWindow {
+		return nil
+	}
+	return c.closeWindow()
+}
+
+// closeWindow checks the full window against the baseline, then folds it in.
+func (c *Coverage) closeWindow() []DriftAlert {
+	var alerts []DriftAlert
+	for f, field := range coverageFields {
+		if c.flagged[f] {
+			continue
+		}
+		rate := float64(c.wFilled[f]) / float64(c.window)
+
+		baseline := field.floor
+		if filled, total := c.filled[f]+c.pFilled[f], c.total+c.pTotal[f]; total >= coverageWindow {
+			baseline = max(baseline, float64(filled)/float64(total))
+		}
+		if baseline > 0 && rate < baseline/2 && zScore(c.wFilled[f], c.window, baseline) <= -driftZ {
+			a := DriftAlert{Field: field.name, Rate: rate, Baseline: baseline, Samples: c.window}
+			c.flagged[f] = true
+			c.alerts = append(c.alerts, a)
+			alerts = append(alerts, a)
+		}
+	}
+
+	for f := range coverageFields {
+		c.filled[f] += c.wFilled[f]
+		c.wFilled[f] = 0
+	}
+	c.total += c.window
+	c.window = 0
+	return alerts
+}
+
+// zScore is the binomial z-score of k successes in n trials against rate p.
+func zScore(k, n int, p float64) float64 {
+	if p >= 1 {
+		p = 1 - 1e-9
+	}
+	sd := math.Sqrt(float64(n) * p * (1 - p))
+	return (float64(k) - float64(n)*p) / sd
+}
+
+// Alerts returns the drift alerts raised so far.
+func (c *Coverage) Alerts() []DriftAlert {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]DriftAlert(nil), c.alerts...)
+}
+
+// Report returns the fill counts of every tracked field, including the
+// businesses of a partial last window.
+func (c *Coverage) Report() []storage.FieldCoverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.init()
+
+	report := make([]storage.FieldCoverage, len(coverageFields))
+	for f, field := range coverageFields {
+		report[f] = storage.FieldCoverage{
+			Field:  field.name,
+			Filled: c.filled[f] + c.wFilled[f],
+			Total:  c.total + c.window,
+		}
+		if c.flagged[f] {
+			for _, a := range c.alerts {
+				if a.Field == field.name {
+					report[f].Alert = a.String()
+				}
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+)
+
+// parsed returns n businesses with every field the parser reads, except
+// phones past the first withPhone.
+func parsed(n, withPhone int) []model.Business {
+	bs := make([]model.Business, n)
+	for i := range bs {
+		bs[i] = model.Business{
+			CID: strconv.Itoa(i), PlaceID: "ChIJ" + strconv.Itoa(i), Lat: 40.4, Lng: -3.7,
+			Category: "Cafe", Address: "Calle Mayor 1", Rating: 4.2, ReviewCount: 10,
+		}
+		if i < withPhone {
+			bs[i].Phone = "+34 600 000 000"
+		}
+	}
+	return bs
+}
+
+func TestCoverageDrift(t *testing.T) {
+	tests := []struct {
+		name      string
+		baseline  []storage.FieldCoverage
+		batches   [][]model.Business
+		wantAlert bool
+	}{
+		{
+			name:    "no phones in a first scan",
+			batches: [][]model.Business{parsed(100, 0)},
+		},
+		{
+			name:      "phones gone since the last scan",
+			baseline:  []storage.FieldCoverage{{Field: "phone", Filled: 700, Total: 1000}},
+			batches:   [][]model.Business{parsed(100, 0)},
+			wantAlert: true,
+		},
+		{
+			name:     "phones as in the last scan",
+			baseline: []storage.FieldCoverage{{Field: "phone", Filled: 700, Total: 1000}},
+			batches:  [][]model.Business{parsed(100, 65)},
+		},
+		{
+			name:     "too little of the last scan to compare",
+			baseline: []storage.FieldCoverage{{Field: "phone", Filled: 70, Total: 100}},
+			batches:  [][]model.Business{parsed(100, 0)},
+		},
+		{
+			name:      "phones gone during the scan",
+			batches:   [][]model.Business{parsed(coverageWindow, coverageWindow*7/10), parsed(coverageWindow, 0)},
+			wantAlert: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Coverage
+			c.SetBaseline(tt.baseline)
+			for _, b := range tt.batches {
+				c.Observe(b)
+			}
+			c.Flush()
+
+			var phone *DriftAlert
+			for _, a := range c.Alerts() {
+				if a.Field == "phone" {
+					phone = &a
+				} else {
+					t.Errorf("unexpected alert %s", a)
+				}
+			}
+			if (phone != nil) != tt.wantAlert {
+				t.Fatalf("phone alert = %v, want %t", phone, tt.wantAlert)
+			}
+			if phone != nil && phone.Baseline < 0.69 {
+				t.Errorf("baseline = %.2f, want about 0.7", phone.Baseline)
+			}
+
+			// The baseline is not part of the run's report
+			for _, f := range c.Report() {
+				if want := len(tt.batches) * len(tt.batches[0]); f.Total != want {
+					t.Errorf("%s total = %d, want %d", f.Field, f.Total, want)
+				}
+			}
+		})
+	}
+}
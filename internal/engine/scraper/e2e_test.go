@@ -0,0 +1,368 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb"
+
+	"github.com/rendis/geotap/internal/engine/geo"
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/fakemaps"
+	"github.com/rendis/geotap/internal/model"
+)
+
+// e2eBound is the area the synthetic places are spread over and the grid
+// covers.
+var e2eBound = orb.Bound{Min: orb.Point{-3.73, 40.39}, Max: orb.Point{-3.67, 40.45}}
+
+// e2eQueries map the scanned queries to the category of the places they
+// should find.
+var e2eQueries = map[string]string{
+	"restaurants": "Restaurant",
+	"pharmacies":  "Pharmacy",
+}
+
+// e2eScan is a scan of the synthetic places through a fakemaps server.
+type e2eScan struct {
+	srv     *fakemaps.Server
+	places  []fakemaps.Place
+	sectors []model.Sector
+	params  model.SearchParams
+	store   *storage.Store
+	dbPath  string
+}
+
+func newE2EScan(t *testing.T, cfg fakemaps.Config) *e2eScan {
+	t.Helper()
+	places := fakemaps.RandomPlaces(7, 120, e2eBound, []string{"Restaurant", "Cafe", "Pharmacy"})
+	cfg.Places = places
+	srv := fakemaps.NewServer(cfg)
+	t.Cleanup(srv.Close)
+
+	dbPath := filepath.Join(t.TempDir(), "e2e.db")
+	store, err := storage.NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("opening store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	const zoom = 13
+	return &e2eScan{
+		srv:     srv,
+		places:  places,
+		sectors: geo.GenerateGrid(e2eBound.Min.Lat(), e2eBound.Min.Lon(), e2eBound.Max.Lat(), e2eBound.Max.Lon(), zoom),
+		params: model.SearchParams{
+			Queries:     []string{"restaurants", "pharmacies"},
+			Zoom:        zoom,
+			Concurrency: 4,
+			MaxPages:    5,
+			Lang:        "en",
+			Endpoint:    srv.URL(),
+		},
+		store:  store,
+		dbPath: dbPath,
+	}
+}
+
+func (s *e2eScan) run(ctx context.Context, opts RunOptions) (*Stats, error) {
+	opts.SuppressStderr = true
+	return Run(ctx, s.sectors, s.params, s.store, log.New(io.Discard, "", 0), &opts)
+}
+
+func (s *e2eScan) resume(ctx context.Context, opts RunOptions) (*Stats, error) {
+	opts.SuppressStderr = true
+	return Resume(ctx, s.params, s.store, log.New(io.Discard, "", 0), &opts)
+}
+
+// expected returns the places a complete scan should store per query: those
+// of the query's category inside some sector's viewport, passing keep.
+func (s *e2eScan) expected(t *testing.T, keep func(fakemaps.Place) bool) map[string]map[string]bool {
+	t.Helper()
+	want := make(map[string]map[string]bool)
+	for _, q := range s.params.Queries {
+		want[q] = make(map[string]bool)
+		for _, sec := range s.sectors {
+			vp, err := fakemaps.ParsePB(BuildPB(sec.Lat, sec.Lng, sec.Zoom, 0))
+			if err != nil {
+				t.Fatalf("parsing pb: %v", err)
+			}
+			n := 0
+			for _, p := range s.places {
+				if p.Categories[0] != e2eQueries[q] || !vp.Contains(p.Lat, p.Lng) {
+					continue
+				}
+				n++
+				if keep == nil || keep(p) {
+					want[q][p.CID] = true
+				}
+			}
+			if n >= pageSize*s.params.MaxPages {
+				t.Fatalf("sector %d,%d has %d %s, more than MaxPages fetch", sec.Row, sec.Col, n, q)
+			}
+		}
+	}
+	return want
+}
+
+// checkRows compares the places, query memberships and phones stored in
+// the project database with want, and the job ledger with the grid.
+func (s *e2eScan) checkRows(t *testing.T, want map[string]map[string]bool) {
+	t.Helper()
+	db, err := sql.Open("sqlite", s.dbPath)
+	if err != nil {
+		t.Fatalf("opening db: %v", err)
+	}
+	defer db.Close()
+
+	unique := make(map[string]bool)
+	memberships := 0
+	for q, cids := range want {
+		memberships += len(cids)
+		for cid := range cids {
+			unique[cid] = true
+		}
+		var n int
+		if err := db.QueryRow("SELECT COUNT(*) FROM place_queries WHERE query = ?", q).Scan(&n); err != nil {
+			t.Fatalf("counting place_queries: %v", err)
+		}
+		if n != len(cids) {
+			t.Errorf("place_queries for %q = %d, want %d", q, n, len(cids))
+		}
+	}
+
+	counts := map[string]int{
+		"SELECT COUNT(*) FROM places":                            len(unique),
+		"SELECT COUNT(*) FROM place_queries":                     memberships,
+		"SELECT COUNT(*) FROM phones":                            len(unique),
+		"SELECT COUNT(DISTINCT cid) FROM opening_hours":          len(unique),
+		"SELECT COUNT(*) FROM jobs WHERE status = 'done'":        len(s.sectors) * len(s.params.Queries),
+		"SELECT COUNT(*) FROM jobs WHERE status != 'done'":       0,
+		"SELECT COUNT(*) FROM places WHERE lat = 0 OR name = ''": 0,
+	}
+	for q, want := range counts {
+		var n int
+		if err := db.QueryRow(q).Scan(&n); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+		if n != want {
+			t.Errorf("%s = %d, want %d", q, n, want)
+		}
+	}
+
+	rows, err := db.Query("SELECT cid FROM places")
+	if err != nil {
+		t.Fatalf("querying places: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid string
+		rows.Scan(&cid)
+		if !unique[cid] {
+			t.Errorf("stored place %s was not expected", cid)
+		}
+	}
+}
+
+// shortBackoff makes rate-limit retries immediate for the test.
+func shortBackoff(t *testing.T) {
+	base, max := baseBackoff, maxBackoff
+	baseBackoff, maxBackoff = time.Millisecond, 5*time.Millisecond
+	t.Cleanup(func() { baseBackoff, maxBackoff = base, max })
+}
+
+func TestClientAgainstFakemaps(t *testing.T) {
+	places := []fakemaps.Place{
+		{Name: "Cafe Near", Categories: []string{"Cafe"}, CID: "11", PlaceID: "ChIJnear", Lat: 40.4200, Lng: -3.7000, Rating: 4.5, Reviews: 120, Phone: "+34 600 000 011"},
+		{Name: "Cafe Far", Categories: []string{"Cafe"}, CID: "12", Lat: 40.4250, Lng: -3.7050, Rating: 3.9, Reviews: 8},
+		{Name: "Bar Out", Categories: []string{"Bar"}, CID: "13", Lat: 40.4201, Lng: -3.7001, Rating: 4.0},
+	}
+	srv := fakemaps.NewServer(fakemaps.Config{Places: places})
+	defer srv.Close()
+
+	c := NewClient("en", "", 14)
+	c.SetBaseURL(srv.URL())
+	sector := model.Sector{Lat: 40.42, Lng: -3.70, Zoom: 14}
+
+	body, err := c.SearchMap(context.Background(), sector, "cafes", 0)
+	if err != nil {
+		t.Fatalf("SearchMap: %v", err)
+	}
+	got, hasMore := ParseMapResponse(body, "cafes")
+	if hasMore {
+		t.Error("hasMore = true for a 2-place page")
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d places, want the 2 cafes", len(got))
+	}
+	// Nearest first, with the fields the fake encodes
+	b := got[0]
+	if b.CID != "11" || b.Name != "Cafe Near" || b.Rating != 4.5 || b.ReviewCount != 120 ||
+		b.PlaceID != "ChIJnear" || b.Phone != "+34 600 000 011" || b.Lat != 40.42 || b.Query != "cafes" {
+		t.Errorf("first place = %+v, want Cafe Near", b)
+	}
+	if got[1].CID != "12" {
+		t.Errorf("second place = %s, want 12", got[1].CID)
+	}
+
+	body, err = c.SearchMap(context.Background(), sector, "cafes", 20)
+	if err != nil {
+		t.Fatalf("SearchMap page 2: %v", err)
+	}
+	if got, _ := ParseMapResponse(body, "cafes"); len(got) != 0 {
+		t.Errorf("page 2 has %d places, want none", len(got))
+	}
+}
+
+func TestScanEndToEnd(t *testing.T) {
+	s := newE2EScan(t, fakemaps.Config{})
+	stats, err := s.run(context.Background(), RunOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := s.expected(t, nil)
+	s.checkRows(t, want)
+	if stats.Errors.Load() != 0 || stats.RateLimits.Load() != 0 {
+		t.Errorf("errors = %d, rate limits = %d, want none", stats.Errors.Load(), stats.RateLimits.Load())
+	}
+	if done, total := stats.SectorsDone.Load(), int64(len(s.sectors)*len(s.params.Queries)); done != total {
+		t.Errorf("sectors done = %d, want %d", done, total)
+	}
+}
+
+func TestScanFilters(t *testing.T) {
+	s := newE2EScan(t, fakemaps.Config{})
+	s.params.MinRating = 2.5
+	s.params.MaxRating = 4.5
+
+	// Keep the western half of the area
+	mid := e2eBound.Center().Lon()
+	west := orb.MultiPolygon{{{
+		{e2eBound.Min.Lon() - 1, e2eBound.Min.Lat() - 1}, {mid, e2eBound.Min.Lat() - 1},
+		{mid, e2eBound.Max.Lat() + 1}, {e2eBound.Min.Lon() - 1, e2eBound.Max.Lat() + 1},
+		{e2eBound.Min.Lon() - 1, e2eBound.Min.Lat() - 1},
+	}}}
+
+	if _, err := s.run(context.Background(), RunOptions{GeoFilter: west}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := s.expected(t, func(p fakemaps.Place) bool {
+		return p.Rating >= 2.5 && p.Rating <= 4.5 && p.Lng < mid
+	})
+	all := s.expected(t, nil)
+	if len(want["restaurants"]) == 0 || len(want["restaurants"]) == len(all["restaurants"]) {
+		t.Fatalf("filters keep %d of %d restaurants, want some but not all", len(want["restaurants"]), len(all["restaurants"]))
+	}
+	s.checkRows(t, want)
+}
+
+func TestScanRateLimits(t *testing.T) {
+	shortBackoff(t)
+
+	// One worker, so the first job gets all three faults: a 403, a 302 to
+	// /sorry/ and a 429, exhausting its retries
+	s := newE2EScan(t, fakemaps.Config{
+		FailFirst:     maxRetries,
+		FaultStatuses: []int{http.StatusTooManyRequests, http.StatusForbidden, http.StatusFound},
+	})
+	s.params.Concurrency = 1
+
+	stats, err := s.run(context.Background(), RunOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if f := s.srv.Faults(); f != maxRetries {
+		t.Errorf("faults = %d, want %d", f, maxRetries)
+	}
+	if rl := stats.RateLimits.Load(); rl != 1 {
+		t.Errorf("rate-limited jobs = %d, want 1", rl)
+	}
+	counts, err := s.store.JobCounts()
+	if err != nil {
+		t.Fatalf("JobCounts: %v", err)
+	}
+	if counts[storage.JobFailed] != 1 {
+		t.Fatalf("failed jobs = %d, want 1", counts[storage.JobFailed])
+	}
+
+	// Resume retries the failed job and completes the scan
+	if _, err := s.resume(context.Background(), RunOptions{}); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	s.checkRows(t, s.expected(t, nil))
+}
+
+func TestScanBlocked(t *testing.T) {
+	shortBackoff(t)
+	s := newE2EScan(t, fakemaps.Config{
+		FaultRate:     1,
+		FaultStatuses: []int{http.StatusTooManyRequests, http.StatusForbidden, http.StatusFound},
+	})
+	// Few jobs, all in flight at once: each rate-limited job slows the
+	// workers down by half a second
+	s.sectors = s.sectors[:4]
+	s.params.Queries = s.params.Queries[:1]
+
+	stats, err := s.run(context.Background(), RunOptions{})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	total := len(s.sectors) * len(s.params.Queries)
+	if rl := stats.RateLimits.Load(); rl != int64(total) {
+		t.Errorf("rate-limited jobs = %d, want %d", rl, total)
+	}
+	if f := s.srv.Faults(); f != int64(total*maxRetries) {
+		t.Errorf("faults = %d, want %d (every attempt)", f, total*maxRetries)
+	}
+	counts, _ := s.store.JobCounts()
+	if counts[storage.JobFailed] != total {
+		t.Errorf("failed jobs = %d, want %d", counts[storage.JobFailed], total)
+	}
+	if n, _ := s.store.Count(); n != 0 {
+		t.Errorf("stored %d places, want none", n)
+	}
+}
+
+func TestScanLatencyInterruptAndResume(t *testing.T) {
+	// Every response takes longer than the run is given, so only aborting
+	// the in-flight requests returns in time
+	const latency = 5 * time.Second
+	s := newE2EScan(t, fakemaps.Config{Latency: latency})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := s.run(ctx, RunOptions{})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Run err = %v, want deadline exceeded", err)
+	}
+	if d := time.Since(start); d >= latency {
+		t.Errorf("cancelled run took %v, want in-flight requests aborted", d)
+	}
+	counts, _ := s.store.JobCounts()
+	if storage.Unfinished(counts) == 0 {
+		t.Fatal("no unfinished jobs after cancelling, want the rest left for resume")
+	}
+
+	// Resume against a server with the same places and a usable latency
+	srv := fakemaps.NewServer(fakemaps.Config{Places: s.places, Latency: 10 * time.Millisecond, Jitter: 20 * time.Millisecond})
+	t.Cleanup(srv.Close)
+	s.srv, s.params.Endpoint = srv, srv.URL()
+
+	stats, err := s.resume(context.Background(), RunOptions{})
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if int(stats.SectorsTotal.Load()) != storage.Unfinished(counts) {
+		t.Errorf("resumed %d jobs, want the %d unfinished", stats.SectorsTotal.Load(), storage.Unfinished(counts))
+	}
+	s.checkRows(t, s.expected(t, nil))
+}
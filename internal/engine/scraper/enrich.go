@@ -0,0 +1,232 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+)
+
+// EnrichStats holds the counters of a details enrichment run.
+type EnrichStats struct {
+	PlacesTotal atomic.Int64
+	PlacesDone  atomic.Int64
+	Enriched    atomic.Int64 // places whose details were stored
+	Reviews     atomic.Int64 // new reviews stored from detail pages
+	Errors      atomic.Int64
+	RateLimits  atomic.Int64
+
+	proxies atomic.Pointer[[]*ProxyStats]
+}
+
+// Proxies returns the per-proxy counters of a run using a proxy pool, or nil.
+func (s *EnrichStats) Proxies() []*ProxyStats {
+	if p := s.proxies.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// EnrichOptions provides optional settings for Enrich.
+type EnrichOptions struct {
+	// SuppressStderr disables the built-in stderr progress reporter.
+	SuppressStderr bool
+	// Stats allows passing an external EnrichStats for live progress tracking.
+	Stats *EnrichStats
+}
+
+// Enrich fetches and stores the place details of every business in the
+// store's enrichment ledger that is not done yet. Call
+// Store.EnqueueEnrichJobs first to queue the scanned places; an interrupted
+// run picks up where it stopped when called again.
+func Enrich(ctx context.Context, params model.SearchParams, store *storage.Store, logger *log.Logger, opts *EnrichOptions) (*EnrichStats, error) {
+	if opts == nil {
+		opts = &EnrichOptions{}
+	}
+	stats := opts.Stats
+	if stats == nil {
+		stats = &EnrichStats{}
+	}
+
+	jobs, err := store.UnfinishedEnrichJobs()
+	if err != nil {
+		return stats, err
+	}
+	stats.PlacesTotal.Store(int64(len(jobs)))
+	logger.Printf("ENRICH %d places", len(jobs))
+
+	r := newRunner(params, store, logger)
+	if r.pool != nil {
+		ps := r.pool.stats()
+		stats.proxies.Store(&ps)
+	}
+
+	stop := r.startProgress(opts.SuppressStderr,
+		func() string {
+			return fmt.Sprintf("[%d/%d places] %d enriched | %d reviews | %d errors",
+				stats.PlacesDone.Load(), stats.PlacesTotal.Load(), stats.Enriched.Load(),
+				stats.Reviews.Load(), stats.Errors.Load())
+		},
+		func() string {
+			return fmt.Sprintf("places=%d/%d enriched=%d reviews=%d errors=%d rate_limits=%d",
+				stats.PlacesDone.Load(), stats.PlacesTotal.Load(), stats.Enriched.Load(),
+				stats.Reviews.Load(), stats.Errors.Load(), stats.RateLimits.Load())
+		})
+	r.forEachPlace(ctx, len(jobs), opts.SuppressStderr, func(i int) {
+		r.enrichPlace(ctx, jobs[i], stats)
+	})
+	stop()
+
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// enrichPlace fetches, parses and stores the details of one place.
+func (r *runner) enrichPlace(ctx context.Context, job storage.EnrichJob, stats *EnrichStats) {
+	defer stats.PlacesDone.Add(1)
+
+	body, err := r.fetch(ctx, func(c *Client) ([]byte, error) {
+		return c.PlaceDetails(ctx, job.CID, job.PlaceID)
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			r.updateEnrichJob(job, storage.JobInterrupted, nil)
+			return
+		}
+		if rl, ok := err.(*RateLimitError); ok {
+			stats.RateLimits.Add(1)
+			r.adjustDelay(true)
+			r.logger.Printf("RATE_LIMIT cid=%s status=%d", job.CID, rl.StatusCode)
+		} else {
+			r.logger.Printf("ERROR cid=%s err=%v", job.CID, err)
+		}
+		stats.Errors.Add(1)
+		r.updateEnrichJob(job, storage.JobFailed, err)
+		return
+	}
+	r.adjustDelay(false)
+
+	details, err := ParsePlaceDetails(body)
+	if err != nil {
+		r.logger.Printf("PARSE cid=%s err=%v", job.CID, err)
+		stats.Errors.Add(1)
+		r.updateEnrichJob(job, storage.JobFailed, err)
+		return
+	}
+	// Key the rows by the ledger's CID even if the page reports another form
+	details.CID = job.CID
+	for i := range details.Reviews {
+		details.Reviews[i].CID = job.CID
+	}
+
+	newReviews, err := r.store.SaveDetails(details)
+	if err != nil {
+		stats.Errors.Add(1)
+		r.updateEnrichJob(job, storage.JobFailed, err)
+		return
+	}
+	stats.Enriched.Add(1)
+	stats.Reviews.Add(int64(newReviews))
+	r.updateEnrichJob(job, storage.JobDone, nil)
+}
+
+// forEachPlace calls do for every index below n on up to
+// params.Concurrency workers, applying the adaptive delay between requests.
+// It stops starting new calls when ctx is cancelled or after persistent rate
+// limiting; the places not processed stay unfinished in their ledger.
+func (r *runner) forEachPlace(ctx context.Context, n int, suppressStderr bool, do func(i int)) {
+	concurrency := r.params.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+loop:
+	for i := range n {
+		// Same early abort as a scan
+		if r.consecutiveRL.Load() > 50 {
+			r.logger.Printf("ABORT: persistent rate limiting (50+ consecutive), stopping")
+			if !suppressStderr {
+				fmt.Fprintf(os.Stderr, "\n[!] Persistent rate limiting detected — aborting. Try again later or reduce concurrency.\n")
+			}
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			break loop
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			r.delayMu.RLock()
+			d := r.delay
+			r.delayMu.RUnlock()
+			if d > 0 && sleepCtx(ctx, d) != nil {
+				return
+			}
+			do(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// startProgress reports line on stderr every 2s (unless suppressed) and
+// logLine in the log every 10s, until the returned stop is called, which
+// also prints the final stderr line.
+func (r *runner) startProgress(suppressStderr bool, line, logLine func() string) (stop func()) {
+	startTime := time.Now()
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(2 * time.Second)
+		logTicker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+		defer logTicker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if !suppressStderr {
+					fmt.Fprintf(os.Stderr, "\r%s | %s", line(), time.Since(startTime).Truncate(time.Second))
+				}
+			case <-logTicker.C:
+				r.logger.Printf("PROGRESS %s elapsed=%s", logLine(), time.Since(startTime).Truncate(time.Second))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+		if !suppressStderr {
+			fmt.Fprintf(os.Stderr, "\r%s | %s\n", line(), time.Since(startTime).Truncate(time.Second))
+		}
+	}
+}
+
+// updateEnrichJob updates the enrichment ledger, logging (but not failing on)
+// storage errors.
+func (r *runner) updateEnrichJob(job storage.EnrichJob, status string, jobErr error) {
+	var msg string
+	if jobErr != nil {
+		msg = jobErr.Error()
+	}
+	if err := r.store.UpdateEnrichJob(job.CID, status, msg); err != nil {
+		r.logger.Printf("LEDGER cid=%s err=%v", job.CID, err)
+	}
+}
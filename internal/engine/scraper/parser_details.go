@@ -0,0 +1,240 @@
+package scraper
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// ErrNoPlaceData is returned when a detail page carries no place payload,
+// e.g. a consent or captcha page served with status 200.
+var ErrNoPlaceData = errors.New("no place data in response")
+
+// appStateMarker precedes the JSON state embedded in a Maps page.
+const appStateMarker = "window.APP_INITIALIZATION_STATE="
+
+// ParsePlaceDetails parses a place detail page into PlaceDetails. It accepts
+// both the HTML page (place data embedded in APP_INITIALIZATION_STATE) and a
+// bare )]}'-prefixed preview response. The place array has the same layout
+// as a tbm=map result item, with more fields filled in.
+func ParsePlaceDetails(body []byte) (*model.PlaceDetails, error) {
+	payload, err := placePayload(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []any
+	if err := json.Unmarshal(payload, &raw); err != nil {
+		return nil, fmt.Errorf("decoding place payload: %w", err)
+	}
+	biz := safeSlice(safeGet(raw, 6))
+	if len(biz) == 0 || safeString(safeGet(biz, 11)) == "" {
+		return nil, ErrNoPlaceData
+	}
+
+	d := &model.PlaceDetails{
+		CID:        safeString(safeGet(biz, 10)),
+		PlaceID:    safeString(safeGet(biz, 78)),
+		StatusText: safeString(safeGet(biz, 34, 4, 4)),
+		Timezone:   safeString(safeGet(biz, 30)),
+		PlusCode:   safeString(safeGet(biz, 183, 2, 2, 0)),
+	}
+	d.Hours = parseDayHours(biz)
+	d.Schedule = parseSchedule(biz)
+	d.PopularTimes = parsePopularTimes(biz)
+	d.Attributes = parseAttributes(biz)
+	d.Reviews = parseDetailReviews(biz, d.CID)
+	d.Status = placeStatus(d.StatusText, len(d.Hours) > 0)
+	return d, nil
+}
+
+// placePayload extracts the )]}'-prefixed place JSON from a detail response.
+func placePayload(body []byte) ([]byte, error) {
+	body = bytes.TrimSpace(body)
+	if bytes.HasPrefix(body, []byte(")]}'")) {
+		return stripXSSI(body), nil
+	}
+
+	idx := bytes.Index(body, []byte(appStateMarker))
+	if idx < 0 {
+		return nil, ErrNoPlaceData
+	}
+	// The state is a JSON array followed by ";"; the decoder stops at its end.
+	var state []any
+	dec := json.NewDecoder(bytes.NewReader(body[idx+len(appStateMarker):]))
+	if err := dec.Decode(&state); err != nil {
+		return nil, fmt.Errorf("decoding app state: %w", err)
+	}
+
+	// The place payload is normally state[3][6]; look through state[3] in
+	// case it moved.
+	candidates := safeSlice(safeGet(state, 3))
+	if s := safeString(safeGet(candidates, 6)); strings.HasPrefix(s, ")]}'") {
+		return stripXSSI([]byte(s)), nil
+	}
+	for _, c := range candidates {
+		if s := safeString(c); strings.HasPrefix(s, ")]}'") {
+			return stripXSSI([]byte(s)), nil
+		}
+	}
+	return nil, ErrNoPlaceData
+}
+
+// stripXSSI removes the )]}' anti-XSS prefix line.
+func stripXSSI(body []byte) []byte {
+	if idx := bytes.IndexByte(body, '\n'); idx >= 0 && idx < 10 {
+		return body[idx+1:]
+	}
+	return bytes.TrimPrefix(body, []byte(")]}'"))
+}
+
+// parseDayHours reads the weekly schedule: biz[34][1] holds
+// [day, [range, ...]] pairs; newer pages use biz[203][0] with
+// [day, dayIndex, date, [[range, ...], ...]].
+func parseDayHours(biz []any) []model.DayHours {
+	var hours []model.DayHours
+	for _, d := range safeSlice(safeGet(biz, 34, 1)) {
+		day := safeString(safeGet(d, 0))
+		if day == "" {
+			continue
+		}
+		dh := model.DayHours{Day: day}
+		for _, r := range safeSlice(safeGet(d, 1)) {
+			if s := safeString(r); s != "" {
+				dh.Hours = append(dh.Hours, s)
+			}
+		}
+		hours = append(hours, dh)
+	}
+	if len(hours) > 0 {
+		return hours
+	}
+
+	for _, d := range safeSlice(safeGet(biz, 203, 0)) {
+		day := safeString(safeGet(d, 0))
+		if day == "" {
+			continue
+		}
+		dh := model.DayHours{Day: day}
+		for _, r := range safeSlice(safeGet(d, 3)) {
+			if s := safeString(safeGet(r, 0)); s != "" {
+				dh.Hours = append(dh.Hours, s)
+			}
+		}
+		hours = append(hours, dh)
+	}
+	return hours
+}
+
+// parsePopularTimes reads biz[84][0]: [day (1-7), [[hour, busyness, ...], ...]].
+func parsePopularTimes(biz []any) []model.PopularHour {
+	var out []model.PopularHour
+	for _, d := range safeSlice(safeGet(biz, 84, 0)) {
+		day := int(safeFloat(safeGet(d, 0)))
+		if day < 1 || day > 7 {
+			continue
+		}
+		for _, h := range safeSlice(safeGet(d, 1)) {
+			out = append(out, model.PopularHour{
+				Day:      day,
+				Hour:     int(safeFloat(safeGet(h, 0))),
+				Busyness: int(safeFloat(safeGet(h, 1))),
+			})
+		}
+	}
+	return out
+}
+
+// parseAttributes reads biz[100][1]: [id, group, [[id, name, [_, [[flag]]]], ...]].
+func parseAttributes(biz []any) []model.Attribute {
+	var out []model.Attribute
+	for _, g := range safeSlice(safeGet(biz, 100, 1)) {
+		group := safeString(safeGet(g, 1))
+		for _, o := range safeSlice(safeGet(g, 2)) {
+			name := safeString(safeGet(o, 1))
+			if name == "" {
+				continue
+			}
+			out = append(out, model.Attribute{
+				Group:     group,
+				Name:      name,
+				Available: safeFloat(safeGet(o, 2, 1, 0, 0)) == 1,
+			})
+		}
+	}
+	return out
+}
+
+// parseDetailReviews reads the handful of most relevant reviews embedded in
+// the detail page, from biz[175][9][0][0] or, on older pages, biz[52][0].
+func parseDetailReviews(biz []any, cid string) []model.Review {
+	var out []model.Review
+	for _, item := range safeSlice(safeGet(biz, 175, 9, 0, 0)) {
+		if r, ok := parseReviewEl(safeGet(item, 0), cid); ok {
+			out = append(out, r)
+		}
+	}
+	if len(out) > 0 {
+		return out
+	}
+
+	for _, el := range safeSlice(safeGet(biz, 52, 0)) {
+		r := model.Review{
+			CID:          cid,
+			ID:           safeString(safeGet(el, 10)),
+			Author:       safeString(safeGet(el, 0, 1)),
+			RelativeDate: safeString(safeGet(el, 1)),
+			Text:         safeString(safeGet(el, 3)),
+			Rating:       int(safeFloat(safeGet(el, 4))),
+		}
+		if r.Author == "" && r.Text == "" {
+			continue
+		}
+		out = append(out, withReviewID(r))
+	}
+	return out
+}
+
+// reviewDate formats a [year, month, day, ...] array as YYYY-MM-DD.
+func reviewDate(parts []any) string {
+	if len(parts) < 3 {
+		return ""
+	}
+	y, m, d := int(safeFloat(parts[0])), int(safeFloat(parts[1])), int(safeFloat(parts[2]))
+	if y == 0 || m == 0 || d == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", y, m, d)
+}
+
+// withReviewID fills in a stable ID for reviews whose payload had none, so
+// re-fetching the same review does not store it twice.
+func withReviewID(r model.Review) model.Review {
+	if r.ID == "" {
+		sum := sha1.Sum([]byte(r.Author + "\x00" + r.Text))
+		r.ID = "h:" + hex.EncodeToString(sum[:8])
+	}
+	return r
+}
+
+// placeStatus derives the business status from Google's status line. Only
+// English and Spanish status texts are recognized; a place with opening
+// hours and no closure notice is taken as operational.
+func placeStatus(text string, hasHours bool) string {
+	t := strings.ToLower(text)
+	switch {
+	case strings.Contains(t, "permanently closed"), strings.Contains(t, "cerrado permanentemente"):
+		return model.StatusPermanentlyClosed
+	case strings.Contains(t, "temporarily closed"), strings.Contains(t, "cerrado temporalmente"):
+		return model.StatusTemporarilyClosed
+	case text != "" || hasHours:
+		return model.StatusOperational
+	}
+	return ""
+}
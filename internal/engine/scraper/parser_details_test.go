@@ -0,0 +1,141 @@
+package scraper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+func readDetails(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", "details", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+// reviewFields is the part of a review the detail page fills in.
+type reviewFields struct {
+	ID, Author, RelativeDate, Text, Published string
+	Rating                                    int
+}
+
+func detailReviews(reviews []model.Review) []reviewFields {
+	var out []reviewFields
+	for _, r := range reviews {
+		out = append(out, reviewFields{r.ID, r.Author, r.RelativeDate, r.Text, r.Published, r.Rating})
+	}
+	return out
+}
+
+func TestParsePlaceDetails(t *testing.T) {
+	// A detail page trimmed to the fields geotap reads
+	d, err := ParsePlaceDetails(readDetails(t, "place.html"))
+	if err != nil {
+		t.Fatalf("ParsePlaceDetails: %v", err)
+	}
+
+	if d.CID != "1711231875390658011" || d.PlaceID != "ChIJ2fW0o-PQwRcR2_W0o-PQwRc" {
+		t.Errorf("ids = %q, %q", d.CID, d.PlaceID)
+	}
+	if d.Status != model.StatusTemporarilyClosed || d.StatusText != "Temporarily closed" {
+		t.Errorf("status = %q (%q), want temporarily closed", d.Status, d.StatusText)
+	}
+	if d.Timezone != "Europe/Madrid" || d.PlusCode != "8CGRC78W+5V" {
+		t.Errorf("timezone, plus code = %q, %q", d.Timezone, d.PlusCode)
+	}
+
+	wantHours := []model.DayHours{
+		{Day: "Monday", Hours: []string{"8 AM–8 PM"}},
+		{Day: "Tuesday", Hours: []string{"8 AM–8 PM"}},
+		{Day: "Wednesday", Hours: []string{"8 AM–8 PM"}},
+		{Day: "Thursday", Hours: []string{"8 AM–8 PM"}},
+		{Day: "Friday", Hours: []string{"8 AM–2:30 PM", "5 PM–1 AM"}},
+		{Day: "Saturday", Hours: []string{"9 AM–1 AM"}},
+		{Day: "Sunday", Hours: []string{"Closed"}},
+	}
+	if !reflect.DeepEqual(d.Hours, wantHours) {
+		t.Errorf("hours = %v, want %v", d.Hours, wantHours)
+	}
+
+	// Day 0 is not a weekday and is dropped
+	var wantPopular []model.PopularHour
+	for _, day := range []int{1, 2, 5} {
+		wantPopular = append(wantPopular,
+			model.PopularHour{Day: day, Hour: 8, Busyness: 0},
+			model.PopularHour{Day: day, Hour: 9, Busyness: 15},
+			model.PopularHour{Day: day, Hour: 10, Busyness: 40})
+	}
+	if !reflect.DeepEqual(d.PopularTimes, wantPopular) {
+		t.Errorf("popular times = %v, want %v", d.PopularTimes, wantPopular)
+	}
+
+	wantAttrs := []model.Attribute{
+		{Group: "Accessibility", Name: "Wheelchair accessible entrance", Available: true},
+		{Group: "Accessibility", Name: "Wheelchair accessible restroom", Available: false},
+		{Group: "Service options", Name: "Takeout", Available: true},
+		{Group: "Service options", Name: "Delivery", Available: false},
+	}
+	if !reflect.DeepEqual(d.Attributes, wantAttrs) {
+		t.Errorf("attributes = %v, want %v", d.Attributes, wantAttrs)
+	}
+
+	// The review with neither author nor text is skipped
+	wantReviews := []reviewFields{
+		{"ChZDSUhNMG9nS0VJQ0FnSUR4czRmOFNBEAE", "Lucía Fernández", "2 weeks ago",
+			"Best café con leche in the area & friendly staff.", "2026-09-29", 5},
+		{"ChdDSUhNMG9nS0VJQ0FnSUN4NmZqRGpRRRAB", "Marco Rossi", "a month ago",
+			"Buen café, pero <muy> lento a la hora punta.", "2026-09-12", 3},
+	}
+	if got := detailReviews(d.Reviews); !reflect.DeepEqual(got, wantReviews) {
+		t.Errorf("reviews = %+v, want %+v", got, wantReviews)
+	}
+	for _, r := range d.Reviews {
+		if r.CID != d.CID {
+			t.Errorf("review %s CID = %q, want the place's", r.ID, r.CID)
+		}
+	}
+}
+
+func TestParsePlaceDetailsPreview(t *testing.T) {
+	// A bare preview response in the older layout: hours at [203], reviews
+	// at [52] and no status line
+	d, err := ParsePlaceDetails(readDetails(t, "preview.json"))
+	if err != nil {
+		t.Fatalf("ParsePlaceDetails: %v", err)
+	}
+	if d.CID != "10914520958447009385" || d.Status != model.StatusOperational || d.StatusText != "" {
+		t.Errorf("CID, status = %q, %q (%q), want operational from the hours", d.CID, d.Status, d.StatusText)
+	}
+	wantHours := []model.DayHours{
+		{Day: "lunes", Hours: []string{"9:00–21:00"}},
+		{Day: "domingo", Hours: []string{"Cerrado"}},
+	}
+	if !reflect.DeepEqual(d.Hours, wantHours) {
+		t.Errorf("hours = %v, want %v", d.Hours, wantHours)
+	}
+	if len(d.PopularTimes) != 0 || len(d.Attributes) != 0 {
+		t.Errorf("popular times %v, attributes %v, want none", d.PopularTimes, d.Attributes)
+	}
+	wantReviews := []reviewFields{{"rev-legacy-1", "Pedro Gil", "hace una semana", "Muy atentos.", "", 5}}
+	if got := detailReviews(d.Reviews); !reflect.DeepEqual(got, wantReviews) {
+		t.Errorf("reviews = %+v, want %+v", got, wantReviews)
+	}
+}
+
+func TestParsePlaceDetailsNoPlace(t *testing.T) {
+	for _, body := range [][]byte{
+		readDetails(t, "consent.html"),
+		[]byte(")]}'\n[null,null,null,null,null,null,[]]"),
+		[]byte(`<script>window.APP_INITIALIZATION_STATE=[null,null,null,[]];</script>`),
+	} {
+		if _, err := ParsePlaceDetails(body); !errors.Is(err, ErrNoPlaceData) {
+			t.Errorf("ParsePlaceDetails(%.40q) = %v, want ErrNoPlaceData", body, err)
+		}
+	}
+}
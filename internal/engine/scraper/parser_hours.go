@@ -0,0 +1,190 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// dayNames maps English and Spanish weekday names, lowercased and without
+// accents, to 1 = Monday … 7 = Sunday.
+var dayNames = map[string]int{
+	"monday": 1, "tuesday": 2, "wednesday": 3, "thursday": 4, "friday": 5, "saturday": 6, "sunday": 7,
+	"lunes": 1, "martes": 2, "miercoles": 3, "jueves": 4, "viernes": 5, "sabado": 6, "domingo": 7,
+}
+
+var clockRe = regexp.MustCompile(`^(\d{1,2})(?:[:.](\d{2}))?\s*(am|pm)?$`)
+
+// parseSchedule reads a place's weekly hours: biz[203][0] on newer payloads,
+// biz[34][1] on older ones.
+func parseSchedule(biz []any) model.Schedule {
+	if s := scheduleFromArray(safeSlice(safeGet(biz, 203, 0))); len(s) > 0 {
+		return s
+	}
+	return scheduleFromArray(safeSlice(safeGet(biz, 34, 1)))
+}
+
+// ParseSchedule normalizes the raw hours a Business carries in OpenHours
+// (the JSON of biz[203][0] or biz[34][1]) into a weekly schedule. It
+// returns nil when the hours are missing or unrecognized.
+func ParseSchedule(openHours string) model.Schedule {
+	if openHours == "" {
+		return nil
+	}
+	var days []any
+	if err := json.Unmarshal([]byte(openHours), &days); err != nil {
+		return nil
+	}
+	return scheduleFromArray(days)
+}
+
+// scheduleFromArray reads either day layout: [day, [range, ...]] or
+// [day, dayIndex, date, [[range, [[openH, openM], [closeH, closeM]]], ...]].
+// Ranges are taken from the numeric times when present and parsed from the
+// displayed text otherwise. Days are identified by name (English or
+// Spanish), falling back to the day index.
+func scheduleFromArray(days []any) model.Schedule {
+	var out model.Schedule
+	for _, d := range days {
+		day := dayNames[normalizeDay(safeString(safeGet(d, 0)))]
+		if day == 0 {
+			if i := int(safeFloat(safeGet(d, 1))); i >= 1 && i <= 7 {
+				day = i
+			}
+		}
+		if day == 0 {
+			continue
+		}
+
+		ranges := safeSlice(safeGet(d, 3))
+		if ranges == nil {
+			ranges = safeSlice(safeGet(d, 1))
+		}
+		for _, r := range ranges {
+			var sp model.OpeningSpan
+			var ok bool
+			if open := safeSlice(safeGet(r, 1, 0)); open != nil {
+				sp, ok = numericSpan(open, safeSlice(safeGet(r, 1, 1)))
+			} else if text := safeString(safeGet(r, 0)); text != "" {
+				sp, ok = parseSpan(text)
+			} else {
+				sp, ok = parseSpan(safeString(r))
+			}
+			if !ok {
+				continue
+			}
+			sp.Day = day
+			out = append(out, sp)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Day != out[j].Day {
+			return out[i].Day < out[j].Day
+		}
+		return out[i].Open < out[j].Open
+	})
+	return out
+}
+
+// numericSpan builds a span from [hour, minute] pairs; a missing minute is 0.
+func numericSpan(open, close []any) (model.OpeningSpan, bool) {
+	if close == nil {
+		return model.OpeningSpan{}, false
+	}
+	o := int(safeFloat(safeGet(open, 0)))*60 + int(safeFloat(safeGet(open, 1)))
+	c := int(safeFloat(safeGet(close, 0)))*60 + int(safeFloat(safeGet(close, 1)))
+	return makeSpan(o, c), true
+}
+
+// parseSpan parses a displayed range such as "9 AM–5:30 PM", "11–2 PM",
+// "09:00–17:00", "Closed" or "Open 24 hours" (English or Spanish).
+func parseSpan(text string) (model.OpeningSpan, bool) {
+	t := strings.ToLower(strings.TrimSpace(text))
+	t = strings.NewReplacer("\u202f", " ", "\u00a0", " ", "a. m.", "am", "p. m.", "pm", "a.m.", "am", "p.m.", "pm").Replace(t)
+
+	switch {
+	case t == "":
+		return model.OpeningSpan{}, false
+	case strings.Contains(t, "closed"), strings.Contains(t, "cerrado"):
+		return model.OpeningSpan{Closed: true}, true
+	case strings.Contains(t, "24 hours"), strings.Contains(t, "24 horas"):
+		return model.OpeningSpan{AllDay: true}, true
+	}
+
+	var parts []string
+	for _, sep := range []string{"–", "—", " to ", "-"} {
+		if p := strings.SplitN(t, sep, 2); len(p) == 2 {
+			parts = p
+			break
+		}
+	}
+	if parts == nil {
+		return model.OpeningSpan{}, false
+	}
+
+	oh, om, omer, ok1 := parseClock(parts[0])
+	ch, cm, cmer, ok2 := parseClock(parts[1])
+	if !ok1 || !ok2 {
+		return model.OpeningSpan{}, false
+	}
+	close := to24(ch, cm, cmer)
+	if omer == "" && cmer != "" {
+		// "11–2 PM" is 11 AM to 2 PM, "9–1 AM" is 9 PM to 1 AM: take the
+		// closing meridiem unless that would put the opening after the close.
+		omer = cmer
+		if to24(oh, om, omer) >= close {
+			omer = map[string]string{"am": "pm", "pm": "am"}[cmer]
+		}
+	}
+	return makeSpan(to24(oh, om, omer), close), true
+}
+
+// parseClock parses "9", "9:30", "9 am" or "21:00".
+func parseClock(s string) (hour, minute int, meridiem string, ok bool) {
+	m := clockRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, 0, "", false
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	if hour > 24 || minute > 59 {
+		return 0, 0, "", false
+	}
+	return hour, minute, m[3], true
+}
+
+// to24 converts a 12-hour clock reading to minutes after midnight.
+func to24(hour, minute int, meridiem string) int {
+	switch {
+	case meridiem == "pm" && hour < 12:
+		hour += 12
+	case meridiem == "am" && hour == 12:
+		hour = 0
+	}
+	return hour*60 + minute
+}
+
+// makeSpan builds a span from minutes after midnight; a close before the
+// open runs past midnight.
+func makeSpan(open, close int) model.OpeningSpan {
+	if (open == 0 && close >= 24*60) || open == close {
+		return model.OpeningSpan{AllDay: true}
+	}
+	return model.OpeningSpan{
+		Open:      fmt.Sprintf("%02d:%02d", open/60, open%60),
+		Close:     fmt.Sprintf("%02d:%02d", close/60, close%60),
+		Overnight: close < open,
+	}
+}
+
+// normalizeDay lowercases a weekday name and strips Spanish accents.
+func normalizeDay(s string) string {
+	return strings.NewReplacer("é", "e", "á", "a").Replace(strings.ToLower(strings.TrimSpace(s)))
+}
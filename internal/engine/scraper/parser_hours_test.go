@@ -0,0 +1,95 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+func TestParseSpan(t *testing.T) {
+	tests := []struct {
+		text string
+		want model.OpeningSpan
+		ok   bool
+	}{
+		// 12-hour text, as Google spaces it, with the opening meridiem
+		// inferred from the close
+		{"9 AM–5:30 PM", model.OpeningSpan{Open: "09:00", Close: "17:30"}, true},
+		{"11–2 PM", model.OpeningSpan{Open: "11:00", Close: "14:00"}, true},
+		{"9–1 AM", model.OpeningSpan{Open: "21:00", Close: "01:00", Overnight: true}, true},
+		{"12 PM–12 AM", model.OpeningSpan{Open: "12:00", Close: "00:00", Overnight: true}, true},
+		{"9\u202fAM\u2009–\u20095\u202fPM", model.OpeningSpan{Open: "09:00", Close: "17:00"}, true},
+		{"9 a. m.–1 p. m.", model.OpeningSpan{Open: "09:00", Close: "13:00"}, true},
+		{"7:30 am to 3 pm", model.OpeningSpan{Open: "07:30", Close: "15:00"}, true},
+
+		// 24-hour text
+		{"09:00–17:00", model.OpeningSpan{Open: "09:00", Close: "17:00"}, true},
+		{"9.30-14.00", model.OpeningSpan{Open: "09:30", Close: "14:00"}, true},
+		{"22:00–02:00", model.OpeningSpan{Open: "22:00", Close: "02:00", Overnight: true}, true},
+		{"00:00–24:00", model.OpeningSpan{AllDay: true}, true},
+
+		// Whole days
+		{"Open 24 hours", model.OpeningSpan{AllDay: true}, true},
+		{"Abierto 24 horas", model.OpeningSpan{AllDay: true}, true},
+		{"Closed", model.OpeningSpan{Closed: true}, true},
+		{"Cerrado", model.OpeningSpan{Closed: true}, true},
+
+		// Badly formed
+		{"", model.OpeningSpan{}, false},
+		{"   ", model.OpeningSpan{}, false},
+		{"Hours might differ", model.OpeningSpan{}, false},
+		{"9 AM", model.OpeningSpan{}, false},
+		{"9–", model.OpeningSpan{}, false},
+		{"25:00–26:00", model.OpeningSpan{}, false},
+		{"9:75–10:00", model.OpeningSpan{}, false},
+		{"noon–midnight", model.OpeningSpan{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSpan(tt.text)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseSpan(%q) = %+v, %v, want %+v, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name  string
+		hours string
+		want  model.Schedule
+	}{
+		{
+			"text ranges by day name",
+			`[["Saturday",["10 AM–2 PM","5–9 PM"]],["Friday",["10 PM–2 AM"]],["Sunday",["Closed"]],["Monday",["Open 24 hours"]]]`,
+			model.Schedule{
+				{Day: 1, AllDay: true},
+				{Day: 5, Open: "22:00", Close: "02:00", Overnight: true},
+				{Day: 6, Open: "10:00", Close: "14:00"},
+				{Day: 6, Open: "17:00", Close: "21:00"},
+				{Day: 7, Closed: true},
+			},
+		},
+		{
+			"numeric times by day index",
+			`[["viernes",5,[2026,1,16],[["22:00–2:00",[[22],[2]]]]],["martes",2,[2026,1,13],[["9:00–14:30",[[9],[14,30]]]]]]`,
+			model.Schedule{
+				{Day: 2, Open: "09:00", Close: "14:30"},
+				{Day: 5, Open: "22:00", Close: "02:00", Overnight: true},
+			},
+		},
+		{
+			"unknown day names fall back to the index",
+			`[["Freitag",5,[2026,1,16],[["9–17",null]]]]`,
+			model.Schedule{{Day: 5, Open: "09:00", Close: "17:00"}},
+		},
+		{"unrecognized ranges are skipped", `[["Monday",["by appointment"]],["Someday",["9 AM–5 PM"]]]`, nil},
+		{"not JSON", `Mon 9-5`, nil},
+		{"empty", ``, nil},
+	}
+	for _, tt := range tests {
+		if got := ParseSchedule(tt.hours); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParseSchedule = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/rendis/geotap/internal/engine/phone"
 	"github.com/rendis/geotap/internal/model"
 )
 
@@ -82,12 +83,15 @@ func ParseMapResponse(body []byte, query string) ([]model.Business, bool) {
 			Description: safeString(safeGet(biz, 32, 1, 1)),
 			PlaceID:     safeString(safeGet(biz, 78)),
 			OpenHours:   openHours,
+			Hours:       parseSchedule(biz),
 			Thumbnail:   safeString(safeGet(biz, 157)),
 			Categories:  categories,
 			City:        safeString(safeGet(biz, 183, 1, 3)),
 			PostalCode:  safeString(safeGet(biz, 183, 1, 4)),
 			CountryCode: safeString(safeGet(biz, 183, 1, 6)),
 		}
+		b.PhoneNumber = phone.Parse(b.Phone, b.CountryCode)
+		b.Rank = len(businesses) + 1
 
 		businesses = append(businesses, b)
 	}
@@ -0,0 +1,90 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// ParseReviewsPage parses one listugcposts response into the reviews of the
+// page and the continuation token of the next one ("" on the last page). The
+// payload is [_, nextToken, [[review], ...]], each review laid out like the
+// ones embedded in a detail page.
+func ParseReviewsPage(body []byte, cid string) ([]model.Review, string, error) {
+	var raw []any
+	if err := json.Unmarshal(stripXSSI(body), &raw); err != nil {
+		return nil, "", fmt.Errorf("decoding reviews page: %w", err)
+	}
+
+	var out []model.Review
+	for _, item := range safeSlice(safeGet(raw, 2)) {
+		if r, ok := parseReviewEl(safeGet(item, 0), cid); ok {
+			out = append(out, r)
+		}
+	}
+	return out, safeString(safeGet(raw, 1)), nil
+}
+
+// parseReviewEl reads a review element: [id, [.., author at [4][5][0],
+// relative date at [6]], [rating at [0][0], date at [2][0][1][21][6][8],
+// language at [14][0], text at [15][0][0]], [owner response at [14][0][0]]].
+func parseReviewEl(el any, cid string) (model.Review, bool) {
+	r := model.Review{
+		CID:           cid,
+		ID:            safeString(safeGet(el, 0)),
+		Author:        safeString(safeGet(el, 1, 4, 5, 0)),
+		RelativeDate:  safeString(safeGet(el, 1, 6)),
+		Rating:        int(safeFloat(safeGet(el, 2, 0, 0))),
+		Text:          safeString(safeGet(el, 2, 15, 0, 0)),
+		Language:      safeString(safeGet(el, 2, 14, 0)),
+		Published:     reviewDate(safeSlice(safeGet(el, 2, 2, 0, 1, 21, 6, 8))),
+		OwnerResponse: safeString(safeGet(el, 3, 14, 0, 0)),
+	}
+	if r.Author == "" && r.Text == "" {
+		return r, false
+	}
+	return withReviewID(r), true
+}
+
+var relativeDateRe = regexp.MustCompile(`(?i)\b(a|an|un|una|\d+)\s+(day|week|month|year|día|dia|semana|mes|año|ano)`)
+
+// ReviewTime returns when a review was published: its absolute date when
+// known, otherwise an approximation from its relative date ("3 months ago",
+// "hace un año") counted back from now. Only English and Spanish relative
+// dates are recognized; ok is false when neither is usable.
+func ReviewTime(r model.Review, now time.Time) (time.Time, bool) {
+	if t, err := time.Parse("2006-01-02", r.Published); err == nil {
+		return t, true
+	}
+
+	text := strings.ToLower(r.RelativeDate)
+	m := relativeDateRe.FindStringSubmatch(text)
+	if m == nil {
+		// "an hour ago", "yesterday", "hace unos minutos"...
+		for _, recent := range []string{"hour", "minute", "moment", "yesterday", "hora", "minuto", "momento", "ayer"} {
+			if strings.Contains(text, recent) {
+				return now, true
+			}
+		}
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		n = 1
+	}
+	switch m[2] {
+	case "day", "día", "dia":
+		return now.AddDate(0, 0, -n), true
+	case "week", "semana":
+		return now.AddDate(0, 0, -7*n), true
+	case "month", "mes":
+		return now.AddDate(0, -n, 0), true
+	default:
+		return now.AddDate(-n, 0, 0), true
+	}
+}
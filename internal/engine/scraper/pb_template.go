@@ -9,6 +9,8 @@ const (
 	viewportW = 1024
 	viewportH = 768
 	pageSize  = 20
+
+	reviewsPageSize = 20
 )
 
 // BuildPB constructs the pb= protobuf URL parameter for tbm=map requests.
@@ -26,6 +28,16 @@ func BuildPB(lat, lng float64, zoom, offset int) string {
 	)
 }
 
+// BuildReviewsPB constructs the pb= parameter of a listugcposts request for
+// one page of a place's reviews, sorted newest first (!13m1!1e2).
+func BuildReviewsPB(cid, token string) string {
+	return fmt.Sprintf(
+		"!1m6!1s%s!6m4!4m1!1e1!4m1!1e3!2m2!1i%d!2s%s"+
+			"!5m2!1s!7e81!8m9!2b1!3b1!5b1!7b1!12m4!1b1!2b1!4m1!1e1!11m0!13m1!1e2",
+		cid, reviewsPageSize, token,
+	)
+}
+
 // altitude converts zoom level to meters for the !1d field.
 // Formula: alt = (2 * pi * R * viewportH) / (512 * 2^zoom)
 func altitude(lat float64, zoom int) float64 {
@@ -0,0 +1,45 @@
+package scraper
+
+import (
+	"time"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// estRequestLatency is the typical round-trip of a tbm=map request observed
+// on residential connections, used for ETA estimates.
+const estRequestLatency = 1200 * time.Millisecond
+
+// Estimate summarizes the expected cost of a scan before it starts.
+type Estimate struct {
+	Jobs        int           // sectors × queries
+	MinRequests int           // one page per job
+	MaxRequests int           // every job paginating up to MaxPages
+	MinETA      time.Duration // MinRequests at the configured concurrency
+	MaxETA      time.Duration // MaxRequests at the configured concurrency
+}
+
+// EstimateScan computes request counts and ETA for scanning numSectors sectors
+// with params. Adaptive subdivision is not predictable and is not included.
+func EstimateScan(numSectors int, params model.SearchParams) Estimate {
+	maxPages := params.MaxPages
+	if maxPages <= 0 {
+		maxPages = 1
+	}
+	concurrency := params.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	e := Estimate{Jobs: numSectors * len(params.Queries)}
+	e.MinRequests = e.Jobs
+	e.MaxRequests = e.Jobs * maxPages
+	e.MinETA = requestsETA(e.MinRequests, concurrency)
+	e.MaxETA = requestsETA(e.MaxRequests, concurrency)
+	return e
+}
+
+func requestsETA(requests, concurrency int) time.Duration {
+	rounds := (requests + concurrency - 1) / concurrency
+	return (time.Duration(rounds) * estRequestLatency).Truncate(time.Second)
+}
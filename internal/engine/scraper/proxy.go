@@ -0,0 +1,171 @@
+package scraper
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+const (
+	proxyBenchBase = 30 * time.Second
+	proxyBenchMax  = 10 * time.Minute
+)
+
+// ProxyStats holds the counters of one proxy in a pool.
+type ProxyStats struct {
+	URL        string // credentials redacted
+	Requests   atomic.Int64
+	Successes  atomic.Int64
+	Failures   atomic.Int64
+	RateLimits atomic.Int64
+	Benched    atomic.Int64 // times the proxy was taken out of rotation
+
+	benchedUntil atomic.Int64 // unix nanos; zero when healthy
+}
+
+// Healthy reports whether the proxy is currently in rotation.
+func (p *ProxyStats) Healthy() bool {
+	return time.Now().UnixNano() >= p.benchedUntil.Load()
+}
+
+// LoadProxies reads a proxy list: one URL per line, blank lines and lines
+// starting with # are skipped. Entries without a scheme are taken as HTTP
+// proxies ("host:port" or "user:pass@host:port").
+func LoadProxies(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening proxy list: %w", err)
+	}
+	defer f.Close()
+
+	var proxies []string
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !strings.Contains(line, "://") {
+			line = "http://" + line
+		}
+		u, err := url.Parse(line)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("%s:%d: invalid proxy %q", path, n, line)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("%s:%d: unsupported proxy scheme %q", path, n, u.Scheme)
+		}
+		proxies = append(proxies, u.String())
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading proxy list: %w", err)
+	}
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("no proxies in %s", path)
+	}
+	return proxies, nil
+}
+
+// proxy is one pool member: its own client (and so its own cookie jar and
+// rate-limit counter) plus its counters.
+type proxy struct {
+	client *Client
+	stats  *ProxyStats
+
+	mu          sync.Mutex
+	consecutive int // rate limits since the last success, drives the bench length
+}
+
+// proxyPool rotates requests round-robin across healthy proxies.
+type proxyPool struct {
+	proxies []*proxy
+	next    atomic.Uint64
+}
+
+func newProxyPool(params model.SearchParams) *proxyPool {
+	p := &proxyPool{}
+	for _, u := range params.Proxies {
+		c := newClient(params, u)
+		// Rotate to another proxy instead of backing off on this one
+		c.retries = 1
+		p.proxies = append(p.proxies, &proxy{client: c, stats: &ProxyStats{URL: model.RedactURL(u)}})
+	}
+	return p
+}
+
+// stats returns the counters of every proxy in pool order.
+func (p *proxyPool) stats() []*ProxyStats {
+	out := make([]*ProxyStats, len(p.proxies))
+	for i, px := range p.proxies {
+		out[i] = px.stats
+	}
+	return out
+}
+
+// acquire returns the next healthy proxy. When every proxy is benched it
+// waits for the first one to come back, or returns ctx.Err().
+func (p *proxyPool) acquire(ctx context.Context) (*proxy, error) {
+	for {
+		start := p.next.Add(1)
+		var soonest int64
+		for i := range p.proxies {
+			px := p.proxies[(start+uint64(i))%uint64(len(p.proxies))]
+			until := px.stats.benchedUntil.Load()
+			if time.Now().UnixNano() >= until {
+				return px, nil
+			}
+			if soonest == 0 || until < soonest {
+				soonest = until
+			}
+		}
+
+		t := time.NewTimer(time.Until(time.Unix(0, soonest)))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// record updates the proxy's counters with the outcome of a request and
+// benches it on a rate limit. It returns the bench duration (zero if none).
+func (px *proxy) record(err error) time.Duration {
+	px.stats.Requests.Add(1)
+	if err == nil {
+		px.stats.Successes.Add(1)
+		px.mu.Lock()
+		px.consecutive = 0
+		px.mu.Unlock()
+		return 0
+	}
+
+	px.stats.Failures.Add(1)
+	if _, ok := err.(*RateLimitError); !ok {
+		return 0
+	}
+
+	px.stats.RateLimits.Add(1)
+	px.stats.Benched.Add(1)
+
+	px.mu.Lock()
+	d := proxyBenchBase << min(px.consecutive, 5)
+	px.consecutive++
+	px.mu.Unlock()
+	if d > proxyBenchMax {
+		d = proxyBenchMax
+	}
+	px.stats.benchedUntil.Store(time.Now().Add(d).UnixNano())
+	return d
+}
@@ -0,0 +1,133 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestPool(urls ...string) *proxyPool {
+	p := &proxyPool{}
+	for _, u := range urls {
+		p.proxies = append(p.proxies, &proxy{stats: &ProxyStats{URL: u}})
+	}
+	return p
+}
+
+func TestProxyPoolRotation(t *testing.T) {
+	p := newTestPool("a", "b", "c")
+	counts := make(map[string]int)
+	var prev string
+	for i := 0; i < 30; i++ {
+		px, err := p.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		if px.stats.URL == prev {
+			t.Errorf("acquire %d returned %s twice in a row", i, prev)
+		}
+		prev = px.stats.URL
+		counts[px.stats.URL]++
+	}
+	for _, u := range []string{"a", "b", "c"} {
+		if counts[u] != 10 {
+			t.Errorf("%s served %d of 30 requests, want 10 (%v)", u, counts[u], counts)
+		}
+	}
+}
+
+func TestProxyPoolSkipsBenched(t *testing.T) {
+	p := newTestPool("a", "b", "c")
+	benched := p.proxies[1]
+	benched.stats.benchedUntil.Store(time.Now().Add(100 * time.Millisecond).UnixNano())
+	if benched.stats.Healthy() {
+		t.Error("benched proxy reports healthy")
+	}
+
+	for i := 0; i < 10; i++ {
+		px, err := p.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+		if px == benched {
+			t.Fatalf("acquire %d returned the benched proxy", i)
+		}
+	}
+
+	// It is back in rotation once the bench runs out
+	time.Sleep(150 * time.Millisecond)
+	if !benched.stats.Healthy() {
+		t.Error("proxy still benched after its cooldown")
+	}
+	seen := false
+	for i := 0; i < 3; i++ {
+		px, _ := p.acquire(context.Background())
+		seen = seen || px == benched
+	}
+	if !seen {
+		t.Error("proxy not back in rotation after its cooldown")
+	}
+}
+
+func TestProxyPoolAllBenched(t *testing.T) {
+	p := newTestPool("a", "b")
+	now := time.Now()
+	p.proxies[0].stats.benchedUntil.Store(now.Add(time.Minute).UnixNano())
+	p.proxies[1].stats.benchedUntil.Store(now.Add(80 * time.Millisecond).UnixNano())
+
+	// acquire waits for the first proxy to come back
+	px, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if px != p.proxies[1] {
+		t.Errorf("acquire returned %s, want b, the first back", px.stats.URL)
+	}
+	if waited := time.Since(now); waited < 80*time.Millisecond {
+		t.Errorf("acquire returned after %v, before any proxy came back", waited)
+	}
+
+	// or gives up when the context ends first
+	p.proxies[1].stats.benchedUntil.Store(time.Now().Add(time.Minute).UnixNano())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if px, err := p.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire = %v, %v, want the context's error", px, err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("acquire returned after %v, long after the context ended", waited)
+	}
+}
+
+func TestProxyRecord(t *testing.T) {
+	px := &proxy{stats: &ProxyStats{URL: "a"}}
+
+	// Each rate limit in a row doubles the bench, up to the cap
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, proxyBenchMax, proxyBenchMax}
+	for i, w := range want {
+		if d := px.record(&RateLimitError{StatusCode: 429}); d != w {
+			t.Errorf("rate limit %d benched for %v, want %v", i+1, d, w)
+		}
+	}
+	if px.stats.Healthy() {
+		t.Error("rate-limited proxy reports healthy")
+	}
+
+	// Other errors count but do not bench; a success resets the streak
+	if d := px.record(errors.New("connection reset")); d != 0 {
+		t.Errorf("plain error benched for %v", d)
+	}
+	px.record(nil)
+	if d := px.record(&RateLimitError{StatusCode: 429}); d != proxyBenchBase {
+		t.Errorf("rate limit after a success benched for %v, want %v", d, proxyBenchBase)
+	}
+
+	s := px.stats
+	if s.Requests.Load() != 10 || s.Successes.Load() != 1 || s.Failures.Load() != 9 ||
+		s.RateLimits.Load() != 8 || s.Benched.Load() != 8 {
+		t.Errorf("requests=%d successes=%d failures=%d rate limits=%d benched=%d, want 10 1 9 8 8",
+			s.Requests.Load(), s.Successes.Load(), s.Failures.Load(), s.RateLimits.Load(), s.Benched.Load())
+	}
+}
@@ -0,0 +1,88 @@
+package scraper
+
+import "sync"
+
+// jobQueue is an unbounded FIFO of jobs that workers can grow while the run
+// is in progress (e.g. adaptive subdivision). It drains once every popped job
+// has been marked done and no queued jobs remain. Like the ledger, it holds
+// each sector and query once.
+type jobQueue struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	items    []Job
+	seen     map[jobKey]bool
+	inflight int
+	closed   bool
+}
+
+// jobKey identifies a job the way the ledger does, whatever its page.
+type jobKey struct {
+	zoom, row, col int
+	query          string
+}
+
+func newJobQueue(jobs []Job) *jobQueue {
+	q := &jobQueue{seen: make(map[jobKey]bool)}
+	q.cond = sync.NewCond(&q.mu)
+	q.add(jobs)
+	return q
+}
+
+// push appends the jobs the queue has not held before and returns how many
+// it added. A resumed sector that saturates again splits into children the
+// resume already queued from the ledger.
+func (q *jobQueue) push(jobs ...Job) int {
+	q.mu.Lock()
+	n := q.add(jobs)
+	q.mu.Unlock()
+	q.cond.Broadcast()
+	return n
+}
+
+// add appends the unseen jobs; q.mu must be held.
+func (q *jobQueue) add(jobs []Job) int {
+	n := 0
+	for _, j := range jobs {
+		k := jobKey{j.Sector.Zoom, j.Sector.Row, j.Sector.Col, j.Query}
+		if q.seen[k] {
+			continue
+		}
+		q.seen[k] = true
+		q.items = append(q.items, j)
+		n++
+	}
+	return n
+}
+
+// pop blocks until a job is available. It returns false once the queue is
+// closed or fully drained.
+func (q *jobQueue) pop() (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && q.inflight > 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed || len(q.items) == 0 {
+		return Job{}, false
+	}
+	j := q.items[0]
+	q.items = q.items[1:]
+	q.inflight++
+	return j, true
+}
+
+// done marks a popped job as finished.
+func (q *jobQueue) done() {
+	q.mu.Lock()
+	q.inflight--
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// close stops the queue; pending pop calls return false.
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
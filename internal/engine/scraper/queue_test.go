@@ -0,0 +1,199 @@
+package scraper
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/geo"
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+)
+
+func TestJobQueue(t *testing.T) {
+	job := func(col int) Job { return Job{Sector: model.Sector{Zoom: 12, Col: col}, Query: "cafes"} }
+
+	q := newJobQueue([]Job{job(0), job(1)})
+	for want := 0; want < 2; want++ {
+		j, ok := q.pop()
+		if !ok || j.Sector.Col != want {
+			t.Fatalf("pop = %+v, %v, want col %d", j, ok, want)
+		}
+	}
+
+	// With both jobs in flight an empty queue waits for what they push
+	popped := make(chan Job)
+	go func() {
+		j, ok := q.pop()
+		if !ok {
+			close(popped)
+			return
+		}
+		popped <- j
+	}()
+	select {
+	case j := <-popped:
+		t.Fatalf("pop returned %+v while jobs were in flight", j)
+	case <-time.After(50 * time.Millisecond):
+	}
+	q.push(job(2))
+	if j, ok := <-popped; !ok || j.Sector.Col != 2 {
+		t.Fatalf("pop = %+v, %v, want the pushed job", j, ok)
+	}
+
+	// It drains once the last job in flight is done
+	go func() {
+		_, ok := q.pop()
+		if ok {
+			popped <- Job{}
+		}
+		close(popped)
+	}()
+	q.done()
+	q.done()
+	select {
+	case <-popped:
+		t.Fatal("queue drained with a job still in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	q.done()
+	select {
+	case _, ok := <-popped:
+		if ok {
+			t.Fatal("pop returned a job from a drained queue")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pop did not return once the queue drained")
+	}
+
+	// close stops a waiting pop even with jobs in flight
+	q = newJobQueue([]Job{job(0)})
+	q.pop()
+	stopped := make(chan bool)
+	go func() {
+		_, ok := q.pop()
+		stopped <- ok
+	}()
+	q.close()
+	if ok := <-stopped; ok {
+		t.Error("pop returned a job from a closed queue")
+	}
+	if _, ok := q.pop(); ok {
+		t.Error("pop returned a job from a closed queue")
+	}
+}
+
+func TestSubdivide(t *testing.T) {
+	store, err := storage.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+
+	r := &runner{
+		store:  store,
+		params: model.SearchParams{Adaptive: true, MaxZoom: 15},
+		stats:  &Stats{},
+		logger: log.New(io.Discard, "", 0),
+		queue:  newJobQueue(nil),
+	}
+
+	// Splitting stops at the maximum zoom
+	tests := []struct {
+		params model.SearchParams
+		zoom   int
+		want   bool
+	}{
+		{model.SearchParams{Adaptive: true, MaxZoom: 15}, 14, true},
+		{model.SearchParams{Adaptive: true, MaxZoom: 15}, 15, false},
+		{model.SearchParams{Adaptive: true, MaxZoom: 15}, 16, false},
+		{model.SearchParams{Adaptive: true}, 15, true},
+		{model.SearchParams{Adaptive: true}, defaultMaxZoom, false},
+		{model.SearchParams{Adaptive: true}, 0, false},
+		{model.SearchParams{MaxZoom: 15}, 12, false},
+	}
+	for _, tt := range tests {
+		rr := &runner{params: tt.params}
+		if got := rr.canSubdivide(model.Sector{Zoom: tt.zoom}); got != tt.want {
+			t.Errorf("canSubdivide(zoom %d) with %+v = %v, want %v", tt.zoom, tt.params, got, tt.want)
+		}
+	}
+
+	parent := Job{Sector: model.Sector{Lat: -33.45, Lng: -70.66, Span: geo.ZoomToSpanDegrees(14), Zoom: 14, Row: 3, Col: 7}, Query: "cafes"}
+	r.subdivide(parent)
+
+	children := geo.SplitSector(parent.Sector)
+	var queued []model.Sector
+	for range children {
+		j, ok := r.queue.pop()
+		if !ok {
+			t.Fatalf("queued %d children, want %d", len(queued), len(children))
+		}
+		if j.Query != parent.Query || j.Page != 0 {
+			t.Errorf("child job %+v, want the parent's query from the first page", j)
+		}
+		queued = append(queued, j.Sector)
+		r.queue.done()
+	}
+	if j, ok := r.queue.pop(); ok {
+		t.Errorf("queued %+v after the children", j)
+	}
+	if !reflect.DeepEqual(queued, children) {
+		t.Errorf("queued %v, want %v", queued, children)
+	}
+
+	// Each child is in the ledger once, pending, so a resume picks it up
+	jobs, err := store.UnfinishedJobs()
+	if err != nil {
+		t.Fatalf("UnfinishedJobs: %v", err)
+	}
+	var recorded []model.Sector
+	for _, j := range jobs {
+		if j.Query != parent.Query || j.Status != storage.JobPending {
+			t.Errorf("ledger job %+v, want pending for the parent's query", j)
+		}
+		recorded = append(recorded, j.Sector)
+	}
+	if !reflect.DeepEqual(recorded, children) {
+		t.Errorf("ledger has %v, want %v", recorded, children)
+	}
+
+	if r.stats.Subdivided.Load() != 1 || r.stats.SectorsTotal.Load() != 4 {
+		t.Errorf("stats subdivided=%d total=%d, want 1 and 4", r.stats.Subdivided.Load(), r.stats.SectorsTotal.Load())
+	}
+
+	// A resume queues the children from the ledger along with their parent,
+	// left pending by an interrupt; the parent saturating again adds nothing
+	r.stats = &Stats{}
+	r.queue = newJobQueue(append([]Job{parent}, Job{Sector: children[2], Query: parent.Query, Page: 1}))
+	r.queue.pop()
+	r.subdivide(parent)
+	r.queue.done()
+	var resumed []Job
+	for {
+		j, ok := r.queue.pop()
+		if !ok {
+			break
+		}
+		resumed = append(resumed, j)
+		r.queue.done()
+	}
+	want := []Job{
+		{Sector: children[2], Query: parent.Query, Page: 1},
+		{Sector: children[0], Query: parent.Query},
+		{Sector: children[1], Query: parent.Query},
+		{Sector: children[3], Query: parent.Query},
+	}
+	if !reflect.DeepEqual(resumed, want) {
+		t.Errorf("resume queued %+v, want each child once: %+v", resumed, want)
+	}
+	if r.stats.SectorsTotal.Load() != 3 {
+		t.Errorf("resume total grew by %d, want 3", r.stats.SectorsTotal.Load())
+	}
+	if jobs, err := store.UnfinishedJobs(); err != nil || len(jobs) != len(children) {
+		t.Errorf("ledger has %d jobs after the resume, want %d (%v)", len(jobs), len(children), err)
+	}
+}
@@ -0,0 +1,247 @@
+package scraper
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/geo"
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+)
+
+// Recording layout: a manifest plus one gzip'd JSON-lines archive of raw
+// responses. Each recording session appends a new gzip member, which
+// gzip readers decode as one continuous stream.
+const (
+	recordManifestFile  = "manifest.json"
+	recordResponsesFile = "responses.jsonl.gz"
+	recordVersion       = 1
+)
+
+// RecordManifest describes a recording directory.
+type RecordManifest struct {
+	Version   int                `json:"version"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	Responses int                `json:"responses"`
+	Params    model.SearchParams `json:"params"`
+}
+
+// RecordedResponse is one raw tbm=map response with its request context.
+type RecordedResponse struct {
+	Time   time.Time    `json:"time"`
+	URL    string       `json:"url"`
+	Query  string       `json:"query"`
+	Sector model.Sector `json:"sector"`
+	Page   int          `json:"page"`
+	Body   string       `json:"body"`
+}
+
+// Recorder appends raw responses to a recording directory. It is safe for
+// concurrent use by the scraper workers.
+type Recorder struct {
+	dir      string
+	manifest RecordManifest
+
+	mu    sync.Mutex
+	file  *os.File
+	gz    *gzip.Writer
+	enc   *json.Encoder
+	count int
+}
+
+// NewRecorder opens (or creates) a recording directory for a scan with params.
+func NewRecorder(dir string, params model.SearchParams) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating record dir: %w", err)
+	}
+
+	manifest, err := LoadRecordManifest(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		manifest = &RecordManifest{Version: recordVersion, CreatedAt: time.Now()}
+	} else if err != nil {
+		return nil, err
+	} else if err := checkRecordParams(dir, manifest, params); err != nil {
+		return nil, err
+	}
+	manifest.Params = params.Redacted()
+
+	f, err := os.OpenFile(filepath.Join(dir, recordResponsesFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("opening record archive: %w", err)
+	}
+	gz := gzip.NewWriter(f)
+
+	rec := &Recorder{dir: dir, manifest: *manifest, file: f, gz: gz, enc: json.NewEncoder(gz)}
+	if err := rec.writeManifest(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return rec, nil
+}
+
+// CheckRecordDir reports whether a scan with params can record into dir: a
+// new directory, or one recorded by a scan of the same search. Replay
+// applies one set of params to the whole archive, so responses of different
+// searches cannot share a directory.
+func CheckRecordDir(dir string, params model.SearchParams) error {
+	manifest, err := LoadRecordManifest(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return checkRecordParams(dir, manifest, params)
+}
+
+func checkRecordParams(dir string, manifest *RecordManifest, params model.SearchParams) error {
+	if !reflect.DeepEqual(manifest.Params.Search(), params.Search()) {
+		return fmt.Errorf("%s holds a recording of a different search (%s, %s): record into a new directory",
+			dir, strings.Join(manifest.Params.Queries, ","), manifest.Params.AreaLabel())
+	}
+	return nil
+}
+
+// Record appends one response.
+func (rec *Recorder) Record(reqURL string, job Job, page int, body []byte) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	err := rec.enc.Encode(RecordedResponse{
+		Time:   time.Now(),
+		URL:    reqURL,
+		Query:  job.Query,
+		Sector: job.Sector,
+		Page:   page,
+		Body:   string(body),
+	})
+	if err != nil {
+		return fmt.Errorf("writing response: %w", err)
+	}
+	rec.count++
+	// Flush so an interrupted scan keeps everything recorded so far
+	return rec.gz.Flush()
+}
+
+// Close finishes the archive and updates the manifest's response count.
+func (rec *Recorder) Close() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	if err := rec.gz.Close(); err != nil {
+		rec.file.Close()
+		return fmt.Errorf("closing record archive: %w", err)
+	}
+	if err := rec.file.Close(); err != nil {
+		return fmt.Errorf("closing record archive: %w", err)
+	}
+	rec.manifest.Responses += rec.count
+	rec.count = 0
+	return rec.writeManifest()
+}
+
+func (rec *Recorder) writeManifest() error {
+	rec.manifest.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(rec.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(rec.dir, recordManifestFile), data, 0644); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	return nil
+}
+
+// LoadRecordManifest reads the manifest of a recording directory.
+func LoadRecordManifest(dir string) (*RecordManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, recordManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	var m RecordManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("decoding manifest: %w", err)
+	}
+	if m.Version > recordVersion {
+		return nil, fmt.Errorf("recording version %d is newer than supported (%d)", m.Version, recordVersion)
+	}
+	return &m, nil
+}
+
+// Replay feeds the responses of a recording directory through
+// ParseMapResponse and the filter/storage pipeline, without any network
+// access. params supplies the filters, normally the manifest's.
+func Replay(ctx context.Context, dir string, params model.SearchParams, store *storage.Store, logger *log.Logger, opts *RunOptions) (*Stats, error) {
+	if opts == nil {
+		opts = &RunOptions{}
+	}
+	stats := opts.Stats
+	if stats == nil {
+		stats = &Stats{}
+	}
+	if m, err := LoadRecordManifest(dir); err == nil {
+		stats.SectorsTotal.Store(int64(m.Responses))
+	}
+
+	f, err := os.Open(filepath.Join(dir, recordResponsesFile))
+	if err != nil {
+		return stats, fmt.Errorf("opening record archive: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return stats, fmt.Errorf("reading record archive: %w", err)
+	}
+	defer gz.Close()
+
+	r := &runner{
+		store:       store,
+		params:      params,
+		stats:       stats,
+		logger:      logger,
+		opts:        opts,
+		phoneRegion: geo.CountryISO2(params.Country),
+	}
+	r.loadCoverageBaseline()
+
+	dec := json.NewDecoder(gz)
+	for {
+		if err := ctx.Err(); err != nil {
+			r.finishCoverage()
+			return stats, err
+		}
+
+		var resp RecordedResponse
+		if err := dec.Decode(&resp); err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			// A scan killed mid-write leaves a truncated last member
+			break
+		} else if err != nil {
+			return stats, fmt.Errorf("decoding recorded response: %w", err)
+		}
+
+		if _, err := r.storePage([]byte(resp.Body), resp.Query, storage.Origin{Sector: resp.Sector, Page: resp.Page}); err != nil {
+			stats.Errors.Add(1)
+			logger.Printf("REPLAY sector=%d,%d page=%d err=%v", resp.Sector.Row, resp.Sector.Col, resp.Page, err)
+		}
+		stats.SectorsDone.Add(1)
+		if n := stats.SectorsDone.Load(); n > stats.SectorsTotal.Load() {
+			stats.SectorsTotal.Store(n)
+		}
+	}
+
+	r.finishCoverage()
+	logger.Printf("REPLAY done responses=%d found=%d stored=%d",
+		stats.SectorsDone.Load(), stats.BusinessesFound.Load(), stats.BusinessesStored.Load())
+	return stats, nil
+}
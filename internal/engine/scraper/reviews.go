@@ -0,0 +1,189 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/model"
+)
+
+// ReviewStats holds the counters of a review fetching run.
+type ReviewStats struct {
+	PlacesTotal atomic.Int64
+	PlacesDone  atomic.Int64
+	Pages       atomic.Int64
+	Reviews     atomic.Int64 // new reviews stored
+	Errors      atomic.Int64
+	RateLimits  atomic.Int64
+
+	proxies atomic.Pointer[[]*ProxyStats]
+}
+
+// Proxies returns the per-proxy counters of a run using a proxy pool, or nil.
+func (s *ReviewStats) Proxies() []*ProxyStats {
+	if p := s.proxies.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// ReviewOptions provides optional settings for FetchReviews.
+type ReviewOptions struct {
+	// Limit keeps only the newest Limit reviews of each place (0 = all).
+	Limit int
+	// Since stops at the first review published before it (zero = no limit).
+	// Reviews with only a relative date are placed approximately.
+	Since time.Time
+	// SuppressStderr disables the built-in stderr progress reporter.
+	SuppressStderr bool
+	// Stats allows passing an external ReviewStats for live progress tracking.
+	Stats *ReviewStats
+}
+
+// FetchReviews pages through the reviews of every place in the store's
+// review ledger that is not done yet, newest first, and stores them. Call
+// Store.EnqueueReviewJobs first to queue the scanned places. Paging stops
+// early at a page with no new reviews, so refreshing a place only fetches
+// what was posted since the last run.
+func FetchReviews(ctx context.Context, params model.SearchParams, store *storage.Store, logger *log.Logger, opts *ReviewOptions) (*ReviewStats, error) {
+	if opts == nil {
+		opts = &ReviewOptions{}
+	}
+	stats := opts.Stats
+	if stats == nil {
+		stats = &ReviewStats{}
+	}
+
+	jobs, err := store.UnfinishedReviewJobs()
+	if err != nil {
+		return stats, err
+	}
+	stats.PlacesTotal.Store(int64(len(jobs)))
+	logger.Printf("REVIEWS %d places limit=%d since=%s", len(jobs), opts.Limit, formatSince(opts.Since))
+
+	r := newRunner(params, store, logger)
+	if r.pool != nil {
+		ps := r.pool.stats()
+		stats.proxies.Store(&ps)
+	}
+
+	stop := r.startProgress(opts.SuppressStderr,
+		func() string {
+			return fmt.Sprintf("[%d/%d places] %d reviews | %d pages | %d errors",
+				stats.PlacesDone.Load(), stats.PlacesTotal.Load(), stats.Reviews.Load(),
+				stats.Pages.Load(), stats.Errors.Load())
+		},
+		func() string {
+			return fmt.Sprintf("places=%d/%d reviews=%d pages=%d errors=%d rate_limits=%d",
+				stats.PlacesDone.Load(), stats.PlacesTotal.Load(), stats.Reviews.Load(),
+				stats.Pages.Load(), stats.Errors.Load(), stats.RateLimits.Load())
+		})
+	r.forEachPlace(ctx, len(jobs), opts.SuppressStderr, func(i int) {
+		r.fetchPlaceReviews(ctx, jobs[i], opts, stats)
+	})
+	stop()
+
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// fetchPlaceReviews pages through and stores the reviews of one place.
+func (r *runner) fetchPlaceReviews(ctx context.Context, job storage.ReviewJob, opts *ReviewOptions, stats *ReviewStats) {
+	defer stats.PlacesDone.Add(1)
+
+	now := time.Now()
+	token := ""
+	kept := 0
+	for {
+		body, err := r.fetch(ctx, func(c *Client) ([]byte, error) {
+			return c.Reviews(ctx, job.CID, token)
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				r.updateReviewJob(job, storage.JobInterrupted, nil)
+				return
+			}
+			if rl, ok := err.(*RateLimitError); ok {
+				stats.RateLimits.Add(1)
+				r.adjustDelay(true)
+				r.logger.Printf("RATE_LIMIT cid=%s status=%d", job.CID, rl.StatusCode)
+			} else {
+				r.logger.Printf("ERROR cid=%s err=%v", job.CID, err)
+			}
+			stats.Errors.Add(1)
+			r.updateReviewJob(job, storage.JobFailed, err)
+			return
+		}
+		r.adjustDelay(false)
+		stats.Pages.Add(1)
+
+		reviews, next, err := ParseReviewsPage(body, job.CID)
+		if err != nil {
+			r.logger.Printf("PARSE cid=%s err=%v", job.CID, err)
+			stats.Errors.Add(1)
+			r.updateReviewJob(job, storage.JobFailed, err)
+			return
+		}
+
+		// Pages are newest first: the first review past a limit ends the place
+		done := false
+		var page []model.Review
+		for _, rv := range reviews {
+			if opts.Limit > 0 && kept+len(page) >= opts.Limit {
+				done = true
+				break
+			}
+			if !opts.Since.IsZero() {
+				if t, ok := ReviewTime(rv, now); ok && t.Before(opts.Since) {
+					done = true
+					break
+				}
+			}
+			page = append(page, rv)
+		}
+
+		added, err := r.store.InsertReviews(page)
+		if err != nil {
+			stats.Errors.Add(1)
+			r.updateReviewJob(job, storage.JobFailed, err)
+			return
+		}
+		kept += len(page)
+		stats.Reviews.Add(int64(added))
+		if opts.Limit > 0 && kept >= opts.Limit {
+			done = true
+		}
+
+		// A page with nothing new means the rest was stored by an earlier run
+		if done || next == "" || len(reviews) == 0 || (len(page) > 0 && added == 0) {
+			break
+		}
+		token = next
+	}
+	r.updateReviewJob(job, storage.JobDone, nil)
+}
+
+// updateReviewJob updates the review ledger, logging (but not failing on)
+// storage errors.
+func (r *runner) updateReviewJob(job storage.ReviewJob, status string, jobErr error) {
+	var msg string
+	if jobErr != nil {
+		msg = jobErr.Error()
+	}
+	if err := r.store.UpdateReviewJob(job.CID, status, msg); err != nil {
+		r.logger.Printf("LEDGER cid=%s err=%v", job.CID, err)
+	}
+}
+
+func formatSince(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,181 @@
+package scraper
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rendis/geotap/internal/engine/storage"
+	"github.com/rendis/geotap/internal/fakemaps"
+	"github.com/rendis/geotap/internal/model"
+)
+
+func TestParseReviewsPage(t *testing.T) {
+	body, err := os.ReadFile(filepath.Join("testdata", "reviews", "page1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviews, next, err := ParseReviewsPage(body, "1711231875390658011")
+	if err != nil {
+		t.Fatalf("ParseReviewsPage: %v", err)
+	}
+	if next != "CAESY0NBRVFBUnBDUTJGQ1VWUkJRVUZC" {
+		t.Errorf("next = %q, want the continuation token", next)
+	}
+	// The review with neither author nor text is skipped
+	want := []model.Review{
+		{CID: "1711231875390658011", ID: "ChZDSUhNMG9nS0VJQ0FnSUR4czRmOFNBEAE", Author: "Lucía Fernández", Rating: 5,
+			Text: "Best café con leche in the area.", Language: "en", Published: "2026-10-09",
+			RelativeDate: "a week ago", OwnerResponse: "¡Gracias, Lucía!"},
+		{CID: "1711231875390658011", ID: "ChdDSUhNMG9nS0VJQ0FnSUN4NmZqRGpRRRAB", Author: "Marco Rossi", Rating: 2,
+			Text: "Muy lento a la hora punta.", Language: "es", Published: "2026-09-24", RelativeDate: "3 weeks ago"},
+		{CID: "1711231875390658011", ID: "ChZDSUhNMG9nS0VJQ0FnSUR4aTlfN1dREAE", Author: "Ana", Rating: 4,
+			Language: "en", Published: "2026-09-10", RelativeDate: "a month ago"},
+	}
+	if !reflect.DeepEqual(reviews, want) {
+		t.Errorf("reviews = %+v\nwant %+v", reviews, want)
+	}
+
+	// The last page has no token; a review without an ID gets a stable one
+	body, err = os.ReadFile(filepath.Join("testdata", "reviews", "page2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviews, next, err = ParseReviewsPage(body, "1711231875390658011")
+	if err != nil || next != "" || len(reviews) != 1 {
+		t.Fatalf("ParseReviewsPage = %+v, %q, %v, want one review and no token", reviews, next, err)
+	}
+	again, _, _ := ParseReviewsPage(body, "1711231875390658011")
+	if r := reviews[0]; !strings.HasPrefix(r.ID, "h:") || r.ID != again[0].ID || r.Author != "Pedro Gil" || r.Published != "" {
+		t.Errorf("review = %+v, want a hashed ID and no date", r)
+	}
+
+	if _, _, err := ParseReviewsPage([]byte("<html>consent</html>"), "1"); err == nil {
+		t.Error("ParseReviewsPage of an HTML page succeeded, want an error")
+	}
+}
+
+func TestReviewTime(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 12, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		published, relative string
+		want                time.Time
+		ok                  bool
+	}{
+		{"2026-03-04", "a month ago", time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC), true},
+		{"", "a day ago", day(2026, 10, 16), true},
+		{"", "3 weeks ago", day(2026, 9, 26), true},
+		{"", "2 months ago", day(2026, 8, 17), true},
+		{"", "a year ago", day(2025, 10, 17), true},
+		{"", "hace un mes", day(2026, 9, 17), true},
+		{"", "hace 11 meses", day(2025, 11, 17), true},
+		{"", "Hace 2 años", day(2024, 10, 17), true},
+		{"", "an hour ago", now, true},
+		{"", "ayer", now, true},
+		{"", "", time.Time{}, false},
+		{"", "il y a 2 mois", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ReviewTime(model.Review{Published: tt.published, RelativeDate: tt.relative}, now)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("ReviewTime(%q, %q) = %v, %v, want %v, %v", tt.published, tt.relative, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFetchReviewsLimits(t *testing.T) {
+	// fakemaps serves 59 and 53 reviews for these places, 20 a page, newest
+	// first: one on 2026-10-01 and then one every 11 days
+	sector := model.Sector{Lat: 40.4168, Lng: -3.7038, Zoom: 13}
+	places := []fakemaps.Place{
+		{Name: "Cafe Sol", Categories: []string{"Cafe"}, CID: "1000000000000000002", PlaceID: "ChIJsol", Lat: sector.Lat, Lng: sector.Lng},
+		{Name: "Cafe Luna", Categories: []string{"Cafe"}, CID: "1000000000000000004", PlaceID: "ChIJluna", Lat: sector.Lat, Lng: sector.Lng},
+	}
+	srv := fakemaps.NewServer(fakemaps.Config{Places: places})
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		opts  ReviewOptions
+		pages int64
+		kept  map[string]int
+	}{
+		{"all", ReviewOptions{}, 6, map[string]int{"1000000000000000002": 59, "1000000000000000004": 53}},
+		{"newest 25", ReviewOptions{Limit: 25}, 4, map[string]int{"1000000000000000002": 25, "1000000000000000004": 25}},
+		// A limit the first page fills does not fetch the second
+		{"newest 20", ReviewOptions{Limit: 20}, 2, map[string]int{"1000000000000000002": 20, "1000000000000000004": 20}},
+		{"newest 5", ReviewOptions{Limit: 5}, 2, map[string]int{"1000000000000000002": 5, "1000000000000000004": 5}},
+		// 2026-10-01 back to 2026-02-01 is 242 days: 23 reviews
+		{"since", ReviewOptions{Since: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)}, 4,
+			map[string]int{"1000000000000000002": 23, "1000000000000000004": 23}},
+		{"since and newest", ReviewOptions{Limit: 10, Since: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)}, 2,
+			map[string]int{"1000000000000000002": 3, "1000000000000000004": 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), "reviews.db")
+			store, err := storage.NewStore(dbPath)
+			if err != nil {
+				t.Fatalf("NewStore: %v", err)
+			}
+			defer store.Close()
+
+			params := model.SearchParams{
+				Queries: []string{"cafe"}, Zoom: 13, Concurrency: 2, MaxPages: 1, Lang: "en",
+				Endpoint: srv.URL(), ReviewsEndpoint: srv.ReviewsURL(),
+			}
+			logger := log.New(io.Discard, "", 0)
+			if _, err := Run(context.Background(), []model.Sector{sector}, params, store, logger, &RunOptions{SuppressStderr: true}); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if n, err := store.EnqueueReviewJobs(false); err != nil || n != len(places) {
+				t.Fatalf("EnqueueReviewJobs = %d, %v, want %d", n, err, len(places))
+			}
+
+			opts := tt.opts
+			opts.SuppressStderr = true
+			stats, err := FetchReviews(context.Background(), params, store, logger, &opts)
+			if err != nil {
+				t.Fatalf("FetchReviews: %v", err)
+			}
+			if got := stats.Pages.Load(); got != tt.pages {
+				t.Errorf("fetched %d pages, want %d", got, tt.pages)
+			}
+			if jobs, err := store.UnfinishedReviewJobs(); err != nil || len(jobs) != 0 {
+				t.Errorf("unfinished review jobs = %v, %v, want none", jobs, err)
+			}
+
+			db, err := sql.Open("sqlite", dbPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			total := 0
+			for cid, want := range tt.kept {
+				total += want
+				var n int
+				var oldest string
+				err := db.QueryRow("SELECT COUNT(*), COALESCE(MIN(published), '') FROM reviews WHERE cid = ?", cid).Scan(&n, &oldest)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// The newest ones are kept
+				wantOldest := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -11*(want-1)).Format("2006-01-02")
+				if n != want || oldest != wantOldest {
+					t.Errorf("%s: stored %d reviews back to %s, want %d back to %s", cid, n, oldest, want, wantOldest)
+				}
+			}
+			if got := stats.Reviews.Load(); got != int64(total) {
+				t.Errorf("stats counted %d reviews, want %d", got, total)
+			}
+		})
+	}
+}
@@ -0,0 +1,466 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readTLSRecord reads one TLS record: the 5-byte header and its payload.
+func readTLSRecord(r io.Reader) ([]byte, error) {
+	hdr := make([]byte, 5)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, err
+	}
+	body := make([]byte, binary.BigEndian.Uint16(hdr[3:]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return append(hdr, body...), nil
+}
+
+// listen starts a loopback listener that hands each connection to handle.
+func listen(t *testing.T, handle func(net.Conn)) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return ln
+}
+
+// tlsSink stands in for Google: it reads the ClientHello record of each
+// connection and hangs up.
+func tlsSink(t *testing.T) (net.Listener, chan []byte) {
+	hellos := make(chan []byte, 4)
+	ln := listen(t, func(conn net.Conn) {
+		if rec, err := readTLSRecord(conn); err == nil {
+			hellos <- rec
+		}
+	})
+	return ln, hellos
+}
+
+// relay is a proxy stand-in's view of one tunnel: the target it was asked
+// for and the first TLS record it relayed.
+type relay struct {
+	target string
+	first  []byte
+}
+
+// pipe dials target, forwards the first TLS record read from client and
+// reports it, then copies bytes both ways until either side hangs up.
+func pipe(client net.Conn, r io.Reader, target string, relays chan<- relay, requested string) {
+	upstream, err := net.Dial("tcp", target)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+
+	first, err := readTLSRecord(r)
+	if err != nil {
+		return
+	}
+	relays <- relay{target: requested, first: first}
+	if _, err := upstream.Write(first); err != nil {
+		return
+	}
+	go io.Copy(upstream, r)
+	io.Copy(client, upstream)
+}
+
+// connectProxy is an HTTP CONNECT proxy stand-in that tunnels every request
+// to target. With user set it requires Basic proxy authentication.
+func connectProxy(t *testing.T, target, user, pass string) (net.Listener, chan relay) {
+	relays := make(chan relay, 4)
+	ln := listen(t, func(conn net.Conn) {
+		br := bufio.NewReader(conn)
+		req, err := http.ReadRequest(br)
+		if err != nil || req.Method != http.MethodConnect {
+			fmt.Fprint(conn, "HTTP/1.1 405 Method Not Allowed\r\n\r\n")
+			return
+		}
+		if user != "" {
+			want := "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+			if req.Header.Get("Proxy-Authorization") != want {
+				fmt.Fprint(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+				return
+			}
+		}
+		fmt.Fprint(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		pipe(conn, br, target, relays, req.Host)
+	})
+	return ln, relays
+}
+
+// socks5Proxy is a SOCKS5 proxy stand-in that tunnels every CONNECT to
+// target. With user set it requires username/password authentication.
+func socks5Proxy(t *testing.T, target, user, pass string) (net.Listener, chan relay) {
+	relays := make(chan relay, 4)
+	ln := listen(t, func(conn net.Conn) {
+		// Greeting
+		var head [2]byte
+		if _, err := io.ReadFull(conn, head[:]); err != nil || head[0] != socks5Version {
+			return
+		}
+		methods := make([]byte, head[1])
+		if _, err := io.ReadFull(conn, methods); err != nil {
+			return
+		}
+		method := byte(socks5AuthNone)
+		if user != "" {
+			method = socks5AuthPassword
+		}
+		if !slices.Contains(methods, method) {
+			conn.Write([]byte{socks5Version, socks5AuthNoAccept})
+			return
+		}
+		conn.Write([]byte{socks5Version, method})
+
+		if user != "" {
+			br := bufio.NewReader(conn)
+			var hdr [2]byte
+			if _, err := io.ReadFull(br, hdr[:]); err != nil {
+				return
+			}
+			u := make([]byte, hdr[1])
+			io.ReadFull(br, u)
+			n, _ := br.ReadByte()
+			p := make([]byte, n)
+			io.ReadFull(br, p)
+			if string(u) != user || string(p) != pass {
+				conn.Write([]byte{0x01, 0x01})
+				return
+			}
+			conn.Write([]byte{0x01, 0x00})
+		}
+
+		// CONNECT request
+		var req [4]byte
+		if _, err := io.ReadFull(conn, req[:]); err != nil || req[1] != socks5CmdConnect {
+			return
+		}
+		var host string
+		switch req[3] {
+		case socks5AddrIPv4:
+			ip := make([]byte, net.IPv4len)
+			io.ReadFull(conn, ip)
+			host = net.IP(ip).String()
+		case socks5AddrIPv6:
+			ip := make([]byte, net.IPv6len)
+			io.ReadFull(conn, ip)
+			host = net.IP(ip).String()
+		case socks5AddrDomain:
+			var n [1]byte
+			io.ReadFull(conn, n[:])
+			name := make([]byte, n[0])
+			io.ReadFull(conn, name)
+			host = string(name)
+		}
+		var port [2]byte
+		if _, err := io.ReadFull(conn, port[:]); err != nil {
+			return
+		}
+		conn.Write([]byte{socks5Version, 0x00, 0x00, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+
+		requested := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:]))))
+		pipe(conn, conn, target, relays, requested)
+	})
+	return ln, relays
+}
+
+// clientHello is the part of a ClientHello that identifies the client:
+// everything but the per-connection randomness. GREASE values are folded
+// into one, and extensions are sorted since Chrome shuffles their order.
+type clientHello struct {
+	Version    uint16
+	Ciphers    []uint16
+	Extensions []uint16
+	SNI        string
+	ALPN       []string
+	Groups     []uint16
+	SigAlgs    []uint16
+	Versions   []uint16
+}
+
+// isGREASE reports whether v is one of the reserved GREASE values
+// (RFC 8701), which Chrome picks at random per connection.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+func ungrease(v uint16) uint16 {
+	if isGREASE(v) {
+		return 0x0a0a
+	}
+	return v
+}
+
+// cursor reads big-endian fields off a byte slice, failing softly.
+type cursor struct {
+	b   []byte
+	err error
+}
+
+func (c *cursor) next(n int) []byte {
+	if c.err != nil || len(c.b) < n {
+		c.err = errors.New("short ClientHello")
+		return make([]byte, n)
+	}
+	out := c.b[:n]
+	c.b = c.b[n:]
+	return out
+}
+
+func (c *cursor) u8() int      { return int(c.next(1)[0]) }
+func (c *cursor) u16() uint16  { return binary.BigEndian.Uint16(c.next(2)) }
+func (c *cursor) vec8() []byte { return c.next(c.u8()) }
+func (c *cursor) vec16() []byte {
+	return c.next(int(c.u16()))
+}
+
+func u16s(b []byte) []uint16 {
+	out := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		out = append(out, ungrease(binary.BigEndian.Uint16(b[i:])))
+	}
+	return out
+}
+
+// parseClientHello reads the identifying fields of a ClientHello record.
+func parseClientHello(rec []byte) (clientHello, error) {
+	var h clientHello
+	c := &cursor{b: rec}
+	if typ := c.u8(); typ != 0x16 {
+		return h, fmt.Errorf("record type %d, want handshake", typ)
+	}
+	c.next(2) // record version
+	c = &cursor{b: c.vec16()}
+	if typ := c.u8(); typ != 0x01 {
+		return h, fmt.Errorf("handshake type %d, want ClientHello", typ)
+	}
+	c.next(3) // handshake length
+	h.Version = c.u16()
+	c.next(32) // random
+	c.vec8()   // session ID
+	h.Ciphers = u16s(c.vec16())
+	c.vec8() // compression methods
+
+	exts := &cursor{b: c.vec16()}
+	for exts.err == nil && len(exts.b) > 0 {
+		typ := ungrease(exts.u16())
+		data := &cursor{b: exts.vec16()}
+		h.Extensions = append(h.Extensions, typ)
+		switch typ {
+		case 0: // server_name
+			list := &cursor{b: data.vec16()}
+			list.next(1) // name type
+			h.SNI = string(list.vec16())
+		case 10: // supported_groups
+			h.Groups = u16s(data.vec16())
+		case 13: // signature_algorithms
+			h.SigAlgs = u16s(data.vec16())
+		case 16: // application_layer_protocol_negotiation
+			list := &cursor{b: data.vec16()}
+			for list.err == nil && len(list.b) > 0 {
+				h.ALPN = append(h.ALPN, string(list.vec8()))
+			}
+		case 43: // supported_versions
+			h.Versions = u16s(data.vec8())
+		}
+	}
+	slices.Sort(h.Extensions)
+	if c.err != nil {
+		return h, c.err
+	}
+	return h, exts.err
+}
+
+// dialHello runs the client's TLS dial to addr, which ends when the sink
+// hangs up after the ClientHello.
+func dialHello(t *testing.T, proxyURL, addr string) error {
+	t.Helper()
+	c := NewClient("en", proxyURL, 13)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := c.http.Transport.(*http.Transport).DialTLSContext(ctx, "tcp", addr)
+	if err == nil {
+		conn.Close()
+		return errors.New("handshake with the sink succeeded")
+	}
+	return err
+}
+
+func receive[T any](t *testing.T, ch <-chan T, what string) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no %s received", what)
+	}
+	var zero T
+	return zero
+}
+
+func TestTunnelKeepsClientHello(t *testing.T) {
+	sink, hellos := tlsSink(t)
+	_, port, _ := net.SplitHostPort(sink.Addr().String())
+	// A host name, so the ClientHello carries SNI; the proxies tunnel to
+	// the sink whatever they are asked for
+	addr := net.JoinHostPort("localhost", port)
+
+	dialHello(t, "", addr)
+	direct, err := parseClientHello(receive(t, hellos, "direct ClientHello"))
+	if err != nil {
+		t.Fatalf("parsing direct ClientHello: %v", err)
+	}
+	if direct.SNI != "localhost" || !reflect.DeepEqual(direct.ALPN, []string{"http/1.1"}) || len(direct.Ciphers) < 10 {
+		t.Fatalf("direct ClientHello is not Chrome's: %+v", direct)
+	}
+
+	tests := []struct {
+		name       string
+		scheme     string
+		user, pass string
+		wantTarget string
+	}{
+		{"connect", "http", "", "", addr},
+		{"connect auth", "http", "alice", "s3cr3t:pw", addr},
+		{"socks5", "socks5h", "", "", addr},
+		{"socks5 auth", "socks5h", "alice", "s3cr3t", addr},
+		{"socks5 local dns", "socks5", "", "", net.JoinHostPort("127.0.0.1", port)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var proxy net.Listener
+			var relays chan relay
+			if tt.scheme == "http" {
+				proxy, relays = connectProxy(t, sink.Addr().String(), tt.user, tt.pass)
+			} else {
+				proxy, relays = socks5Proxy(t, sink.Addr().String(), tt.user, tt.pass)
+			}
+			proxyURL := tt.scheme + "://" + proxy.Addr().String()
+			if tt.user != "" {
+				proxyURL = tt.scheme + "://" + tt.user + ":" + strings.ReplaceAll(tt.pass, ":", "%3A") + "@" + proxy.Addr().String()
+			}
+
+			err := dialHello(t, proxyURL, addr)
+			var r relay
+			select {
+			case r = <-relays:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("proxy relayed nothing (dial error: %v)", err)
+			}
+			received := receive(t, hellos, "proxied ClientHello")
+
+			if r.target != tt.wantTarget {
+				t.Errorf("proxy asked for %s, want %s", r.target, tt.wantTarget)
+			}
+			if !bytes.Equal(r.first, received) {
+				t.Error("the sink received other bytes than the proxy relayed")
+			}
+			got, err := parseClientHello(r.first)
+			if err != nil {
+				t.Fatalf("parsing relayed ClientHello: %v", err)
+			}
+			if !reflect.DeepEqual(got, direct) {
+				t.Errorf("relayed ClientHello differs from a direct one:\n got %+v\nwant %+v", got, direct)
+			}
+		})
+	}
+}
+
+func TestTunnelAuthFailure(t *testing.T) {
+	sink, _ := tlsSink(t)
+	connect, _ := connectProxy(t, sink.Addr().String(), "alice", "s3cr3t")
+	socks, _ := socks5Proxy(t, sink.Addr().String(), "alice", "s3cr3t")
+
+	tests := []struct {
+		name     string
+		proxyURL string
+		wantErr  string
+	}{
+		{"connect wrong password", "http://alice:nope@" + connect.Addr().String(), "407"},
+		{"connect no credentials", "http://" + connect.Addr().String(), "407"},
+		{"socks5 wrong password", "socks5h://alice:nope@" + socks.Addr().String(), "authentication failed"},
+		{"socks5 no credentials", "socks5h://" + socks.Addr().String(), "no acceptable authentication"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dialHello(t, tt.proxyURL, sink.Addr().String())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlainHTTPThroughProxy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+	target := srv.Listener.Addr().String()
+
+	// A CONNECT proxy that reports each tunnel and relays it as is
+	tunnels := make(chan string, 4)
+	proxy := listen(t, func(conn net.Conn) {
+		br := bufio.NewReader(conn)
+		req, err := http.ReadRequest(br)
+		if err != nil || req.Method != http.MethodConnect {
+			fmt.Fprint(conn, "HTTP/1.1 405 Method Not Allowed\r\n\r\n")
+			return
+		}
+		upstream, err := net.Dial("tcp", target)
+		if err != nil {
+			return
+		}
+		defer upstream.Close()
+		tunnels <- req.Host
+		fmt.Fprint(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go io.Copy(upstream, br)
+		io.Copy(conn, upstream)
+	})
+
+	c := NewClient("en", "http://"+proxy.Addr().String(), 14)
+	resp, err := c.http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET through the proxy: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Errorf("body = %q, want the server's", body)
+	}
+	if got := receive(t, tunnels, "tunnel"); got != target {
+		t.Errorf("proxy asked for %s, want %s", got, target)
+	}
+}
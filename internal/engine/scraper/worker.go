@@ -11,22 +11,62 @@ import (
 
 	"github.com/paulmach/orb"
 	"github.com/paulmach/orb/planar"
+	"github.com/rendis/geotap/internal/engine/geo"
+	"github.com/rendis/geotap/internal/engine/phone"
 	"github.com/rendis/geotap/internal/engine/storage"
 	"github.com/rendis/geotap/internal/model"
 )
 
+// defaultMaxZoom bounds adaptive subdivision when SearchParams.MaxZoom is unset.
+const defaultMaxZoom = 16
+
 type Stats struct {
-	SectorsTotal     int
+	SectorsTotal     atomic.Int64 // grows when adaptive mode subdivides sectors
 	SectorsDone      atomic.Int64
+	Subdivided       atomic.Int64 // saturated sectors split into children
 	BusinessesFound  atomic.Int64
 	BusinessesStored atomic.Int64
 	Errors           atomic.Int64
 	RateLimits       atomic.Int64
+
+	proxies  atomic.Pointer[[]*ProxyStats]
+	coverage Coverage
+}
+
+// Coverage returns the per-field fill rates of the businesses parsed so far.
+func (s *Stats) Coverage() *Coverage {
+	return &s.coverage
+}
+
+// Snapshot returns the current counters for a scan session record. It is
+// safe on a nil Stats, as returned by a run that failed to start.
+func (s *Stats) Snapshot() storage.SessionStats {
+	if s == nil {
+		return storage.SessionStats{}
+	}
+	return storage.SessionStats{
+		SectorsTotal: s.SectorsTotal.Load(),
+		SectorsDone:  s.SectorsDone.Load(),
+		Subdivided:   s.Subdivided.Load(),
+		Found:        s.BusinessesFound.Load(),
+		Stored:       s.BusinessesStored.Load(),
+		Errors:       s.Errors.Load(),
+		RateLimits:   s.RateLimits.Load(),
+	}
+}
+
+// Proxies returns the per-proxy counters of a run using a proxy pool, or nil.
+func (s *Stats) Proxies() []*ProxyStats {
+	if p := s.proxies.Load(); p != nil {
+		return *p
+	}
+	return nil
 }
 
 type Job struct {
 	Sector model.Sector
 	Query  string
+	Page   int // first page to fetch (non-zero when resuming a partially paginated job)
 }
 
 // RunOptions provides optional callbacks for the scraping pipeline.
@@ -43,40 +83,119 @@ type RunOptions struct {
 	GeoFilter orb.MultiPolygon
 }
 
+// runner holds the state shared by all workers of a scraping run.
+type runner struct {
+	client   *Client
+	pool     *proxyPool // nil unless params.Proxies is set
+	store    *storage.Store
+	params   model.SearchParams
+	stats    *Stats
+	logger   *log.Logger
+	opts     *RunOptions
+	queue    *jobQueue
+	recorder *Recorder // nil unless params.RecordDir is set
+
+	// phoneRegion is the scanned country (ISO 3166-1 alpha-2) phones of
+	// places without a country code are read in, "" outside country mode
+	phoneRegion string
+
+	// Adaptive delay: increases when rate limited
+	delayMu sync.RWMutex
+	delay   time.Duration
+
+	// consecutiveRL tracks consecutive rate limits to detect persistent blocking
+	consecutiveRL atomic.Int64
+}
+
 // Run executes the scraping pipeline: for each sector*query, fetch and parse results.
+// Every job is recorded in the store's ledger so an interrupted run can be resumed.
 func Run(ctx context.Context, sectors []model.Sector, params model.SearchParams, store *storage.Store, logger *log.Logger, opts *RunOptions) (*Stats, error) {
+	if err := store.SaveParams(params); err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(sectors)*len(params.Queries))
+	records := make([]storage.JobRecord, 0, cap(jobs))
+	for _, q := range params.Queries {
+		for _, s := range sectors {
+			jobs = append(jobs, Job{Sector: s, Query: q})
+			records = append(records, storage.JobRecord{Sector: s, Query: q})
+		}
+	}
+	if err := store.EnqueueJobs(records); err != nil {
+		return nil, err
+	}
+
+	return runJobs(ctx, jobs, params, store, logger, opts)
+}
+
+// Resume re-runs the pending and failed jobs recorded in the store's ledger
+// using the search parameters saved by the original Run.
+func Resume(ctx context.Context, params model.SearchParams, store *storage.Store, logger *log.Logger, opts *RunOptions) (*Stats, error) {
+	records, err := store.UnfinishedJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, len(records))
+	for i, r := range records {
+		jobs[i] = Job{Sector: r.Sector, Query: r.Query, Page: r.Page}
+	}
+	logger.Printf("RESUME %d unfinished jobs", len(jobs))
+
+	return runJobs(ctx, jobs, params, store, logger, opts)
+}
+
+func runJobs(ctx context.Context, jobList []Job, params model.SearchParams, store *storage.Store, logger *log.Logger, opts *RunOptions) (*Stats, error) {
 	if opts == nil {
 		opts = &RunOptions{}
 	}
 
-	var stats *Stats
-	if opts.Stats != nil {
-		stats = opts.Stats
-		stats.SectorsTotal = len(sectors) * len(params.Queries)
-	} else {
-		stats = &Stats{SectorsTotal: len(sectors) * len(params.Queries)}
+	stats := opts.Stats
+	if stats == nil {
+		stats = &Stats{}
+	}
+	stats.SectorsTotal.Store(int64(len(jobList)))
+
+	r := newRunner(params, store, logger)
+	r.stats = stats
+	r.opts = opts
+	r.phoneRegion = geo.CountryISO2(params.Country)
+	r.loadCoverageBaseline()
+	if r.pool != nil {
+		ps := r.pool.stats()
+		stats.proxies.Store(&ps)
 	}
-	client := NewClient(params.Lang, params.ProxyURL, params.Zoom)
 
-	jobs := make(chan Job, stats.SectorsTotal)
-	for _, q := range params.Queries {
-		for _, s := range sectors {
-			jobs <- Job{Sector: s, Query: q}
+	if params.RecordDir != "" {
+		rec, err := NewRecorder(params.RecordDir, params)
+		if err != nil {
+			return stats, err
 		}
+		r.recorder = rec
+		defer func() {
+			if err := rec.Close(); err != nil {
+				logger.Printf("RECORD close err=%v", err)
+			}
+		}()
 	}
-	close(jobs)
+
+	r.queue = newJobQueue(jobList)
 
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, params.Concurrency)
 
-	// Adaptive delay: increases when rate limited
-	var delayMu sync.RWMutex
-	delay := time.Duration(0)
-
 	startTime := time.Now()
 
 	// Progress reporter
 	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.queue.close()
+		case <-done:
+		}
+	}()
 	if !opts.SuppressStderr {
 		go func() {
 			ticker := time.NewTicker(2 * time.Second)
@@ -90,19 +209,19 @@ func Run(ctx context.Context, sectors []model.Sector, params model.SearchParams,
 					rl := stats.RateLimits.Load()
 					if rl > 0 {
 						fmt.Fprintf(os.Stderr, "\r[%d/%d sectors] %d businesses | %d stored | %d errors | %d rate-limited | %s",
-							stats.SectorsDone.Load(), stats.SectorsTotal,
+							stats.SectorsDone.Load(), stats.SectorsTotal.Load(),
 							stats.BusinessesFound.Load(), stats.BusinessesStored.Load(),
 							stats.Errors.Load(), rl, elapsed)
 					} else {
 						fmt.Fprintf(os.Stderr, "\r[%d/%d sectors] %d businesses | %d stored | %d errors | %s",
-							stats.SectorsDone.Load(), stats.SectorsTotal,
+							stats.SectorsDone.Load(), stats.SectorsTotal.Load(),
 							stats.BusinessesFound.Load(), stats.BusinessesStored.Load(),
 							stats.Errors.Load(), elapsed)
 					}
 				case <-logTicker.C:
 					elapsed := time.Since(startTime).Truncate(time.Second)
 					logger.Printf("PROGRESS sectors=%d/%d found=%d stored=%d errors=%d rate_limits=%d elapsed=%s",
-						stats.SectorsDone.Load(), stats.SectorsTotal,
+						stats.SectorsDone.Load(), stats.SectorsTotal.Load(),
 						stats.BusinessesFound.Load(), stats.BusinessesStored.Load(),
 						stats.Errors.Load(), stats.RateLimits.Load(), elapsed)
 				case <-done:
@@ -119,7 +238,7 @@ func Run(ctx context.Context, sectors []model.Sector, params model.SearchParams,
 				case <-logTicker.C:
 					elapsed := time.Since(startTime).Truncate(time.Second)
 					logger.Printf("PROGRESS sectors=%d/%d found=%d stored=%d errors=%d rate_limits=%d elapsed=%s",
-						stats.SectorsDone.Load(), stats.SectorsTotal,
+						stats.SectorsDone.Load(), stats.SectorsTotal.Load(),
 						stats.BusinessesFound.Load(), stats.BusinessesStored.Load(),
 						stats.Errors.Load(), stats.RateLimits.Load(), elapsed)
 				case <-done:
@@ -129,19 +248,16 @@ func Run(ctx context.Context, sectors []model.Sector, params model.SearchParams,
 		}()
 	}
 
-	// consecutiveRL tracks consecutive rate limits to detect persistent blocking
-	var consecutiveRL atomic.Int64
-
-	for job := range jobs {
-		select {
-		case <-ctx.Done():
-			close(done)
-			return stats, ctx.Err()
-		default:
+	for {
+		job, ok := r.queue.pop()
+		if !ok {
+			break
 		}
 
-		// Early abort: if we've been rate limited 50+ times in a row, Google has blocked us
-		if consecutiveRL.Load() > 50 {
+		// Early abort: if we've been rate limited 50+ times in a row, Google has blocked us.
+		// Remaining jobs stay pending in the ledger and can be resumed later.
+		if r.consecutiveRL.Load() > 50 {
+			r.queue.done()
 			logger.Printf("ABORT: persistent rate limiting (50+ consecutive), stopping")
 			if !opts.SuppressStderr {
 				fmt.Fprintf(os.Stderr, "\n[!] Persistent rate limiting detected — aborting. Try again later or reduce concurrency/zoom.\n")
@@ -154,119 +270,315 @@ func Run(ctx context.Context, sectors []model.Sector, params model.SearchParams,
 		go func(j Job) {
 			defer wg.Done()
 			defer func() { <-sem }()
+			defer r.queue.done()
 
 			// Apply adaptive delay
-			delayMu.RLock()
-			d := delay
-			delayMu.RUnlock()
-			if d > 0 {
-				time.Sleep(d)
+			r.delayMu.RLock()
+			d := r.delay
+			r.delayMu.RUnlock()
+			if d > 0 && sleepCtx(ctx, d) != nil {
+				// Cancelled before the job started: it stays pending
+				return
 			}
 
-			processJob(ctx, client, store, j, params, stats, logger, opts, func(rateLimited bool) {
-				if rateLimited {
-					consecutiveRL.Add(1)
-					delayMu.Lock()
-					if delay < 5*time.Second {
-						delay += 500 * time.Millisecond
-					}
-					delayMu.Unlock()
-				} else {
-					consecutiveRL.Store(0)
-					delayMu.Lock()
-					if delay > 0 {
-						delay -= 100 * time.Millisecond
-						if delay < 0 {
-							delay = 0
-						}
-					}
-					delayMu.Unlock()
-				}
-			})
+			r.processJob(ctx, j)
 		}(job)
 	}
 
 	wg.Wait()
 	close(done)
+	r.finishCoverage()
 
 	// Final progress line
 	if !opts.SuppressStderr {
 		elapsed := time.Since(startTime).Truncate(time.Second)
 		fmt.Fprintf(os.Stderr, "\r[%d/%d sectors] %d businesses | %d stored | %d errors | %s\n",
-			stats.SectorsDone.Load(), stats.SectorsTotal,
+			stats.SectorsDone.Load(), stats.SectorsTotal.Load(),
 			stats.BusinessesFound.Load(), stats.BusinessesStored.Load(),
 			stats.Errors.Load(), elapsed)
 	}
 
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
 	return stats, nil
 }
 
-func processJob(ctx context.Context, client *Client, store *storage.Store, job Job, params model.SearchParams, stats *Stats, logger *log.Logger, opts *RunOptions, adjustDelay func(rateLimited bool)) {
-	defer stats.SectorsDone.Add(1)
+// newRunner sets up the client, and the proxy pool when params has one,
+// shared by the scan and enrichment workers.
+func newRunner(params model.SearchParams, store *storage.Store, logger *log.Logger) *runner {
+	r := &runner{
+		client: newClient(params, params.ProxyURL),
+		store:  store,
+		params: params,
+		logger: logger,
+	}
+	if len(params.Proxies) > 0 {
+		r.pool = newProxyPool(params)
+		logger.Printf("PROXIES %d in pool", len(r.pool.proxies))
+	}
+	return r
+}
+
+// newClient creates a client for params going through proxyURL, pointed at
+// the endpoint overrides of params.
+func newClient(params model.SearchParams, proxyURL string) *Client {
+	c := NewClient(params.Lang, proxyURL, params.Zoom)
+	if params.Endpoint != "" {
+		c.SetBaseURL(params.Endpoint)
+	}
+	if params.DetailsEndpoint != "" {
+		c.SetDetailsURL(params.DetailsEndpoint)
+	}
+	if params.ReviewsEndpoint != "" {
+		c.SetReviewsURL(params.ReviewsEndpoint)
+	}
+	return c
+}
 
-	maxPages := params.MaxPages
+func (r *runner) adjustDelay(rateLimited bool) {
+	r.delayMu.Lock()
+	defer r.delayMu.Unlock()
+	if rateLimited {
+		r.consecutiveRL.Add(1)
+		if r.delay < 5*time.Second {
+			r.delay += 500 * time.Millisecond
+		}
+		return
+	}
+	r.consecutiveRL.Store(0)
+	if r.delay > 0 {
+		r.delay -= 100 * time.Millisecond
+		if r.delay < 0 {
+			r.delay = 0
+		}
+	}
+}
+
+// recordJob updates the job ledger, logging (but not failing on) storage errors.
+func (r *runner) recordJob(job Job, page int, status string, jobErr error) {
+	var msg string
+	if jobErr != nil {
+		msg = jobErr.Error()
+	}
+	if err := r.store.UpdateJob(job.Sector, job.Query, page, status, msg); err != nil {
+		r.logger.Printf("LEDGER sector=%d,%d query=%q err=%v", job.Sector.Row, job.Sector.Col, job.Query, err)
+	}
+}
+
+// canSubdivide reports whether adaptive mode may split the sector further.
+func (r *runner) canSubdivide(s model.Sector) bool {
+	if !r.params.Adaptive {
+		return false
+	}
+	maxZoom := r.params.MaxZoom
+	if maxZoom <= 0 {
+		maxZoom = defaultMaxZoom
+	}
+	return s.Zoom > 0 && s.Zoom < maxZoom
+}
+
+// subdivide enqueues the four child sectors of a saturated job.
+func (r *runner) subdivide(job Job) {
+	children := geo.SplitSector(job.Sector)
+	jobs := make([]Job, len(children))
+	records := make([]storage.JobRecord, len(children))
+	for i, c := range children {
+		jobs[i] = Job{Sector: c, Query: job.Query}
+		records[i] = storage.JobRecord{Sector: c, Query: job.Query}
+	}
+	if err := r.store.EnqueueJobs(records); err != nil {
+		r.logger.Printf("LEDGER subdivide sector=%d,%d err=%v", job.Sector.Row, job.Sector.Col, err)
+	}
+
+	r.stats.Subdivided.Add(1)
+	r.stats.SectorsTotal.Add(int64(r.queue.push(jobs...)))
+	r.logger.Printf("SUBDIVIDE sector=%d,%d zoom=%d query=%q -> %d children",
+		job.Sector.Row, job.Sector.Col, job.Sector.Zoom, job.Query, len(children))
+}
+
+// search fetches one results page.
+func (r *runner) search(ctx context.Context, job Job, offset int) ([]byte, error) {
+	return r.fetch(ctx, func(c *Client) ([]byte, error) {
+		return c.SearchMap(ctx, job.Sector, job.Query, offset)
+	})
+}
+
+// fetch runs one request, rotating across the proxy pool when one is
+// configured: a failed request is retried on the next healthy proxy, and a
+// rate-limited proxy is benched.
+func (r *runner) fetch(ctx context.Context, do func(*Client) ([]byte, error)) ([]byte, error) {
+	if r.pool == nil {
+		return do(r.client)
+	}
+
+	var lastErr error
+	for range maxRetries {
+		px, err := r.pool.acquire(ctx)
+		if err != nil {
+			return nil, err
+		}
+		body, err := do(px.client)
+		if ctx.Err() != nil {
+			// Cancellation is not the proxy's fault
+			return nil, ctx.Err()
+		}
+		if bench := px.record(err); bench > 0 {
+			r.logger.Printf("PROXY_BENCH proxy=%s for=%s", px.stats.URL, bench)
+		}
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (r *runner) processJob(ctx context.Context, job Job) {
+	defer r.stats.SectorsDone.Add(1)
+
+	maxPages := r.params.MaxPages
 	if maxPages <= 0 {
 		maxPages = 1
 	}
 
-	for page := 0; page < maxPages; page++ {
+	for page := job.Page; page < maxPages; page++ {
 		select {
 		case <-ctx.Done():
+			// Leave the job pending so it is picked up on resume
 			return
 		default:
 		}
 
 		offset := page * pageSize
-		body, err := client.SearchMap(job.Sector, job.Query, offset)
+		body, err := r.search(ctx, job, offset)
 		if err != nil {
+			if ctx.Err() != nil {
+				r.logger.Printf("INTERRUPTED sector=%d,%d page=%d query=%q", job.Sector.Row, job.Sector.Col, page, job.Query)
+				r.recordJob(job, page, storage.JobInterrupted, nil)
+				return
+			}
 			if rl, ok := err.(*RateLimitError); ok {
-				stats.RateLimits.Add(1)
-				adjustDelay(true)
-				logger.Printf("RATE_LIMIT sector=%d,%d status=%d query=%q", job.Sector.Row, job.Sector.Col, rl.StatusCode, job.Query)
+				r.stats.RateLimits.Add(1)
+				r.adjustDelay(true)
+				r.logger.Printf("RATE_LIMIT sector=%d,%d status=%d query=%q", job.Sector.Row, job.Sector.Col, rl.StatusCode, job.Query)
 			} else {
-				logger.Printf("ERROR sector=%d,%d page=%d err=%v", job.Sector.Row, job.Sector.Col, page, err)
+				r.logger.Printf("ERROR sector=%d,%d page=%d err=%v", job.Sector.Row, job.Sector.Col, page, err)
 			}
-			stats.Errors.Add(1)
+			r.stats.Errors.Add(1)
+			r.recordJob(job, page, storage.JobFailed, err)
 			return
 		}
 
-		adjustDelay(false)
+		r.adjustDelay(false)
 
-		if params.Debug {
+		if r.params.Debug {
 			debugFile := fmt.Sprintf("debug_sector_%d_%d_page_%d.json", job.Sector.Row, job.Sector.Col, page)
 			os.WriteFile(debugFile, body, 0644)
 		}
+		if r.recorder != nil {
+			if err := r.recorder.Record(r.client.searchURL(job.Sector, job.Query, offset), job, page, body); err != nil {
+				r.logger.Printf("RECORD sector=%d,%d page=%d err=%v", job.Sector.Row, job.Sector.Col, page, err)
+			}
+		}
 
-		businesses, hasMore := ParseMapResponse(body, job.Query)
-		stats.BusinessesFound.Add(int64(len(businesses)))
-
-		// Apply rating filter
-		if params.MinRating > 0 || params.MaxRating > 0 {
-			businesses = filterByRating(businesses, params.MinRating, params.MaxRating)
+		hasMore, err := r.storePage(body, job.Query, storage.Origin{Sector: job.Sector, Page: page})
+		if err != nil {
+			r.stats.Errors.Add(1)
+			r.recordJob(job, page, storage.JobFailed, err)
+			return
 		}
 
-		// Apply geographic filter
-		if len(opts.GeoFilter) > 0 {
-			businesses = filterByGeo(businesses, opts.GeoFilter)
+		// A full first page means the sector is saturated: in adaptive mode,
+		// cover it with four children at the next zoom level instead of paginating.
+		if page == 0 && hasMore && r.canSubdivide(job.Sector) {
+			r.subdivide(job)
+			break
 		}
 
-		// Notify callback with filtered results
-		if opts.OnBusinesses != nil && len(businesses) > 0 {
-			opts.OnBusinesses(businesses)
+		if !hasMore || page == maxPages-1 {
+			break
 		}
+		// Checkpoint pagination so a resume continues from the next page
+		r.recordJob(job, page+1, storage.JobPending, nil)
+	}
 
-		if len(businesses) > 0 {
-			inserted, err := store.InsertBatch(businesses)
-			if err != nil {
-				stats.Errors.Add(1)
-			} else {
-				stats.BusinessesStored.Add(int64(inserted))
+	r.recordJob(job, maxPages, storage.JobDone, nil)
+}
+
+// storePage parses a raw results page found at origin and runs it through
+// the rating and geographic filters into the store. It reports whether the
+// page was full.
+func (r *runner) storePage(body []byte, query string, origin storage.Origin) (bool, error) {
+	businesses, hasMore := ParseMapResponse(body, query)
+	r.stats.BusinessesFound.Add(int64(len(businesses)))
+	r.logDrift(r.stats.coverage.Observe(businesses))
+
+	// Phones of places without a country code are read in the scan's country
+	if r.phoneRegion != "" {
+		for i := range businesses {
+			if b := &businesses[i]; b.Phone != "" && !phone.Known(b.CountryCode) {
+				b.PhoneNumber = phone.ForPlace(b.Phone, b.CountryCode, r.phoneRegion)
 			}
 		}
+	}
 
-		if !hasMore {
-			break
+	// Apply rating filter
+	if r.params.MinRating > 0 || r.params.MaxRating > 0 {
+		businesses = filterByRating(businesses, r.params.MinRating, r.params.MaxRating)
+	}
+
+	// Apply geographic filter
+	if len(r.opts.GeoFilter) > 0 {
+		businesses = filterByGeo(businesses, r.opts.GeoFilter)
+	}
+
+	// Notify callback with filtered results
+	if r.opts.OnBusinesses != nil && len(businesses) > 0 {
+		r.opts.OnBusinesses(businesses)
+	}
+
+	if len(businesses) > 0 {
+		inserted, err := r.store.InsertBatch(businesses, origin)
+		if err != nil {
+			return hasMore, err
 		}
+		r.stats.BusinessesStored.Add(int64(inserted))
+	}
+	return hasMore, nil
+}
+
+// loadCoverageBaseline compares the run's field coverage with the project's
+// earlier scans of the same queries.
+func (r *runner) loadCoverageBaseline() {
+	baseline, err := r.store.CoverageBaseline(r.params.Queries)
+	if err != nil {
+		r.logger.Printf("COVERAGE baseline err=%v", err)
+		return
+	}
+	r.stats.coverage.SetBaseline(baseline)
+}
+
+// finishCoverage checks the last partial window for drift, then writes the
+// field coverage report into the log and the store.
+func (r *runner) finishCoverage() {
+	r.logDrift(r.stats.coverage.Flush())
+
+	report := r.stats.coverage.Report()
+	if len(report) == 0 || report[0].Total == 0 {
+		return
+	}
+	for _, f := range report {
+		r.logger.Printf("COVERAGE field=%s filled=%d/%d rate=%.1f%%", f.Field, f.Filled, f.Total, 100*f.Rate())
+	}
+	if err := r.store.AddCoverage(report); err != nil {
+		r.logger.Printf("COVERAGE save err=%v", err)
+	}
+}
+
+func (r *runner) logDrift(alerts []DriftAlert) {
+	for _, a := range alerts {
+		r.logger.Printf("DRIFT field=%s rate=%.1f%% baseline=%.1f%% window=%d", a.Field, 100*a.Rate, 100*a.Baseline, a.Samples)
 	}
 }
 
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// ContactJob is a place queued for the website contacts crawl.
+type ContactJob struct {
+	CID       string
+	Website   string
+	Status    string // one of the Job* ledger statuses
+	Attempts  int
+	LastError string
+}
+
+func createContactsSchema(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS contact_jobs (
+		cid TEXT PRIMARY KEY,
+		website TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		pages INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_contact_jobs_status ON contact_jobs(status);
+
+	CREATE TABLE IF NOT EXISTS contacts (
+		cid TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		value TEXT NOT NULL,
+		network TEXT,
+		source_url TEXT,
+		found_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (cid, kind, value)
+	);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("creating contacts schema: %w", err)
+	}
+	return nil
+}
+
+// EnqueueContactJobs adds every stored place with a website to the contacts
+// ledger as pending and returns the number of places added. With refresh,
+// places already done are queued again.
+func (s *Store) EnqueueContactJobs(refresh bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		INSERT OR IGNORE INTO contact_jobs (cid, website, status)
+		SELECT cid, website, ? FROM places
+		WHERE website IS NOT NULL AND website != ''`, JobPending)
+	if err != nil {
+		return 0, fmt.Errorf("enqueueing contact jobs: %w", err)
+	}
+	n, _ := res.RowsAffected()
+
+	if refresh {
+		_, err := s.db.Exec(`
+			UPDATE contact_jobs SET status = ?, attempts = 0, last_error = NULL, updated_at = CURRENT_TIMESTAMP
+			WHERE status = ?`, JobPending, JobDone)
+		if err != nil {
+			return int(n), fmt.Errorf("requeueing contact jobs: %w", err)
+		}
+	}
+	return int(n), nil
+}
+
+// UnfinishedContactJobs returns every contacts job not yet done.
+func (s *Store) UnfinishedContactJobs() ([]ContactJob, error) {
+	rows, err := s.db.Query(`
+		SELECT cid, website, status, attempts, COALESCE(last_error, '')
+		FROM contact_jobs WHERE status != ? ORDER BY rowid`, JobDone)
+	if err != nil {
+		return nil, fmt.Errorf("querying contact jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []ContactJob
+	for rows.Next() {
+		var j ContactJob
+		if err := rows.Scan(&j.CID, &j.Website, &j.Status, &j.Attempts, &j.LastError); err != nil {
+			return nil, fmt.Errorf("scanning contact job: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// UpdateContactJob stores the outcome of a website crawl and the number of
+// pages fetched. Failed attempts increment the attempt counter.
+func (s *Store) UpdateContactJob(cid, status string, pages int, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt := 0
+	if status == JobFailed {
+		attempt = 1
+	}
+	_, err := s.db.Exec(`
+		UPDATE contact_jobs SET status = ?, attempts = attempts + ?, pages = ?, last_error = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE cid = ?`, status, attempt, pages, lastErr, cid)
+	if err != nil {
+		return fmt.Errorf("updating contact job: %w", err)
+	}
+	return nil
+}
+
+// ContactCounts returns the number of contacts jobs per status.
+func (s *Store) ContactCounts() (map[string]int, error) {
+	rows, err := s.db.Query("SELECT status, COUNT(*) FROM contact_jobs GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("counting contact jobs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// InsertContacts stores contacts, skipping ones already stored for the
+// place, and returns how many were new.
+func (s *Store) InsertContacts(contacts []model.Contact) (int, error) {
+	if len(contacts) == 0 {
+		return 0, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning tx: %w", err)
+	}
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO contacts (cid, kind, value, network, source_url)
+		VALUES (?,?,?,?,?)`)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("preparing stmt: %w", err)
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, c := range contacts {
+		res, err := stmt.Exec(c.CID, c.Kind, c.Value, c.Network, c.Source)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("saving contact: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		inserted += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing tx: %w", err)
+	}
+	return inserted, nil
+}
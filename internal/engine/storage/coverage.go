@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FieldCoverage is how many of the parsed businesses had a field filled.
+type FieldCoverage struct {
+	Field  string
+	Filled int
+	Total  int
+	Alert  string // drift warning raised for the field, if any
+}
+
+// Rate returns the fill rate, or 0 when nothing was parsed.
+func (f FieldCoverage) Rate() float64 {
+	if f.Total == 0 {
+		return 0
+	}
+	return float64(f.Filled) / float64(f.Total)
+}
+
+func createCoverageSchema(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS field_coverage (
+		field TEXT PRIMARY KEY,
+		filled INTEGER NOT NULL,
+		total INTEGER NOT NULL,
+		alert TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("creating coverage schema: %w", err)
+	}
+	return nil
+}
+
+// keyCoverageBySession keys the field coverage report by the session that
+// parsed the businesses, so each scan keeps its own rates. Earlier reports
+// summed every run of the project; they are kept under its latest scan.
+func keyCoverageBySession(tx *sql.Tx) error {
+	schema := `
+	ALTER TABLE field_coverage RENAME TO field_coverage_old;
+	CREATE TABLE field_coverage (
+		session_id INTEGER NOT NULL DEFAULT 0,
+		field TEXT NOT NULL,
+		filled INTEGER NOT NULL,
+		total INTEGER NOT NULL,
+		alert TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (session_id, field)
+	);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("keying coverage by session: %w", err)
+	}
+	_, err := tx.Exec(`
+		INSERT INTO field_coverage (session_id, field, filled, total, alert, updated_at)
+		SELECT COALESCE((SELECT MAX(id) FROM scan_sessions WHERE kind != ?), 0), field, filled, total, alert, updated_at
+		FROM field_coverage_old ORDER BY rowid`, SessionResume)
+	if err != nil {
+		return fmt.Errorf("keying coverage by session: %w", err)
+	}
+	if _, err := tx.Exec("DROP TABLE field_coverage_old"); err != nil {
+		return fmt.Errorf("keying coverage by session: %w", err)
+	}
+	return nil
+}
+
+// AddCoverage adds a run's field counts to the coverage report of the
+// current session, 0 outside one. A field keeps its earlier alert unless
+// the run raised a new one.
+func (s *Store) AddCoverage(report []FieldCoverage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning tx: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO field_coverage (session_id, field, filled, total, alert) VALUES (?,?,?,?,NULLIF(?, ''))
+		ON CONFLICT(session_id, field) DO UPDATE SET
+			filled = filled + excluded.filled,
+			total = total + excluded.total,
+			alert = COALESCE(excluded.alert, alert),
+			updated_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("preparing stmt: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, f := range report {
+		if _, err := stmt.Exec(s.session, f.Field, f.Filled, f.Total, f.Alert); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("saving coverage: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing tx: %w", err)
+	}
+	return nil
+}
+
+// Coverage returns the field coverage report of a session, in insertion
+// order.
+func (s *Store) Coverage(session int64) ([]FieldCoverage, error) {
+	rows, err := s.db.Query("SELECT field, filled, total, COALESCE(alert, '') FROM field_coverage WHERE session_id = ? ORDER BY rowid", session)
+	if err != nil {
+		return nil, fmt.Errorf("querying coverage: %w", err)
+	}
+	defer rows.Close()
+
+	var report []FieldCoverage
+	for rows.Next() {
+		var f FieldCoverage
+		if err := rows.Scan(&f.Field, &f.Filled, &f.Total, &f.Alert); err != nil {
+			return nil, fmt.Errorf("scanning coverage: %w", err)
+		}
+		report = append(report, f)
+	}
+	return report, rows.Err()
+}
+
+// CoverageBaseline sums the field coverage of the sessions before the
+// current one that searched the same queries, in any order: the fill rates
+// a new run of the search is expected to keep. Fill rates of fields like
+// phone or website depend on what is searched, so other searches are left
+// out.
+func (s *Store) CoverageBaseline(queries []string) ([]FieldCoverage, error) {
+	s.mu.Lock()
+	current := s.session
+	s.mu.Unlock()
+
+	sessions, err := LoadSessions(s.db)
+	if err != nil {
+		return nil, err
+	}
+	want := slices.Sorted(slices.Values(queries))
+	var ids []any
+	for _, sess := range sessions {
+		if current != 0 && sess.ID >= current {
+			break
+		}
+		if slices.Equal(slices.Sorted(slices.Values(sess.Params.Queries)), want) {
+			ids = append(ids, sess.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.db.Query(`
+		SELECT field, SUM(filled), SUM(total) FROM field_coverage
+		WHERE session_id IN (?`+strings.Repeat(",?", len(ids)-1)+`)
+		GROUP BY field ORDER BY MIN(rowid)`, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("querying coverage baseline: %w", err)
+	}
+	defer rows.Close()
+
+	var report []FieldCoverage
+	for rows.Next() {
+		var f FieldCoverage
+		if err := rows.Scan(&f.Field, &f.Filled, &f.Total); err != nil {
+			return nil, fmt.Errorf("scanning coverage baseline: %w", err)
+		}
+		report = append(report, f)
+	}
+	return report, rows.Err()
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestCoverageBySession(t *testing.T) {
+	s := newTestStore(t)
+
+	// Two scans of the same search in a different query order, then another
+	// search; each run a report of its own
+	runs := []struct {
+		queries []string
+		kind    string
+		phones  int
+	}{
+		{[]string{"cafes", "bars"}, SessionScan, 70},
+		{[]string{"cafes", "bars"}, SessionResume, 10},
+		{[]string{"bars", "cafes"}, SessionScan, 50},
+		{[]string{"hotels"}, SessionScan, 95},
+	}
+	var ids []int64
+	for _, r := range runs {
+		id, err := s.StartSession(r.kind, "cli", model.SearchParams{Queries: r.queries})
+		if err != nil {
+			t.Fatalf("StartSession: %v", err)
+		}
+		ids = append(ids, id)
+		if err := s.AddCoverage([]FieldCoverage{{Field: "phone", Filled: r.phones, Total: 100}}); err != nil {
+			t.Fatalf("AddCoverage: %v", err)
+		}
+	}
+
+	for i, r := range runs {
+		report, err := s.Coverage(ids[i])
+		if err != nil {
+			t.Fatalf("Coverage: %v", err)
+		}
+		if len(report) != 1 || report[0].Filled != r.phones || report[0].Total != 100 {
+			t.Errorf("session %d coverage = %+v, want its own %d/100", ids[i], report, r.phones)
+		}
+	}
+
+	// A rescan of cafes and bars compares against the earlier three runs of
+	// them, not the hotels scan
+	if _, err := s.StartSession(SessionScan, "cli", model.SearchParams{Queries: []string{"cafes", "bars"}}); err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	baseline, err := s.CoverageBaseline([]string{"bars", "cafes"})
+	if err != nil {
+		t.Fatalf("CoverageBaseline: %v", err)
+	}
+	if len(baseline) != 1 || baseline[0].Field != "phone" || baseline[0].Filled != 130 || baseline[0].Total != 300 {
+		t.Errorf("baseline = %+v, want phone 130/300", baseline)
+	}
+
+	baseline, err = s.CoverageBaseline([]string{"pharmacies"})
+	if err != nil {
+		t.Fatalf("CoverageBaseline: %v", err)
+	}
+	if len(baseline) != 0 {
+		t.Errorf("baseline for a new search = %+v, want none", baseline)
+	}
+}
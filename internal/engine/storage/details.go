@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// EnrichJob is a place queued for the details enrichment pass.
+type EnrichJob struct {
+	CID       string
+	PlaceID   string
+	Status    string // one of the Job* ledger statuses
+	Attempts  int
+	LastError string
+}
+
+func createDetailsSchema(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS enrich_jobs (
+		cid TEXT PRIMARY KEY,
+		place_id TEXT,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_enrich_jobs_status ON enrich_jobs(status);
+
+	CREATE TABLE IF NOT EXISTS place_details (
+		cid TEXT PRIMARY KEY,
+		place_id TEXT,
+		status TEXT,
+		status_text TEXT,
+		timezone TEXT,
+		plus_code TEXT,
+		fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS place_hours (
+		cid TEXT NOT NULL,
+		ord INTEGER NOT NULL,
+		day TEXT NOT NULL,
+		hours TEXT NOT NULL,
+		PRIMARY KEY (cid, ord)
+	);
+
+	CREATE TABLE IF NOT EXISTS place_popular_times (
+		cid TEXT NOT NULL,
+		day INTEGER NOT NULL,
+		hour INTEGER NOT NULL,
+		busyness INTEGER NOT NULL,
+		PRIMARY KEY (cid, day, hour)
+	);
+
+	CREATE TABLE IF NOT EXISTS place_attributes (
+		cid TEXT NOT NULL,
+		grp TEXT NOT NULL,
+		name TEXT NOT NULL,
+		available INTEGER NOT NULL,
+		PRIMARY KEY (cid, grp, name)
+	);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("creating details schema: %w", err)
+	}
+	return nil
+}
+
+// lookupCID matches the place keys that are CIDs Google can look up:
+// decimal numbers. Enrichment and reviews skip any other key, such as those
+// databases migrated by earlier versions gave places without a CID.
+const lookupCID = "cid != '' AND cid NOT GLOB '*[^0-9]*'"
+
+// EnqueueEnrichJobs adds every stored place with a CID (see lookupCID) to
+// the enrichment ledger as pending. Places already in the ledger are left untouched, so running it
+// again after a scan only queues the new ones. It returns the number of
+// places added.
+func (s *Store) EnqueueEnrichJobs() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		INSERT OR IGNORE INTO enrich_jobs (cid, place_id, status)
+		SELECT cid, place_id, ? FROM places WHERE `+lookupCID, JobPending)
+	if err != nil {
+		return 0, fmt.Errorf("enqueueing enrich jobs: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// UnfinishedEnrichJobs returns every enrichment job not yet done.
+func (s *Store) UnfinishedEnrichJobs() ([]EnrichJob, error) {
+	rows, err := s.db.Query(`
+		SELECT cid, COALESCE(place_id, ''), status, attempts, COALESCE(last_error, '')
+		FROM enrich_jobs WHERE status != ? AND `+lookupCID+` ORDER BY rowid`, JobDone)
+	if err != nil {
+		return nil, fmt.Errorf("querying enrich jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []EnrichJob
+	for rows.Next() {
+		var j EnrichJob
+		if err := rows.Scan(&j.CID, &j.PlaceID, &j.Status, &j.Attempts, &j.LastError); err != nil {
+			return nil, fmt.Errorf("scanning enrich job: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// UpdateEnrichJob stores the outcome of an enrichment attempt. Failed
+// attempts increment the attempt counter.
+func (s *Store) UpdateEnrichJob(cid, status, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt := 0
+	if status == JobFailed {
+		attempt = 1
+	}
+	_, err := s.db.Exec(`
+		UPDATE enrich_jobs SET status = ?, attempts = attempts + ?, last_error = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE cid = ?`, status, attempt, lastErr, cid)
+	if err != nil {
+		return fmt.Errorf("updating enrich job: %w", err)
+	}
+	return nil
+}
+
+// EnrichCounts returns the number of enrichment jobs per status.
+func (s *Store) EnrichCounts() (map[string]int, error) {
+	rows, err := s.db.Query("SELECT status, COUNT(*) FROM enrich_jobs WHERE " + lookupCID + " GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("counting enrich jobs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// SaveDetails stores the enrichment data of one place, replacing what an
+// earlier pass stored for it. Reviews are kept across passes; it returns
+// how many of d's reviews were new.
+func (s *Store) SaveDetails(d *model.PlaceDetails) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning tx: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO place_details (cid, place_id, status, status_text, timezone, plus_code)
+		VALUES (?,?,?,?,?,?)`,
+		d.CID, d.PlaceID, d.Status, d.StatusText, d.Timezone, d.PlusCode)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("saving place details: %w", err)
+	}
+
+	for _, table := range []string{"place_hours", "place_popular_times", "place_attributes"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE cid = ?", d.CID); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("clearing %s: %w", table, err)
+		}
+	}
+
+	ord := 0
+	for _, h := range d.Hours {
+		for _, r := range h.Hours {
+			_, err := tx.Exec("INSERT INTO place_hours (cid, ord, day, hours) VALUES (?,?,?,?)",
+				d.CID, ord, h.Day, r)
+			if err != nil {
+				tx.Rollback()
+				return 0, fmt.Errorf("saving hours: %w", err)
+			}
+			ord++
+		}
+	}
+	if err := saveSchedule(tx, d.CID, d.Schedule); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	for _, p := range d.PopularTimes {
+		_, err := tx.Exec("INSERT OR REPLACE INTO place_popular_times (cid, day, hour, busyness) VALUES (?,?,?,?)",
+			d.CID, p.Day, p.Hour, p.Busyness)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("saving popular times: %w", err)
+		}
+	}
+	for _, a := range d.Attributes {
+		_, err := tx.Exec("INSERT OR REPLACE INTO place_attributes (cid, grp, name, available) VALUES (?,?,?,?)",
+			d.CID, a.Group, a.Name, a.Available)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("saving attributes: %w", err)
+		}
+	}
+	newReviews, err := insertReviews(tx, d.Reviews)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing tx: %w", err)
+	}
+	return newReviews, nil
+}
+
+// LoadTimezones reads the time zone of every enriched place, by CID. Like
+// LoadSchedules it works on a plain db handle.
+func LoadTimezones(db *sql.DB) (map[string]string, error) {
+	rows, err := db.Query("SELECT cid, timezone FROM place_details WHERE timezone IS NOT NULL AND timezone != ''")
+	if err != nil {
+		return nil, fmt.Errorf("querying time zones: %w", err)
+	}
+	defer rows.Close()
+
+	zones := make(map[string]string)
+	for rows.Next() {
+		var cid, tz string
+		if err := rows.Scan(&cid, &tz); err != nil {
+			return nil, fmt.Errorf("scanning time zone: %w", err)
+		}
+		zones[cid] = tz
+	}
+	return zones, rows.Err()
+}
@@ -0,0 +1,352 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// trackedFields are the place fields whose changes between sightings are
+// kept in place_history. value renders a business's field the way the
+// stored column reads back; "" means the response did not carry it.
+var trackedFields = []struct {
+	column string
+	value  func(b *model.Business) string
+}{
+	{"name", func(b *model.Business) string { return b.Name }},
+	{"rating", func(b *model.Business) string { return formatNumber(b.Rating) }},
+	{"review_count", func(b *model.Business) string { return formatNumber(float64(b.ReviewCount)) }},
+	{"category", func(b *model.Business) string { return b.Category }},
+	{"categories", func(b *model.Business) string { return b.Categories }},
+	{"address", func(b *model.Business) string { return b.Address }},
+	{"city", func(b *model.Business) string { return b.City }},
+	{"postal_code", func(b *model.Business) string { return b.PostalCode }},
+	{"price_range", func(b *model.Business) string { return b.PriceRange }},
+	{"phone", func(b *model.Business) string { return b.Phone }},
+	{"website", func(b *model.Business) string { return b.Website }},
+	{"description", func(b *model.Business) string { return b.Description }},
+	{"open_hours", func(b *model.Business) string { return b.OpenHours }},
+}
+
+func formatNumber(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func createHistorySchema(tx *sql.Tx) error {
+	schema := `
+	ALTER TABLE places ADD COLUMN first_seen INTEGER;
+	ALTER TABLE places ADD COLUMN last_seen INTEGER;
+
+	CREATE TABLE IF NOT EXISTS place_sightings (
+		session_id INTEGER NOT NULL,
+		cid TEXT NOT NULL,
+		PRIMARY KEY (session_id, cid)
+	);
+	CREATE INDEX IF NOT EXISTS idx_place_sightings_cid ON place_sightings(cid);
+
+	CREATE TABLE IF NOT EXISTS place_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		cid TEXT NOT NULL,
+		session_id INTEGER,
+		field TEXT NOT NULL,
+		old_value TEXT,
+		new_value TEXT,
+		changed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_place_history_cid ON place_history(cid);
+	CREATE INDEX IF NOT EXISTS idx_place_history_session ON place_history(session_id);
+
+	-- Databases from before scan sessions get one for the scan that made them
+	INSERT INTO scan_sessions (kind, source, params, status, started_at, ended_at)
+	SELECT 'scan', 'migration', COALESCE((SELECT value FROM scan_meta WHERE key = 'params'), '{}'),
+	       'done', MIN(created_at), MAX(updated_at)
+	FROM places
+	WHERE NOT EXISTS (SELECT 1 FROM scan_sessions)
+	HAVING COUNT(*) > 0;
+
+	-- Every place stored so far was seen by the first scan
+	UPDATE places SET first_seen = (SELECT MIN(id) FROM scan_sessions),
+	                  last_seen = (SELECT MIN(id) FROM scan_sessions);
+	INSERT OR IGNORE INTO place_sightings (session_id, cid)
+	SELECT (SELECT MIN(id) FROM scan_sessions), cid FROM places
+	WHERE EXISTS (SELECT 1 FROM scan_sessions);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("creating history schema: %w", err)
+	}
+	return nil
+}
+
+// recordChanges compares b with the stored place and adds a place_history
+// row per tracked field that changed. Fields b does not carry are not
+// changes: InsertBatch keeps the stored value for them.
+func recordChanges(tx *sql.Tx, session int64, b *model.Business) error {
+	cols := make([]string, len(trackedFields))
+	for i, f := range trackedFields {
+		cols[i] = "COALESCE(" + f.column + ", '')"
+	}
+	stored := make([]string, len(trackedFields))
+	dest := make([]any, len(trackedFields))
+	for i := range stored {
+		dest[i] = &stored[i]
+	}
+	err := tx.QueryRow("SELECT "+strings.Join(cols, ", ")+" FROM places WHERE cid = ?", b.CID).Scan(dest...)
+	if err != nil {
+		return fmt.Errorf("reading place: %w", err)
+	}
+
+	for i, f := range trackedFields {
+		v := f.value(b)
+		if v == "" || v == stored[i] {
+			continue
+		}
+		_, err := tx.Exec("INSERT INTO place_history (cid, session_id, field, old_value, new_value) VALUES (?,?,?,?,?)",
+			b.CID, nullSession(session), f.column, stored[i], v)
+		if err != nil {
+			return fmt.Errorf("saving place history: %w", err)
+		}
+	}
+	return nil
+}
+
+// nullSession stores session 0, writes outside a scan session, as NULL.
+func nullSession(session int64) any {
+	if session == 0 {
+		return nil
+	}
+	return session
+}
+
+// FieldChange is a change of one place field between two scans.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// DiffPlace is a place in a SessionDiff, with its field changes when it
+// changed.
+type DiffPlace struct {
+	CID     string        `json:"cid"`
+	Name    string        `json:"name"`
+	Address string        `json:"address"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// SessionDiff compares the places seen by two scans.
+type SessionDiff struct {
+	From, To    Session
+	New         []DiffPlace // seen by To, not by From
+	Disappeared []DiffPlace // seen by From, not by To
+	Changed     []DiffPlace // seen by both, with fields changed after From
+}
+
+// scanGroup returns the scan or replay session id belongs to, or started
+// when id is a resume, and the IDs of the sessions that ran it: the session
+// itself and the resumes that followed it.
+func scanGroup(sessions []Session, id int64) (Session, []int64, error) {
+	root := -1
+	for i, s := range sessions {
+		if s.Kind != SessionResume {
+			root = i
+		}
+		if s.ID == id {
+			if root < 0 {
+				return Session{}, nil, fmt.Errorf("session %d is a resume of no scan", id)
+			}
+			ids := []int64{sessions[root].ID}
+			for _, r := range sessions[root+1:] {
+				if r.Kind != SessionResume {
+					break
+				}
+				ids = append(ids, r.ID)
+			}
+			return sessions[root], ids, nil
+		}
+	}
+	return Session{}, nil, fmt.Errorf("no session %d", id)
+}
+
+// ScanSessions returns the sessions that started a scan, oldest first:
+// scans and replays, not resumes.
+func ScanSessions(sessions []Session) []Session {
+	var scans []Session
+	for _, s := range sessions {
+		if s.Kind != SessionResume {
+			scans = append(scans, s)
+		}
+	}
+	return scans
+}
+
+// DiffSessions compares the places seen by the scans of sessions from and
+// to, each with its resumes: places only the later one found, places it no
+// longer found, and field changes recorded after the earlier one up to the
+// end of the later one. It is a plain function over db so read-only tools
+// can use it without a Store.
+func DiffSessions(db *sql.DB, from, to int64) (*SessionDiff, error) {
+	sessions, err := LoadSessions(db)
+	if err != nil {
+		return nil, err
+	}
+	fromScan, fromIDs, err := scanGroup(sessions, from)
+	if err != nil {
+		return nil, err
+	}
+	toScan, toIDs, err := scanGroup(sessions, to)
+	if err != nil {
+		return nil, err
+	}
+	if fromScan.ID >= toScan.ID {
+		return nil, fmt.Errorf("session %d must be an earlier scan than session %d", from, to)
+	}
+
+	before, err := sightings(db, fromIDs)
+	if err != nil {
+		return nil, err
+	}
+	after, err := sightings(db, toIDs)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := changesBetween(db, fromIDs[len(fromIDs)-1], toIDs[len(toIDs)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	d := &SessionDiff{From: fromScan, To: toScan}
+	var cids []string
+	for cid := range after {
+		if !before[cid] {
+			cids = append(cids, cid)
+		}
+	}
+	if d.New, err = diffPlaces(db, cids, nil); err != nil {
+		return nil, err
+	}
+	cids = cids[:0]
+	for cid := range before {
+		if !after[cid] {
+			cids = append(cids, cid)
+		}
+	}
+	if d.Disappeared, err = diffPlaces(db, cids, nil); err != nil {
+		return nil, err
+	}
+	cids = cids[:0]
+	for cid := range changes {
+		if before[cid] && after[cid] {
+			cids = append(cids, cid)
+		}
+	}
+	if d.Changed, err = diffPlaces(db, cids, changes); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// sightings returns the CIDs seen by any of the sessions ids.
+func sightings(db *sql.DB, ids []int64) (map[string]bool, error) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := db.Query("SELECT DISTINCT cid FROM place_sightings WHERE session_id IN (?"+strings.Repeat(",?", len(ids)-1)+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying sightings: %w", err)
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var cid string
+		if err := rows.Scan(&cid); err != nil {
+			return nil, fmt.Errorf("scanning sighting: %w", err)
+		}
+		seen[cid] = true
+	}
+	return seen, rows.Err()
+}
+
+// changesBetween returns the net field changes recorded by sessions after
+// from up to to, by CID: the value before the first change and after the
+// last one, skipping fields that changed back.
+func changesBetween(db *sql.DB, from, to int64) (map[string][]FieldChange, error) {
+	rows, err := db.Query(`
+		SELECT cid, field, COALESCE(old_value, ''), COALESCE(new_value, '') FROM place_history
+		WHERE session_id > ? AND session_id <= ? ORDER BY id`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("querying place history: %w", err)
+	}
+	defer rows.Close()
+
+	net := make(map[string][]FieldChange)
+	for rows.Next() {
+		var cid string
+		var c FieldChange
+		if err := rows.Scan(&cid, &c.Field, &c.Old, &c.New); err != nil {
+			return nil, fmt.Errorf("scanning place history: %w", err)
+		}
+		merged := false
+		for i := range net[cid] {
+			if net[cid][i].Field == c.Field {
+				net[cid][i].New = c.New
+				merged = true
+			}
+		}
+		if !merged {
+			net[cid] = append(net[cid], c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for cid, cs := range net {
+		kept := cs[:0]
+		for _, c := range cs {
+			if c.Old != c.New {
+				kept = append(kept, c)
+			}
+		}
+		if len(kept) == 0 {
+			delete(net, cid)
+		} else {
+			net[cid] = kept
+		}
+	}
+	return net, nil
+}
+
+// diffPlaces reads the names and addresses of cids, sorted by name, with
+// their changes.
+func diffPlaces(db *sql.DB, cids []string, changes map[string][]FieldChange) ([]DiffPlace, error) {
+	stmt, err := db.Prepare("SELECT name, COALESCE(address, '') FROM places WHERE cid = ?")
+	if err != nil {
+		return nil, fmt.Errorf("preparing stmt: %w", err)
+	}
+	defer stmt.Close()
+
+	places := make([]DiffPlace, 0, len(cids))
+	for _, cid := range cids {
+		p := DiffPlace{CID: cid, Changes: changes[cid]}
+		if err := stmt.QueryRow(cid).Scan(&p.Name, &p.Address); err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("reading place: %w", err)
+		}
+		places = append(places, p)
+	}
+	sort.Slice(places, func(i, j int) bool {
+		if places[i].Name != places[j].Name {
+			return places[i].Name < places[j].Name
+		}
+		return places[i].CID < places[j].CID
+	})
+	return places, nil
+}
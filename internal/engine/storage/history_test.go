@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+func TestDiffSessions(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer s.Close()
+
+	place := func(cid, name string, rating float64, website string) model.Business {
+		return model.Business{CID: cid, Name: name, Query: "cafes", Address: name + " street",
+			Rating: rating, Website: website, Lat: 40.4, Lng: -3.7}
+	}
+	run := func(kind string, places ...model.Business) int64 {
+		t.Helper()
+		id, err := s.StartSession(kind, "cli", model.SearchParams{Queries: []string{"cafes"}, Country: "Spain"})
+		if err != nil {
+			t.Fatalf("StartSession: %v", err)
+		}
+		if _, err := s.InsertBatch(places, Origin{}); err != nil {
+			t.Fatalf("InsertBatch: %v", err)
+		}
+		if err := s.FinishSession(id, SessionStats{}, nil); err != nil {
+			t.Fatalf("FinishSession: %v", err)
+		}
+		return id
+	}
+
+	first := run(SessionScan,
+		place("1", "Cafe Sol", 4.5, "https://sol.example"),
+		place("2", "Bar Luna", 4.1, "https://luna.example"),
+		place("3", "Horno Real", 4.8, ""))
+	firstResume := run(SessionResume, place("4", "Kiosko Prado", 3.9, ""))
+	second := run(SessionScan,
+		place("1", "Cafe Sol", 4.6, "https://sol.example"),
+		place("2", "Bar Luna", 4.1, "https://luna.example/new"),
+		place("4", "Kiosko Prado", 3.9, ""),
+		place("5", "Churros Mayor", 4.4, ""))
+	// The website changes back before the second scan ends
+	secondResume := run(SessionResume,
+		place("2", "Bar Luna", 0, "https://luna.example"),
+		place("6", "Alma Cafe", 4.0, ""))
+	third := run(SessionScan, place("1", "Cafe Sol", 4.7, ""), place("2", "Bar Luna", 4.1, ""))
+
+	names := func(places []DiffPlace) []string {
+		var out []string
+		for _, p := range places {
+			out = append(out, p.CID+" "+p.Name)
+		}
+		return out
+	}
+	tests := []struct {
+		name                     string
+		from, to                 int64
+		fromScan, toScan         int64
+		added, gone, changedCIDs []string
+		changes                  []FieldChange
+	}{
+		{"scans", first, second, first, second,
+			[]string{"6 Alma Cafe", "5 Churros Mayor"}, []string{"3 Horno Real"}, []string{"1 Cafe Sol"},
+			[]FieldChange{{Field: "rating", Old: "4.5", New: "4.6"}}},
+		// A resume stands for the scan it resumed
+		{"resumes", firstResume, secondResume, first, second,
+			[]string{"6 Alma Cafe", "5 Churros Mayor"}, []string{"3 Horno Real"}, []string{"1 Cafe Sol"},
+			[]FieldChange{{Field: "rating", Old: "4.5", New: "4.6"}}},
+		{"net changes", first, third, first, third,
+			nil, []string{"3 Horno Real", "4 Kiosko Prado"}, []string{"1 Cafe Sol"},
+			[]FieldChange{{Field: "rating", Old: "4.5", New: "4.7"}}},
+		{"since the second", second, third, second, third,
+			nil, []string{"6 Alma Cafe", "5 Churros Mayor", "4 Kiosko Prado"}, []string{"1 Cafe Sol"},
+			[]FieldChange{{Field: "rating", Old: "4.6", New: "4.7"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := DiffSessions(s.db, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("DiffSessions: %v", err)
+			}
+			if d.From.ID != tt.fromScan || d.To.ID != tt.toScan {
+				t.Errorf("compared sessions %d and %d, want %d and %d", d.From.ID, d.To.ID, tt.fromScan, tt.toScan)
+			}
+			if got := names(d.New); !reflect.DeepEqual(got, tt.added) {
+				t.Errorf("new = %v, want %v", got, tt.added)
+			}
+			if got := names(d.Disappeared); !reflect.DeepEqual(got, tt.gone) {
+				t.Errorf("disappeared = %v, want %v", got, tt.gone)
+			}
+			if got := names(d.Changed); !reflect.DeepEqual(got, tt.changedCIDs) {
+				t.Fatalf("changed = %v, want %v", got, tt.changedCIDs)
+			}
+			if !reflect.DeepEqual(d.Changed[0].Changes, tt.changes) {
+				t.Errorf("changes = %+v, want %+v", d.Changed[0].Changes, tt.changes)
+			}
+			if d.Changed[0].Address != "Cafe Sol street" {
+				t.Errorf("changed place address = %q", d.Changed[0].Address)
+			}
+		})
+	}
+
+	for _, tt := range []struct {
+		from, to int64
+		err      string
+	}{
+		{second, first, "must be an earlier scan"},
+		{second, secondResume, "must be an earlier scan"},
+		{first, first, "must be an earlier scan"},
+		{first, 99, "no session 99"},
+	} {
+		if _, err := DiffSessions(s.db, tt.from, tt.to); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("DiffSessions(%d, %d) = %v, want %q", tt.from, tt.to, err, tt.err)
+		}
+	}
+}
+
+func TestChangesBetween(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer s.Close()
+
+	history := []struct {
+		cid, field, old, new string
+		session              int64
+	}{
+		{"1", "rating", "4.5", "4.6", 2},
+		{"1", "phone", "", "+34 600 000 001", 2},
+		{"1", "rating", "4.6", "4.7", 3},
+		{"2", "website", "https://a.example", "https://b.example", 2},
+		{"2", "website", "https://b.example", "https://a.example", 3},
+		{"3", "name", "Old", "New", 1},   // before the range
+		{"3", "name", "New", "Newer", 4}, // after it
+	}
+	for _, h := range history {
+		_, err := s.db.Exec("INSERT INTO place_history (cid, session_id, field, old_value, new_value) VALUES (?,?,?,?,?)",
+			h.cid, h.session, h.field, h.old, h.new)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := changesBetween(s.db, 1, 3)
+	if err != nil {
+		t.Fatalf("changesBetween: %v", err)
+	}
+	want := map[string][]FieldChange{
+		"1": {{Field: "rating", Old: "4.5", New: "4.7"}, {Field: "phone", Old: "", New: "+34 600 000 001"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changesBetween = %+v, want %+v", got, want)
+	}
+}
+
+func TestScanGroup(t *testing.T) {
+	sessions := []Session{
+		{ID: 1, Kind: SessionScan},
+		{ID: 2, Kind: SessionResume},
+		{ID: 3, Kind: SessionResume},
+		{ID: 4, Kind: SessionReplay},
+		{ID: 5, Kind: SessionScan},
+		{ID: 6, Kind: SessionResume},
+	}
+	tests := []struct {
+		id   int64
+		scan int64
+		ids  []int64
+	}{
+		{1, 1, []int64{1, 2, 3}},
+		{3, 1, []int64{1, 2, 3}},
+		{4, 4, []int64{4}},
+		{6, 5, []int64{5, 6}},
+	}
+	for _, tt := range tests {
+		scan, ids, err := scanGroup(sessions, tt.id)
+		if err != nil || scan.ID != tt.scan || !reflect.DeepEqual(ids, tt.ids) {
+			t.Errorf("scanGroup(%d) = %d, %v, %v, want %d, %v", tt.id, scan.ID, ids, err, tt.scan, tt.ids)
+		}
+	}
+
+	if _, _, err := scanGroup(sessions, 7); err == nil {
+		t.Error("scanGroup of a missing session succeeded")
+	}
+	orphan := []Session{{ID: 1, Kind: SessionResume}, {ID: 2, Kind: SessionScan}}
+	if _, _, err := scanGroup(orphan, 1); err == nil || !strings.Contains(err.Error(), "resume of no scan") {
+		t.Errorf("scanGroup of a resume without its scan = %v", err)
+	}
+}
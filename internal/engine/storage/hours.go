@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+func createHoursSchema(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS opening_hours (
+		cid TEXT NOT NULL,
+		day INTEGER NOT NULL,
+		ord INTEGER NOT NULL,
+		open TEXT,
+		close TEXT,
+		overnight INTEGER NOT NULL DEFAULT 0,
+		all_day INTEGER NOT NULL DEFAULT 0,
+		closed INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (cid, day, ord)
+	);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("creating hours schema: %w", err)
+	}
+	return nil
+}
+
+// saveSchedule replaces the stored weekly schedule of a place. An empty
+// schedule leaves what is stored untouched.
+func saveSchedule(tx *sql.Tx, cid string, schedule model.Schedule) error {
+	if cid == "" || len(schedule) == 0 {
+		return nil
+	}
+	if _, err := tx.Exec("DELETE FROM opening_hours WHERE cid = ?", cid); err != nil {
+		return fmt.Errorf("clearing opening hours: %w", err)
+	}
+	ord := make(map[int]int)
+	for _, sp := range schedule {
+		_, err := tx.Exec(`
+			INSERT INTO opening_hours (cid, day, ord, open, close, overnight, all_day, closed)
+			VALUES (?,?,?,?,?,?,?,?)`,
+			cid, sp.Day, ord[sp.Day], sp.Open, sp.Close, sp.Overnight, sp.AllDay, sp.Closed)
+		if err != nil {
+			return fmt.Errorf("saving opening hours: %w", err)
+		}
+		ord[sp.Day]++
+	}
+	return nil
+}
+
+// LoadSchedules reads every stored weekly schedule, by CID. It is a plain
+// function over db so read-only tools can use it without a Store.
+func LoadSchedules(db *sql.DB) (map[string]model.Schedule, error) {
+	rows, err := db.Query(`
+		SELECT cid, day, COALESCE(open, ''), COALESCE(close, ''), overnight, all_day, closed
+		FROM opening_hours ORDER BY cid, day, ord`)
+	if err != nil {
+		return nil, fmt.Errorf("querying opening hours: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := make(map[string]model.Schedule)
+	for rows.Next() {
+		var cid string
+		var sp model.OpeningSpan
+		if err := rows.Scan(&cid, &sp.Day, &sp.Open, &sp.Close, &sp.Overnight, &sp.AllDay, &sp.Closed); err != nil {
+			return nil, fmt.Errorf("scanning opening hours: %w", err)
+		}
+		schedules[cid] = append(schedules[cid], sp)
+	}
+	return schedules, rows.Err()
+}
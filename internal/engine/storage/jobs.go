@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// Job ledger statuses.
+const (
+	JobPending     = "pending"
+	JobDone        = "done"
+	JobFailed      = "failed"
+	JobInterrupted = "interrupted" // in flight when the scan was cancelled
+)
+
+// Unfinished returns the number of jobs a resume would run, given JobCounts.
+func Unfinished(counts map[string]int) int {
+	return counts[JobPending] + counts[JobFailed] + counts[JobInterrupted]
+}
+
+// JobRecord is a persisted sector×query job from the scan ledger.
+type JobRecord struct {
+	Sector    model.Sector
+	Query     string
+	Page      int // next page to fetch
+	Status    string
+	Attempts  int
+	LastError string
+}
+
+func createJobsSchema(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		zoom INTEGER NOT NULL,
+		sector_row INTEGER NOT NULL,
+		sector_col INTEGER NOT NULL,
+		lat REAL NOT NULL,
+		lng REAL NOT NULL,
+		span REAL NOT NULL,
+		query TEXT NOT NULL,
+		page INTEGER NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(zoom, sector_row, sector_col, query)
+	);
+	CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
+
+	CREATE TABLE IF NOT EXISTS scan_meta (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("creating jobs schema: %w", err)
+	}
+	return nil
+}
+
+// EnqueueJobs records jobs in the ledger as pending. Jobs already present are left untouched.
+func (s *Store) EnqueueJobs(jobs []JobRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning tx: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO jobs (zoom, sector_row, sector_col, lat, lng, span, query, page, status)
+		VALUES (?,?,?,?,?,?,?,?,?)
+	`)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("preparing stmt: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, j := range jobs {
+		_, err := stmt.Exec(j.Sector.Zoom, j.Sector.Row, j.Sector.Col, j.Sector.Lat, j.Sector.Lng, j.Sector.Span,
+			j.Query, j.Page, JobPending)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("inserting job: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing tx: %w", err)
+	}
+	return nil
+}
+
+// UpdateJob stores the outcome of a job attempt. Failed attempts increment the attempt counter.
+func (s *Store) UpdateJob(sector model.Sector, query string, page int, status, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt := 0
+	if status == JobFailed {
+		attempt = 1
+	}
+	_, err := s.db.Exec(`
+		UPDATE jobs SET page = ?, status = ?, attempts = attempts + ?, last_error = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE zoom = ? AND sector_row = ? AND sector_col = ? AND query = ?`,
+		page, status, attempt, lastErr, sector.Zoom, sector.Row, sector.Col, query)
+	if err != nil {
+		return fmt.Errorf("updating job: %w", err)
+	}
+	return nil
+}
+
+// ResetJobs empties the job ledger, so a new scan appended to the project
+// runs every job again. Unfinished jobs of the previous scan are dropped.
+func (s *Store) ResetJobs() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.db.Exec("DELETE FROM jobs"); err != nil {
+		return fmt.Errorf("resetting jobs: %w", err)
+	}
+	return nil
+}
+
+// UnfinishedJobs returns every job that is still pending, was interrupted or has failed.
+func (s *Store) UnfinishedJobs() ([]JobRecord, error) {
+	rows, err := s.db.Query(`
+		SELECT zoom, sector_row, sector_col, lat, lng, span, query, page, status, attempts, COALESCE(last_error, '')
+		FROM jobs WHERE status != ? ORDER BY id`, JobDone)
+	if err != nil {
+		return nil, fmt.Errorf("querying jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []JobRecord
+	for rows.Next() {
+		var j JobRecord
+		err := rows.Scan(&j.Sector.Zoom, &j.Sector.Row, &j.Sector.Col, &j.Sector.Lat, &j.Sector.Lng, &j.Sector.Span,
+			&j.Query, &j.Page, &j.Status, &j.Attempts, &j.LastError)
+		if err != nil {
+			return nil, fmt.Errorf("scanning job: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// JobCounts returns the number of ledger jobs per status.
+func (s *Store) JobCounts() (map[string]int, error) {
+	rows, err := s.db.Query("SELECT status, COUNT(*) FROM jobs GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("counting jobs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// SaveParams persists the search parameters that produced this database,
+// with proxy credentials redacted.
+func (s *Store) SaveParams(params model.SearchParams) error {
+	data, err := json.Marshal(params.Redacted())
+	if err != nil {
+		return fmt.Errorf("encoding params: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.db.Exec("INSERT OR REPLACE INTO scan_meta (key, value) VALUES ('params', ?)", string(data))
+	if err != nil {
+		return fmt.Errorf("saving params: %w", err)
+	}
+	return nil
+}
+
+// LoadParams returns the search parameters of the latest scan of the
+// database: those of its last scan or replay session, which the job ledger
+// belongs to, or the ones stored by SaveParams in databases from before
+// scan sessions.
+func (s *Store) LoadParams() (model.SearchParams, error) {
+	return ReadParams(s.db)
+}
+
+// ResumeParams returns the parameters to resume the job ledger with, as
+// LoadParams does, but refuses a ledger merged from scans of different
+// searches: their jobs belong to different grids and cannot run as one scan.
+func (s *Store) ResumeParams() (model.SearchParams, error) {
+	searches, err := ledgerSearches(s.db)
+	if err != nil {
+		return model.SearchParams{}, err
+	}
+	if len(searches) > 1 {
+		labels := make([]string, len(searches))
+		for i, p := range searches {
+			labels[i] = strings.Join(p.Queries, ",") + " in " + p.AreaLabel()
+		}
+		return model.SearchParams{}, fmt.Errorf("the project merges scans of %d different searches (%s), which cannot be resumed as one: resume the source databases before merging them",
+			len(searches), strings.Join(labels, "; "))
+	}
+	return s.LoadParams()
+}
+
+// ledgerSearches returns the distinct searches (see
+// model.SearchParams.Search) the job ledger of db belongs to: that of its
+// latest scan, or of the latest scan of each project merged into it when
+// it has not scanned since.
+func ledgerSearches(db *sql.DB) ([]model.SearchParams, error) {
+	sessions, err := LoadSessions(db)
+	if err != nil {
+		return nil, err
+	}
+	var origins []string
+	latest := make(map[string]model.SearchParams)
+	for _, ss := range sessions {
+		switch {
+		case ss.Kind == SessionResume:
+		case ss.Origin == "":
+			origins = []string{""}
+			clear(latest)
+			latest[""] = ss.Params.Search()
+		default:
+			if _, ok := latest[ss.Origin]; !ok {
+				origins = append(origins, ss.Origin)
+			}
+			latest[ss.Origin] = ss.Params.Search()
+		}
+	}
+	if len(origins) == 0 {
+		// Databases from before scan sessions, if they stored parameters
+		params, err := ReadParams(db)
+		if err != nil {
+			return nil, nil
+		}
+		return []model.SearchParams{params.Search()}, nil
+	}
+	var searches []model.SearchParams
+	for _, origin := range origins {
+		p := latest[origin]
+		if !slices.ContainsFunc(searches, func(q model.SearchParams) bool { return reflect.DeepEqual(p, q) }) {
+			searches = append(searches, p)
+		}
+	}
+	return searches, nil
+}
+
+// ReadParams is LoadParams over a plain db, for read-only tools.
+func ReadParams(db *sql.DB) (model.SearchParams, error) {
+	var params model.SearchParams
+	var data string
+	err := db.QueryRow("SELECT params FROM scan_sessions WHERE kind != ? ORDER BY id DESC LIMIT 1", SessionResume).Scan(&data)
+	if err != nil {
+		err = db.QueryRow("SELECT value FROM scan_meta WHERE key = 'params'").Scan(&data)
+	}
+	if err == sql.ErrNoRows {
+		return params, fmt.Errorf("database has no scan parameters (created before resumable scans)")
+	}
+	if err != nil {
+		return params, fmt.Errorf("loading params: %w", err)
+	}
+	if err := json.Unmarshal([]byte(data), &params); err != nil {
+		return params, fmt.Errorf("decoding params: %w", err)
+	}
+	return params, nil
+}
+
+// redactStoredParams masks the proxy credentials that databases from before
+// redaction stored in plain text with their scan parameters.
+func redactStoredParams(tx *sql.Tx) error {
+	for _, table := range []struct{ name, key, value string }{
+		{"scan_sessions", "id", "params"},
+		{"scan_meta", "key", "value"},
+	} {
+		rows, err := tx.Query(fmt.Sprintf("SELECT %s, %s FROM %s", table.key, table.value, table.name))
+		if err != nil {
+			return fmt.Errorf("querying %s: %w", table.name, err)
+		}
+		redacted := make(map[any]string)
+		for rows.Next() {
+			var key any
+			var data string
+			if err := rows.Scan(&key, &data); err != nil {
+				rows.Close()
+				return fmt.Errorf("scanning %s: %w", table.name, err)
+			}
+			var params model.SearchParams
+			if json.Unmarshal([]byte(data), &params) != nil || !params.UsesProxies() {
+				continue
+			}
+			out, err := json.Marshal(params.Redacted())
+			if err != nil {
+				rows.Close()
+				return fmt.Errorf("encoding params: %w", err)
+			}
+			redacted[key] = string(out)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("querying %s: %w", table.name, err)
+		}
+
+		for key, data := range redacted {
+			q := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", table.name, table.value, table.key)
+			if _, err := tx.Exec(q, data, key); err != nil {
+				return fmt.Errorf("redacting %s params: %w", table.name, err)
+			}
+		}
+	}
+	return nil
+}
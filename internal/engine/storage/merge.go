@@ -0,0 +1,478 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// Merge rules for a place found in more than one source.
+const (
+	PreferRecent   = "recent"   // the copy updated last
+	PreferComplete = "complete" // the copy with the most fields filled, the recent one on ties
+)
+
+// MergeSource is what one source database brought to a merge.
+type MergeSource struct {
+	Path      string
+	Places    int         // places in the source
+	New       int         // places no earlier source had
+	Overlaps  map[int]int // places shared with each earlier source, by its index in the merge
+	Conflicts int         // shared places with a field the copies disagree on
+	Taken     int         // conflicting places whose fields came from this source
+	Sessions  int
+	Ledger    bool // whether its scan job ledger was carried over
+	Reviews   int  // reviews no earlier source had
+	Contacts  int  // contacts no earlier source had
+}
+
+// mergeFields are the place fields reconciled between copies, with the
+// value that stands for empty.
+var mergeFields = []struct{ column, empty string }{
+	{"name", "''"},
+	{"rating", "0"},
+	{"review_count", "0"},
+	{"category", "''"},
+	{"address", "''"},
+	{"price_range", "''"},
+	{"phone", "''"},
+	{"website", "''"},
+	{"google_url", "''"},
+	{"description", "''"},
+	{"place_id", "''"},
+	{"open_hours", "''"},
+	{"thumbnail", "''"},
+	{"categories", "''"},
+	{"city", "''"},
+	{"postal_code", "''"},
+	{"country_code", "''"},
+}
+
+// filledExpr counts the merge fields of the place aliased a that are not
+// empty.
+func filledExpr(a string) string {
+	terms := make([]string, len(mergeFields))
+	for i, f := range mergeFields {
+		terms[i] = fmt.Sprintf("(NULLIF(%s.%s, %s) IS NOT NULL)", a, f.column, f.empty)
+	}
+	return strings.Join(terms, " + ")
+}
+
+// conflictExpr is true when the places aliased a and b both fill a merge
+// field with different values.
+func conflictExpr(a, b string) string {
+	terms := make([]string, len(mergeFields))
+	for i, f := range mergeFields {
+		terms[i] = fmt.Sprintf("(NULLIF(%[1]s.%[3]s, %[4]s) IS NOT NULL AND NULLIF(%[2]s.%[3]s, %[4]s) IS NOT NULL AND %[1]s.%[3]s != %[2]s.%[3]s)",
+			a, b, f.column, f.empty)
+	}
+	return "(" + strings.Join(terms, " OR ") + ")"
+}
+
+// coalesceSet assigns each merge field of places its value in from, or in
+// to when from is empty.
+func coalesceSet(from, to string) string {
+	sets := make([]string, len(mergeFields))
+	for i, f := range mergeFields {
+		sets[i] = fmt.Sprintf("%[3]s = COALESCE(NULLIF(%[1]s.%[3]s, %[4]s), %[2]s.%[3]s)", from, to, f.column, f.empty)
+	}
+	return strings.Join(sets, ", ")
+}
+
+// Merge adds the project databases at paths to the store, in order. A place
+// found in more than one keeps the fields of the copy prefer picks, with
+// its empty fields filled from the others. Queries, reviews and contacts
+// are united, place details come from the latest fetch, the ledgers keep
+// the furthest status, and sessions are copied with their origin so
+// sightings and history keep pointing at them. Scan jobs are keyed by
+// sector indices, which only mean the same cell within one grid, so a
+// source's scan job ledger, which belongs to its latest scan, is carried
+// over only when that scan ran the same search as the first source's; the
+// ledger of a merge of different searches is left out.
+// Sources are read from a migrated copy and never written. Each source is
+// merged in its own transaction; on error the sources merged so far are
+// returned.
+func (s *Store) Merge(paths []string, prefer string) ([]MergeSource, error) {
+	var won string
+	switch prefer {
+	case PreferRecent:
+		won = "p.updated_at > m.updated_at"
+	case PreferComplete:
+		won = fmt.Sprintf("(%[1]s) > (%[2]s) OR ((%[1]s) = (%[2]s) AND p.updated_at > m.updated_at)", filledExpr("p"), filledExpr("m"))
+	default:
+		return nil, fmt.Errorf("unknown merge rule %q (use %s or %s)", prefer, PreferRecent, PreferComplete)
+	}
+
+	dir, err := os.MkdirTemp("", "geotap-merge-")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Attached databases and temp tables belong to a connection, so the
+	// whole merge runs on one.
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("opening connection: %w", err)
+	}
+	defer conn.Close()
+
+	schema := `
+	DROP TABLE IF EXISTS temp.merge_cids;
+	DROP TABLE IF EXISTS temp.merge_won;
+	DROP TABLE IF EXISTS temp.merge_details;
+	DROP TABLE IF EXISTS temp.merge_sessions;
+	CREATE TEMP TABLE merge_cids (source INTEGER NOT NULL, cid TEXT NOT NULL, PRIMARY KEY (source, cid));
+	CREATE TEMP TABLE merge_won (cid TEXT PRIMARY KEY);
+	CREATE TEMP TABLE merge_details (cid TEXT PRIMARY KEY);
+	CREATE TEMP TABLE merge_sessions (old INTEGER PRIMARY KEY, new INTEGER NOT NULL);
+	`
+	if _, err := conn.ExecContext(ctx, schema); err != nil {
+		return nil, fmt.Errorf("creating merge tables: %w", err)
+	}
+	defer conn.ExecContext(ctx, "DROP TABLE IF EXISTS temp.merge_cids; DROP TABLE IF EXISTS temp.merge_won; DROP TABLE IF EXISTS temp.merge_details; DROP TABLE IF EXISTS temp.merge_sessions")
+
+	var sources []MergeSource
+	var search *model.SearchParams // the search the merged ledger belongs to
+	for i, path := range paths {
+		snap, err := snapshot(path, filepath.Join(dir, fmt.Sprintf("source%d.db", i)))
+		if err != nil {
+			return sources, fmt.Errorf("reading %s: %w", path, err)
+		}
+		searches, err := snapshotSearches(snap)
+		if err != nil {
+			os.Remove(snap)
+			return sources, fmt.Errorf("reading %s: %w", path, err)
+		}
+		// A ledger of no known grid, or of several, is left out
+		ledger := len(searches) == 1 && (search == nil || reflect.DeepEqual(*search, searches[0]))
+		if ledger && search == nil {
+			search = &searches[0]
+		}
+		src, err := mergeSource(ctx, conn, i, path, snap, won, ledger)
+		os.Remove(snap)
+		if err != nil {
+			return sources, fmt.Errorf("merging %s: %w", path, err)
+		}
+		sources = append(sources, *src)
+	}
+
+	// Session IDs changed, so first and last seen follow the copied sightings
+	_, err = conn.ExecContext(ctx, `
+		UPDATE places SET
+			first_seen = (SELECT MIN(session_id) FROM place_sightings WHERE cid = places.cid),
+			last_seen = (SELECT MAX(session_id) FROM place_sightings WHERE cid = places.cid)`)
+	if err != nil {
+		return sources, fmt.Errorf("updating sightings: %w", err)
+	}
+	return sources, nil
+}
+
+// snapshot copies the database at path to dst and brings the copy up to
+// the current schema, leaving the source untouched.
+func snapshot(path, dst string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("opening db: %w", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return "", fmt.Errorf("opening db: %w", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("PRAGMA busy_timeout=5000"); err != nil {
+		return "", fmt.Errorf("setting busy timeout: %w", err)
+	}
+	if _, err := db.Exec("VACUUM INTO ?", dst); err != nil {
+		return "", fmt.Errorf("copying db: %w", err)
+	}
+
+	cp, err := sql.Open("sqlite", dst)
+	if err != nil {
+		return "", fmt.Errorf("opening copy: %w", err)
+	}
+	defer cp.Close()
+	if _, err := Migrate(cp); err != nil {
+		return "", err
+	}
+	return dst, nil
+}
+
+// snapshotSearches reads the searches the job ledger of a snapshot belongs
+// to.
+func snapshotSearches(snap string) ([]model.SearchParams, error) {
+	db, err := sql.Open("sqlite", snap)
+	if err != nil {
+		return nil, fmt.Errorf("opening copy: %w", err)
+	}
+	defer db.Close()
+	return ledgerSearches(db)
+}
+
+// mergeSource merges the snapshot of the source at path, the index-th of
+// the merge, into the main database of conn. won is the condition under
+// which its copy p of a shared place m is preferred; ledger whether its
+// scan jobs are carried over.
+func mergeSource(ctx context.Context, conn *sql.Conn, index int, path, snap, won string, ledger bool) (*MergeSource, error) {
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS src", snap); err != nil {
+		return nil, fmt.Errorf("attaching db: %w", err)
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE src")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	m := &MergeSource{Path: path, Overlaps: make(map[int]int), Ledger: ledger}
+
+	// Report what the source shares before any of it is merged
+	err = tx.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(cid NOT IN (SELECT cid FROM main.places)), 0) FROM src.places`).Scan(&m.Places, &m.New)
+	if err != nil {
+		return nil, fmt.Errorf("counting places: %w", err)
+	}
+	rows, err := tx.Query(`
+		SELECT c.source, COUNT(*) FROM temp.merge_cids c JOIN src.places p ON p.cid = c.cid GROUP BY c.source`)
+	if err != nil {
+		return nil, fmt.Errorf("counting overlaps: %w", err)
+	}
+	for rows.Next() {
+		var source, n int
+		if err := rows.Scan(&source, &n); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scanning overlap: %w", err)
+		}
+		m.Overlaps[source] = n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("counting overlaps: %w", err)
+	}
+	err = tx.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(`+won+`), 0) FROM src.places p JOIN main.places m ON m.cid = p.cid
+		WHERE `+conflictExpr("p", "m")).Scan(&m.Conflicts, &m.Taken)
+	if err != nil {
+		return nil, fmt.Errorf("counting conflicts: %w", err)
+	}
+
+	steps := []struct {
+		what, query string
+		args        []any
+	}{
+		{"picking places", `DELETE FROM temp.merge_won`, nil},
+		{"picking places", `
+			INSERT INTO temp.merge_won (cid)
+			SELECT p.cid FROM src.places p JOIN main.places m ON m.cid = p.cid WHERE ` + won, nil},
+
+		// Shared places take the preferred copy's fields, then fill the
+		// fields still empty from the other one
+		{"updating places", `
+			UPDATE main.places SET ` + coalesceSet("p", "places") + `, lat = p.lat, lng = p.lng
+			FROM src.places p
+			WHERE p.cid = places.cid AND places.cid IN (SELECT cid FROM temp.merge_won)`, nil},
+		{"updating places", `
+			UPDATE main.places SET ` + coalesceSet("places", "p") + `,
+				created_at = MIN(places.created_at, p.created_at),
+				updated_at = MAX(places.updated_at, p.updated_at)
+			FROM src.places p
+			WHERE p.cid = places.cid`, nil},
+		{"inserting places", `
+			INSERT INTO main.places (` + placeColumns + `, created_at, updated_at)
+			SELECT ` + placeColumns + `, created_at, updated_at FROM src.places
+			WHERE cid NOT IN (SELECT cid FROM main.places)`, nil},
+		{"recording places", `INSERT OR IGNORE INTO temp.merge_cids (source, cid) SELECT ?, cid FROM src.places`, []any{index}},
+		{"merging place queries", `
+			INSERT OR IGNORE INTO main.place_queries (cid, query, sector_lat, sector_lng, sector_zoom, page, rank, found_at)
+			SELECT cid, query, sector_lat, sector_lng, sector_zoom, page, rank, found_at FROM src.place_queries`, nil},
+
+		// Hours and phones are parsed from the place fields, so they follow
+		// the copy the place took
+		{"merging opening hours", `
+			DELETE FROM main.opening_hours
+			WHERE cid IN (SELECT cid FROM temp.merge_won) AND cid IN (SELECT cid FROM src.opening_hours)`, nil},
+		{"merging opening hours", `
+			INSERT INTO main.opening_hours (cid, day, ord, open, close, overnight, all_day, closed)
+			SELECT cid, day, ord, open, close, overnight, all_day, closed FROM src.opening_hours
+			WHERE cid NOT IN (SELECT cid FROM main.opening_hours)`, nil},
+		{"merging phones", `
+			DELETE FROM main.phones
+			WHERE cid IN (SELECT cid FROM temp.merge_won) AND cid IN (SELECT cid FROM src.phones)`, nil},
+		{"merging phones", `
+			INSERT OR IGNORE INTO main.phones (cid, raw, e164, region, valid, type)
+			SELECT cid, raw, e164, region, valid, type FROM src.phones`, nil},
+
+		// Details of a place come together from one fetch, the latest
+		{"merging details", `DELETE FROM temp.merge_details`, nil},
+		{"merging details", `
+			INSERT INTO temp.merge_details (cid)
+			SELECT d.cid FROM src.place_details d LEFT JOIN main.place_details m ON m.cid = d.cid
+			WHERE m.cid IS NULL OR d.fetched_at > m.fetched_at`, nil},
+		{"merging details", `DELETE FROM main.place_details WHERE cid IN (SELECT cid FROM temp.merge_details)`, nil},
+		{"merging details", `DELETE FROM main.place_hours WHERE cid IN (SELECT cid FROM temp.merge_details)`, nil},
+		{"merging details", `DELETE FROM main.place_popular_times WHERE cid IN (SELECT cid FROM temp.merge_details)`, nil},
+		{"merging details", `DELETE FROM main.place_attributes WHERE cid IN (SELECT cid FROM temp.merge_details)`, nil},
+		{"merging details", `
+			INSERT INTO main.place_details (cid, place_id, status, status_text, timezone, plus_code, fetched_at)
+			SELECT cid, place_id, status, status_text, timezone, plus_code, fetched_at FROM src.place_details
+			WHERE cid IN (SELECT cid FROM temp.merge_details)`, nil},
+		{"merging details", `
+			INSERT INTO main.place_hours (cid, ord, day, hours)
+			SELECT cid, ord, day, hours FROM src.place_hours WHERE cid IN (SELECT cid FROM temp.merge_details)`, nil},
+		{"merging details", `
+			INSERT INTO main.place_popular_times (cid, day, hour, busyness)
+			SELECT cid, day, hour, busyness FROM src.place_popular_times WHERE cid IN (SELECT cid FROM temp.merge_details)`, nil},
+		{"merging details", `
+			INSERT INTO main.place_attributes (cid, grp, name, available)
+			SELECT cid, grp, name, available FROM src.place_attributes WHERE cid IN (SELECT cid FROM temp.merge_details)`, nil},
+
+		// Ledgers keep a job done in any source done
+		{"merging enrich jobs", mergeLedger("enrich_jobs", "cid", "place_id, status, attempts, last_error, updated_at"), nil},
+		{"merging review jobs", mergeLedger("review_jobs", "cid", "status, attempts, last_error, updated_at"), nil},
+		{"merging contact jobs", mergeLedger("contact_jobs", "cid", "website, status, attempts, pages, last_error, updated_at"), nil},
+		{"merging scan parameters", `INSERT OR IGNORE INTO main.scan_meta (key, value) SELECT key, value FROM src.scan_meta`, nil},
+	}
+	for _, st := range steps {
+		if _, err := tx.Exec(st.query, st.args...); err != nil {
+			return nil, fmt.Errorf("%s: %w", st.what, err)
+		}
+	}
+	if ledger {
+		_, err := tx.Exec(mergeLedger("jobs", "zoom, sector_row, sector_col, query",
+			"lat, lng, span, page, status, attempts, last_error, updated_at"))
+		if err != nil {
+			return nil, fmt.Errorf("merging jobs: %w", err)
+		}
+	}
+
+	res, err := tx.Exec(`
+		INSERT OR IGNORE INTO main.reviews (cid, review_id, author, rating, text, language, published, relative_date, owner_response, fetched_at)
+		SELECT cid, review_id, author, rating, text, language, published, relative_date, owner_response, fetched_at
+		FROM src.reviews ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("merging reviews: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	m.Reviews = int(n)
+
+	res, err = tx.Exec(`
+		INSERT OR IGNORE INTO main.contacts (cid, kind, value, network, source_url, found_at)
+		SELECT cid, kind, value, network, source_url, found_at FROM src.contacts`)
+	if err != nil {
+		return nil, fmt.Errorf("merging contacts: %w", err)
+	}
+	n, _ = res.RowsAffected()
+	m.Contacts = int(n)
+
+	if m.Sessions, err = mergeSessions(tx, filepath.Base(path)); err != nil {
+		return nil, err
+	}
+
+	// Coverage follows its sessions to their new IDs
+	_, err = tx.Exec(`
+		INSERT INTO main.field_coverage (session_id, field, filled, total, alert, updated_at)
+		SELECT COALESCE(m.new, 0), c.field, c.filled, c.total, c.alert, c.updated_at
+		FROM src.field_coverage c LEFT JOIN temp.merge_sessions m ON m.old = c.session_id
+		WHERE true
+		ON CONFLICT(session_id, field) DO UPDATE SET
+			filled = filled + excluded.filled,
+			total = total + excluded.total,
+			alert = COALESCE(excluded.alert, alert),
+			updated_at = MAX(updated_at, excluded.updated_at)`)
+	if err != nil {
+		return nil, fmt.Errorf("merging coverage: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing tx: %w", err)
+	}
+	return m, nil
+}
+
+// mergeLedger upserts the rows of a ledger table from src, keyed by key.
+// A job already stored takes the source's row only when that one is done
+// and the stored one is not.
+func mergeLedger(table, key, cols string) string {
+	var sets []string
+	for _, c := range strings.Split(cols, ", ") {
+		sets = append(sets, c+" = excluded."+c)
+	}
+	return fmt.Sprintf(`
+		INSERT INTO main.%[1]s (%[2]s, %[3]s)
+		SELECT %[2]s, %[3]s FROM src.%[1]s WHERE true
+		ON CONFLICT(%[2]s) DO UPDATE SET %[4]s
+		WHERE excluded.status = '%[5]s' AND status != '%[5]s'`,
+		table, key, cols, strings.Join(sets, ", "), JobDone)
+}
+
+// mergeSessions copies the sessions of src with new IDs, keeping the
+// origin of sessions src merged itself, and moves its sightings and place
+// history to the new IDs. It returns the number of sessions copied.
+func mergeSessions(tx *sql.Tx, origin string) (int, error) {
+	if _, err := tx.Exec("DELETE FROM temp.merge_sessions"); err != nil {
+		return 0, fmt.Errorf("merging sessions: %w", err)
+	}
+
+	rows, err := tx.Query("SELECT id FROM src.scan_sessions ORDER BY id")
+	if err != nil {
+		return 0, fmt.Errorf("querying sessions: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scanning session: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("querying sessions: %w", err)
+	}
+
+	for _, id := range ids {
+		res, err := tx.Exec(`
+			INSERT INTO main.scan_sessions (kind, source, params, status, started_at, ended_at,
+				sectors_total, sectors_done, subdivided, found, stored, errors, rate_limits, last_error, origin)
+			SELECT kind, source, params, status, started_at, ended_at,
+				sectors_total, sectors_done, subdivided, found, stored, errors, rate_limits, last_error, COALESCE(origin, ?)
+			FROM src.scan_sessions WHERE id = ?`, origin, id)
+		if err != nil {
+			return 0, fmt.Errorf("copying session %d: %w", id, err)
+		}
+		newID, err := res.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("copying session %d: %w", id, err)
+		}
+		if _, err := tx.Exec("INSERT INTO temp.merge_sessions (old, new) VALUES (?, ?)", id, newID); err != nil {
+			return 0, fmt.Errorf("copying session %d: %w", id, err)
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT OR IGNORE INTO main.place_sightings (session_id, cid)
+		SELECT m.new, s.cid FROM src.place_sightings s JOIN temp.merge_sessions m ON m.old = s.session_id`)
+	if err != nil {
+		return 0, fmt.Errorf("merging sightings: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO main.place_history (cid, session_id, field, old_value, new_value, changed_at)
+		SELECT h.cid, m.new, h.field, h.old_value, h.new_value, h.changed_at
+		FROM src.place_history h LEFT JOIN temp.merge_sessions m ON m.old = h.session_id
+		ORDER BY h.id`)
+	if err != nil {
+		return 0, fmt.Errorf("merging place history: %w", err)
+	}
+	return len(ids), nil
+}
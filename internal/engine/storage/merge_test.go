@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// newTestSource returns a project database that ran scans of earlier, then
+// one of params with a pending job per sector in its ledger but the done
+// ones.
+func newTestSource(t *testing.T, name string, params model.SearchParams, pending, done []model.Sector, earlier ...model.SearchParams) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer s.Close()
+	var id int64
+	for _, p := range append(earlier, params) {
+		if id, err = s.StartSession(SessionScan, "cli", p); err != nil {
+			t.Fatalf("StartSession: %v", err)
+		}
+	}
+	var jobs []JobRecord
+	for _, sec := range append(pending, done...) {
+		jobs = append(jobs, JobRecord{Sector: sec, Query: params.Queries[0]})
+	}
+	if err := s.EnqueueJobs(jobs); err != nil {
+		t.Fatalf("EnqueueJobs: %v", err)
+	}
+	for _, sec := range done {
+		if err := s.UpdateJob(sec, params.Queries[0], 0, JobDone, ""); err != nil {
+			t.Fatalf("UpdateJob: %v", err)
+		}
+	}
+	if err := s.FinishSession(id, SessionStats{}, nil); err != nil {
+		t.Fatalf("FinishSession: %v", err)
+	}
+	return path
+}
+
+func TestMergeLedger(t *testing.T) {
+	cell := func(row, col int) model.Sector {
+		return model.Sector{Zoom: 10, Row: row, Col: col, Lat: float64(row), Lng: float64(col), Span: 0.1}
+	}
+	chile := model.SearchParams{Queries: []string{"cafes"}, Country: "Chile", Zoom: 10, Concurrency: 10}
+	// The same search run with more workers on another machine
+	chileFast := chile
+	chileFast.Concurrency = 50
+	// The same cells and query over another country's grid
+	peru := model.SearchParams{Queries: []string{"cafes"}, Country: "Peru", Zoom: 10, Concurrency: 10}
+
+	north := newTestSource(t, "north.db", chile, []model.Sector{cell(0, 0), cell(0, 1)}, nil)
+	// Its ledger belongs to its latest scan, whatever it scanned before
+	south := newTestSource(t, "south.db", chileFast, nil, []model.Sector{cell(0, 1), cell(0, 2)}, peru)
+	other := newTestSource(t, "peru.db", peru, nil, []model.Sector{cell(0, 0)})
+	mixed := filepath.Join(t.TempDir(), "mixed.db")
+	ms, err := NewStore(mixed)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	_, err = ms.Merge([]string{south, other}, PreferRecent)
+	ms.Close()
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		sources []string
+		ledgers []bool
+		counts  map[string]int
+		resume  bool
+	}{
+		{"same search", []string{north, south}, []bool{true, true},
+			map[string]int{JobPending: 1, JobDone: 2}, true},
+		{"different searches", []string{north, south, other}, []bool{true, true, false},
+			map[string]int{JobPending: 1, JobDone: 2}, false},
+		{"first search wins", []string{other, north}, []bool{true, false},
+			map[string]int{JobDone: 1}, false},
+		{"merge of different searches", []string{north, mixed}, []bool{true, false},
+			map[string]int{JobPending: 2}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			merged, err := s.Merge(tt.sources, PreferRecent)
+			if err != nil {
+				t.Fatalf("Merge: %v", err)
+			}
+			for i, m := range merged {
+				if m.Ledger != tt.ledgers[i] {
+					t.Errorf("source %d ledger carried over = %v, want %v", i, m.Ledger, tt.ledgers[i])
+				}
+			}
+
+			// A job done in another grid does not mark the same cell done here
+			counts, err := s.JobCounts()
+			if err != nil {
+				t.Fatalf("JobCounts: %v", err)
+			}
+			for status, n := range tt.counts {
+				if counts[status] != n {
+					t.Errorf("%s jobs = %d, want %d (%v)", status, counts[status], n, counts)
+				}
+			}
+			if Unfinished(counts) != tt.counts[JobPending] {
+				t.Errorf("unfinished jobs = %d, want %d", Unfinished(counts), tt.counts[JobPending])
+			}
+
+			p, err := s.ResumeParams()
+			if !tt.resume {
+				if err == nil || !strings.Contains(err.Error(), "2 different searches") {
+					t.Errorf("ResumeParams = %+v, %v, want the different searches refused", p, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResumeParams: %v", err)
+			}
+			if p.Country != "Chile" || p.Queries[0] != "cafes" {
+				t.Errorf("ResumeParams = %+v, want the merged search", p)
+			}
+		})
+	}
+
+	// A scan run on the merged project starts a ledger of its own
+	s := newTestStore(t)
+	if _, err := s.Merge([]string{north, other}, PreferRecent); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	id, err := s.StartSession(SessionScan, "cli", peru)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := s.FinishSession(id, SessionStats{}, nil); err != nil {
+		t.Fatalf("FinishSession: %v", err)
+	}
+	if p, err := s.ResumeParams(); err != nil || p.Country != "Peru" {
+		t.Errorf("ResumeParams after a scan = %+v, %v, want the scan's", p, err)
+	}
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Migration is one step of the project database schema. Migrations are
+// applied in version order, each in its own transaction, and recorded in
+// the schema_version table.
+type Migration struct {
+	Version int
+	Name    string
+	up      func(tx *sql.Tx) error
+}
+
+// migrations is the schema history. Append new steps at the end; never
+// edit or reorder applied ones. The first eight create their tables with
+// IF NOT EXISTS, so databases written before schema_version existed, which
+// start at version 0, replay them safely.
+var migrations = []Migration{
+	{1, "businesses table", createBusinessesSchema},
+	{2, "job ledger and scan parameters", createJobsSchema},
+	{3, "field coverage", createCoverageSchema},
+	{4, "place details and enrich ledger", createDetailsSchema},
+	{5, "reviews and review ledger", createReviewsSchema},
+	{6, "opening hours", createHoursSchema},
+	{7, "website contacts and contacts ledger", createContactsSchema},
+	{8, "normalized phones", createPhonesSchema},
+	{9, "one row per place with place_queries", createPlacesSchema},
+	{10, "scan sessions", createSessionsSchema},
+	{11, "place sightings and history", createHistorySchema},
+	{12, "session origin", createSessionOriginSchema},
+	{13, "redacted proxy credentials", redactStoredParams},
+	{14, "field coverage per session", keyCoverageBySession},
+}
+
+// LatestVersion is the schema version this build writes.
+func LatestVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+func createVersionSchema(db *sql.DB) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS schema_version (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		return fmt.Errorf("creating schema_version table: %w", err)
+	}
+	return nil
+}
+
+// SchemaVersion returns the highest migration applied to db, 0 for
+// databases from before versioning.
+func SchemaVersion(db *sql.DB) (int, error) {
+	if err := createVersionSchema(db); err != nil {
+		return 0, err
+	}
+	var v int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&v); err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return v, nil
+}
+
+// CheckVersion fails unless db is at the latest schema. It does not write
+// to db, so tools that only read a project database can check it as is.
+func CheckVersion(db *sql.DB) error {
+	var n, v int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_version'").Scan(&n); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	if n > 0 {
+		if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&v); err != nil {
+			return fmt.Errorf("reading schema version: %w", err)
+		}
+	}
+	switch {
+	case v > LatestVersion():
+		return fmt.Errorf("database schema version %d is newer than this geotap supports (%d)", v, LatestVersion())
+	case v < LatestVersion():
+		return fmt.Errorf("database schema version %d is older than this geotap's (%d)", v, LatestVersion())
+	}
+	return nil
+}
+
+// PendingMigrations returns the migrations not yet applied to db. It fails
+// when db was written by a newer geotap.
+func PendingMigrations(db *sql.DB) ([]Migration, error) {
+	v, err := SchemaVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if v > LatestVersion() {
+		return nil, fmt.Errorf("database schema version %d is newer than this geotap supports (%d)", v, LatestVersion())
+	}
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > v {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate brings db up to the latest schema and returns the migrations it
+// applied. NewStore runs it on open, and diff before reading a project
+// database; export only reads one at the latest version (see CheckVersion).
+func Migrate(db *sql.DB) ([]Migration, error) {
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	for _, m := range pending {
+		ok, err := apply(db, m)
+		if err != nil {
+			return applied, err
+		}
+		if ok {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// apply runs one migration and records it. It reports false when another
+// process applied the migration first.
+func apply(db *sql.DB, m Migration) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("beginning tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var done int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM schema_version WHERE version = ?", m.Version).Scan(&done); err != nil {
+		return false, fmt.Errorf("reading schema version: %w", err)
+	}
+	if done > 0 {
+		return false, nil
+	}
+	if err := m.up(tx); err != nil {
+		return false, fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+	}
+	if _, err := tx.Exec("INSERT INTO schema_version (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+		return false, fmt.Errorf("recording migration %d: %w", m.Version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("committing migration %d: %w", m.Version, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+func createPhonesSchema(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS phones (
+		cid TEXT PRIMARY KEY,
+		raw TEXT NOT NULL,
+		e164 TEXT,
+		region TEXT,
+		valid INTEGER NOT NULL DEFAULT 0,
+		type TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_phones_e164 ON phones(e164);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("creating phones schema: %w", err)
+	}
+	return nil
+}
+
+// savePhone stores the parsed phone of a place. A nil phone leaves what is
+// stored untouched.
+func savePhone(tx *sql.Tx, cid string, p *model.PhoneNumber) error {
+	if cid == "" || p == nil {
+		return nil
+	}
+	_, err := tx.Exec(`
+		INSERT OR REPLACE INTO phones (cid, raw, e164, region, valid, type)
+		VALUES (?,?,?,?,?,?)`,
+		cid, p.Raw, p.E164, p.Region, p.Valid, p.Type)
+	if err != nil {
+		return fmt.Errorf("saving phone: %w", err)
+	}
+	return nil
+}
+
+// LoadPhones reads every stored parsed phone, by CID. It is a plain function
+// over db so read-only tools can use it without a Store.
+func LoadPhones(db *sql.DB) (map[string]*model.PhoneNumber, error) {
+	rows, err := db.Query(`
+		SELECT cid, raw, COALESCE(e164, ''), COALESCE(region, ''), valid, COALESCE(type, '')
+		FROM phones`)
+	if err != nil {
+		return nil, fmt.Errorf("querying phones: %w", err)
+	}
+	defer rows.Close()
+
+	phones := make(map[string]*model.PhoneNumber)
+	for rows.Next() {
+		var cid string
+		var p model.PhoneNumber
+		if err := rows.Scan(&cid, &p.Raw, &p.E164, &p.Region, &p.Valid, &p.Type); err != nil {
+			return nil, fmt.Errorf("scanning phone: %w", err)
+		}
+		phones[cid] = &p
+	}
+	return phones, rows.Err()
+}
@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// businessesView keeps the one-row-per-place-and-query shape of the old
+// businesses table readable for external tools and SQL written against it.
+const businessesView = `
+	CREATE VIEW IF NOT EXISTS businesses AS
+	SELECT p.rowid AS id, p.name, p.rating, p.review_count, p.category, p.address, p.price_range,
+	       p.lat, p.lng, p.cid, p.phone, p.website, p.google_url, p.description, p.place_id,
+	       p.open_hours, p.thumbnail, p.categories, p.city, p.postal_code, p.country_code,
+	       q.query, q.found_at AS created_at
+	FROM places p JOIN place_queries q ON q.cid = p.cid`
+
+// createBusinessesSchema is the first schema of a project database: a row
+// per place and query, deduplicated by UNIQUE(cid, query). Later migrations
+// replace it with places and place_queries; on databases that already went
+// through them, where businesses is a view, it does nothing.
+func createBusinessesSchema(tx *sql.Tx) error {
+	var n int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'businesses'").Scan(&n); err != nil {
+		return fmt.Errorf("checking businesses table: %w", err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS businesses (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		rating REAL,
+		review_count INTEGER,
+		category TEXT,
+		address TEXT,
+		price_range TEXT,
+		lat REAL NOT NULL,
+		lng REAL NOT NULL,
+		cid TEXT,
+		phone TEXT,
+		website TEXT,
+		google_url TEXT,
+		description TEXT,
+		place_id TEXT,
+		open_hours TEXT,
+		thumbnail TEXT,
+		categories TEXT,
+		city TEXT,
+		postal_code TEXT,
+		country_code TEXT,
+		query TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(cid, query)
+	);
+	CREATE INDEX IF NOT EXISTS idx_businesses_query ON businesses(query);
+	CREATE INDEX IF NOT EXISTS idx_businesses_rating ON businesses(rating);
+	CREATE INDEX IF NOT EXISTS idx_businesses_coords ON businesses(lat, lng);
+	CREATE INDEX IF NOT EXISTS idx_businesses_city ON businesses(city);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("creating schema: %w", err)
+	}
+	return nil
+}
+
+// migrateBusinesses moves a database from the businesses table, which
+// stored a place once per query it matched, to places and place_queries,
+// then replaces the table with a view of the same shape. Each field of a
+// place takes the most recent of its rows that has it filled, so a field
+// one query's response left empty is not lost. Rows without a CID cannot
+// be keyed as a place, and later stages send the CID to Google, so they are
+// set aside unchanged in businesses_without_cid. It only creates the view
+// when there is no businesses table.
+func migrateBusinesses(tx *sql.Tx) error {
+	var kind string
+	err := tx.QueryRow("SELECT type FROM sqlite_master WHERE name = 'businesses'").Scan(&kind)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("checking businesses table: %w", err)
+	}
+	if kind != "table" {
+		if _, err := tx.Exec(businessesView); err != nil {
+			return fmt.Errorf("creating businesses view: %w", err)
+		}
+		return nil
+	}
+
+	// Newest non-empty value of each column among the rows of a place
+	var cols []string
+	for _, c := range strings.Split(placeColumns, ",") {
+		c = strings.TrimSpace(c)
+		switch c {
+		case "cid":
+			cols = append(cols, "k.cid")
+			continue
+		case "name":
+			cols = append(cols, "COALESCE("+newestFilled(c, "!= ''")+", '')")
+		case "lat", "lng":
+			cols = append(cols, "COALESCE("+newestFilled(c, "!= 0")+", 0)")
+		case "rating", "review_count":
+			cols = append(cols, newestFilled(c, "!= 0"))
+		default:
+			cols = append(cols, newestFilled(c, "!= ''"))
+		}
+	}
+
+	steps := []string{
+		`CREATE TABLE IF NOT EXISTS businesses_without_cid AS
+		SELECT * FROM businesses WHERE cid IS NULL OR cid = ''`,
+		`DROP TABLE IF EXISTS temp.migrate_rows`,
+		`CREATE TEMP TABLE migrate_rows AS
+		SELECT * FROM businesses WHERE cid IS NOT NULL AND cid != ''`,
+		`CREATE INDEX temp.idx_migrate_rows_cid ON migrate_rows(cid, id)`,
+		`INSERT INTO places (` + placeColumns + `, created_at, updated_at)
+		SELECT ` + strings.Join(cols, ", ") + `, k.created_at, k.updated_at
+		FROM (SELECT cid, MIN(created_at) AS created_at, MAX(created_at) AS updated_at
+		      FROM temp.migrate_rows GROUP BY cid) k`,
+		`INSERT OR IGNORE INTO place_queries (cid, query, found_at)
+		SELECT cid, query, created_at FROM temp.migrate_rows ORDER BY id`,
+		`DROP TABLE temp.migrate_rows`,
+		`DROP TABLE businesses`,
+		businessesView,
+	}
+	for _, q := range steps {
+		if _, err := tx.Exec(q); err != nil {
+			return fmt.Errorf("migrating businesses to places: %w", err)
+		}
+	}
+	return nil
+}
+
+// newestFilled selects col from the most recent row of place k where col
+// satisfies filled.
+func newestFilled(col, filled string) string {
+	return fmt.Sprintf("(SELECT r.%[1]s FROM temp.migrate_rows r WHERE r.cid = k.cid AND r.%[1]s %[2]s ORDER BY r.id DESC LIMIT 1)", col, filled)
+}
+
+// LoadQueries reads the queries each place was found by, in the order they
+// first found it, by CID. It is a plain function over db so read-only tools
+// can use it without a Store.
+func LoadQueries(db *sql.DB) (map[string][]string, error) {
+	rows, err := db.Query("SELECT cid, query FROM place_queries ORDER BY found_at, rowid")
+	if err != nil {
+		return nil, fmt.Errorf("querying place queries: %w", err)
+	}
+	defer rows.Close()
+
+	queries := make(map[string][]string)
+	for rows.Next() {
+		var cid, query string
+		if err := rows.Scan(&cid, &query); err != nil {
+			return nil, fmt.Errorf("scanning place query: %w", err)
+		}
+		queries[cid] = append(queries[cid], query)
+	}
+	return queries, rows.Err()
+}
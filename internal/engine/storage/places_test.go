@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateBusinesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("opening db: %v", err)
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("beginning tx: %v", err)
+	}
+	if err := createBusinessesSchema(tx); err != nil {
+		t.Fatalf("creating businesses: %v", err)
+	}
+	// A row per place and query, oldest first
+	rows := []struct {
+		name, cid, placeID, query, phone, website, created string
+		rating                                             float64
+		lat, lng                                           float64
+	}{
+		{"Cafe Sol", "1", "ChIJ1", "cafes", "+34 912 34 56 78", "", "2026-01-01 10:00:00", 4.1, 40.42, -3.70},
+		{"Café Sol", "1", "", "bars", "", "https://sol.example", "2026-01-02 10:00:00", 0, 40.42, -3.70},
+		{"Bar Luna", "", "ChIJ2", "bars", "+34 600 00 00 01", "", "2026-01-01 10:00:00", 3.9, 40.43, -3.71},
+		{"Bar Luna", "", "ChIJ2", "cafes", "", "", "2026-01-02 10:00:00", 4.0, 40.43, -3.71},
+		{"Kiosko", "", "", "cafes", "", "", "2026-01-01 10:00:00", 0, 40.44, -3.72},
+		{"Kiosko", "", "", "bars", "", "", "2026-01-02 10:00:00", 0, 40.44, -3.72},
+	}
+	for _, r := range rows {
+		_, err := tx.Exec(`INSERT INTO businesses (name, cid, place_id, query, phone, website, created_at, rating, lat, lng)
+			VALUES (?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?)`,
+			r.name, r.cid, r.placeID, r.query, r.phone, r.website, r.created, r.rating, r.lat, r.lng)
+		if err != nil {
+			t.Fatalf("inserting row: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("committing: %v", err)
+	}
+	db.Close()
+
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer s.Close()
+
+	type place struct {
+		name, placeID, phone, website string
+		rating                        float64
+		created, updated              string
+		queries                       int
+	}
+	// Fields merged across rows, newest filled first
+	want := map[string]place{
+		"1": {"Café Sol", "ChIJ1", "+34 912 34 56 78", "https://sol.example", 4.1, "2026-01-01 10:00:00", "2026-01-02 10:00:00", 2},
+	}
+	got := make(map[string]place)
+	res, err := s.db.Query(`
+		SELECT cid, name, COALESCE(place_id, ''), COALESCE(phone, ''), COALESCE(website, ''), COALESCE(rating, 0),
+			datetime(created_at), datetime(updated_at), (SELECT COUNT(*) FROM place_queries q WHERE q.cid = p.cid)
+		FROM places p`)
+	if err != nil {
+		t.Fatalf("querying places: %v", err)
+	}
+	defer res.Close()
+	for res.Next() {
+		var cid string
+		var p place
+		if err := res.Scan(&cid, &p.name, &p.placeID, &p.phone, &p.website, &p.rating, &p.created, &p.updated, &p.queries); err != nil {
+			t.Fatalf("scanning place: %v", err)
+		}
+		got[cid] = p
+	}
+	if len(got) != len(want) {
+		t.Errorf("migrated %d places, want %d: %v", len(got), len(want), got)
+	}
+	for cid, w := range want {
+		if got[cid] != w {
+			t.Errorf("place %s = %+v, want %+v", cid, got[cid], w)
+		}
+	}
+
+	// Rows without a CID are set aside as they were, not keyed as places
+	var aside int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM businesses_without_cid WHERE name IN ('Bar Luna', 'Kiosko')").Scan(&aside); err != nil || aside != 4 {
+		t.Errorf("%d rows set aside (%v), want 4", aside, err)
+	}
+	var queries int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM place_queries WHERE cid != '1'").Scan(&queries); err != nil || queries != 0 {
+		t.Errorf("%d place queries without a CID (%v), want none", queries, err)
+	}
+
+	var kind string
+	if err := s.db.QueryRow("SELECT type FROM sqlite_master WHERE name = 'businesses'").Scan(&kind); err != nil || kind != "view" {
+		t.Errorf("businesses is a %q (%v), want a view", kind, err)
+	}
+}
+
+func TestEnqueueSkipsKeysThatAreNotCIDs(t *testing.T) {
+	s := newTestStore(t)
+	// Keys earlier migrations gave places without a CID
+	for _, cid := range []string{"1234567890", "ChIJ2", "Kiosko@40.440000,-3.720000"} {
+		if _, err := s.db.Exec("INSERT INTO places (cid, name, lat, lng) VALUES (?, 'Place', 40.4, -3.7)", cid); err != nil {
+			t.Fatalf("inserting place: %v", err)
+		}
+	}
+	if _, err := s.db.Exec("INSERT INTO review_jobs (cid, status) VALUES ('ChIJ2', ?)", JobPending); err != nil {
+		t.Fatalf("inserting review job: %v", err)
+	}
+
+	if n, err := s.EnqueueEnrichJobs(); err != nil || n != 1 {
+		t.Errorf("EnqueueEnrichJobs = %d, %v, want 1", n, err)
+	}
+	if n, err := s.EnqueueReviewJobs(false); err != nil || n != 1 {
+		t.Errorf("EnqueueReviewJobs = %d, %v, want 1", n, err)
+	}
+	enrich, err := s.UnfinishedEnrichJobs()
+	if err != nil || len(enrich) != 1 || enrich[0].CID != "1234567890" {
+		t.Errorf("UnfinishedEnrichJobs = %+v, %v, want the CID only", enrich, err)
+	}
+	reviews, err := s.UnfinishedReviewJobs()
+	if err != nil || len(reviews) != 1 || reviews[0].CID != "1234567890" {
+		t.Errorf("UnfinishedReviewJobs = %+v, %v, want the CID only", reviews, err)
+	}
+	if counts, err := s.ReviewCounts(); err != nil || counts[JobPending] != 1 {
+		t.Errorf("ReviewCounts = %v, %v, want 1 pending", counts, err)
+	}
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// ReviewJob is a place queued for the review fetcher.
+type ReviewJob struct {
+	CID       string
+	Status    string // one of the Job* ledger statuses
+	Attempts  int
+	LastError string
+}
+
+func createReviewsSchema(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS reviews (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		cid TEXT NOT NULL,
+		review_id TEXT NOT NULL,
+		author TEXT,
+		rating INTEGER,
+		text TEXT,
+		language TEXT,
+		published TEXT,
+		relative_date TEXT,
+		owner_response TEXT,
+		fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(cid, review_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_reviews_cid ON reviews(cid);
+
+	CREATE TABLE IF NOT EXISTS review_jobs (
+		cid TEXT PRIMARY KEY,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_review_jobs_status ON review_jobs(status);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("creating reviews schema: %w", err)
+	}
+	return nil
+}
+
+// EnqueueReviewJobs adds every stored place with a CID (see lookupCID) to
+// the review ledger as pending and returns the number of places added. With refresh, places already done
+// are queued again, to pick up reviews posted since.
+func (s *Store) EnqueueReviewJobs(refresh bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		INSERT OR IGNORE INTO review_jobs (cid, status)
+		SELECT cid, ? FROM places WHERE `+lookupCID, JobPending)
+	if err != nil {
+		return 0, fmt.Errorf("enqueueing review jobs: %w", err)
+	}
+	n, _ := res.RowsAffected()
+
+	if refresh {
+		_, err := s.db.Exec(`
+			UPDATE review_jobs SET status = ?, attempts = 0, last_error = NULL, updated_at = CURRENT_TIMESTAMP
+			WHERE status = ?`, JobPending, JobDone)
+		if err != nil {
+			return int(n), fmt.Errorf("requeueing review jobs: %w", err)
+		}
+	}
+	return int(n), nil
+}
+
+// UnfinishedReviewJobs returns every review job not yet done.
+func (s *Store) UnfinishedReviewJobs() ([]ReviewJob, error) {
+	rows, err := s.db.Query(`
+		SELECT cid, status, attempts, COALESCE(last_error, '')
+		FROM review_jobs WHERE status != ? AND `+lookupCID+` ORDER BY rowid`, JobDone)
+	if err != nil {
+		return nil, fmt.Errorf("querying review jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []ReviewJob
+	for rows.Next() {
+		var j ReviewJob
+		if err := rows.Scan(&j.CID, &j.Status, &j.Attempts, &j.LastError); err != nil {
+			return nil, fmt.Errorf("scanning review job: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// UpdateReviewJob stores the outcome of a review fetch. Failed attempts
+// increment the attempt counter.
+func (s *Store) UpdateReviewJob(cid, status, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt := 0
+	if status == JobFailed {
+		attempt = 1
+	}
+	_, err := s.db.Exec(`
+		UPDATE review_jobs SET status = ?, attempts = attempts + ?, last_error = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE cid = ?`, status, attempt, lastErr, cid)
+	if err != nil {
+		return fmt.Errorf("updating review job: %w", err)
+	}
+	return nil
+}
+
+// ReviewCounts returns the number of review jobs per status.
+func (s *Store) ReviewCounts() (map[string]int, error) {
+	rows, err := s.db.Query("SELECT status, COUNT(*) FROM review_jobs WHERE " + lookupCID + " GROUP BY status")
+	if err != nil {
+		return nil, fmt.Errorf("counting review jobs: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// InsertReviews stores reviews, skipping ones already stored, and returns
+// how many were new.
+func (s *Store) InsertReviews(reviews []model.Review) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning tx: %w", err)
+	}
+	n, err := insertReviews(tx, reviews)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing tx: %w", err)
+	}
+	return n, nil
+}
+
+// insertReviews is InsertReviews within an open transaction.
+func insertReviews(tx *sql.Tx, reviews []model.Review) (int, error) {
+	if len(reviews) == 0 {
+		return 0, nil
+	}
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO reviews
+		(cid, review_id, author, rating, text, language, published, relative_date, owner_response)
+		VALUES (?,?,?,?,?,?,?,?,?)`)
+	if err != nil {
+		return 0, fmt.Errorf("preparing stmt: %w", err)
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, r := range reviews {
+		res, err := stmt.Exec(r.CID, r.ID, r.Author, r.Rating, r.Text, r.Language, r.Published, r.RelativeDate, r.OwnerResponse)
+		if err != nil {
+			return inserted, fmt.Errorf("saving review: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		inserted += int(n)
+	}
+	return inserted, nil
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+// Scan session kinds.
+const (
+	SessionScan   = "scan"
+	SessionResume = "resume"
+	SessionReplay = "replay"
+)
+
+// Scan session statuses.
+const (
+	SessionRunning     = "running" // still running, or the process died
+	SessionDone        = "done"
+	SessionIncomplete  = "incomplete" // finished with unfinished jobs left in the ledger
+	SessionInterrupted = "interrupted"
+	SessionFailed      = "failed"
+)
+
+// SessionStats are the final counters of a scan session.
+type SessionStats struct {
+	SectorsTotal int64
+	SectorsDone  int64
+	Subdivided   int64
+	Found        int64
+	Stored       int64
+	Errors       int64
+	RateLimits   int64
+}
+
+// Session is one run that wrote to a project database: the original scan,
+// each resume and replays, with the exact parameters it ran with.
+type Session struct {
+	ID        int64
+	Kind      string // one of the Session* kinds
+	Source    string // "cli", "tui", or "migration" for the scan of a database from before sessions
+	Origin    string // project database a merged session was copied from, empty for sessions run here
+	Params    model.SearchParams
+	Status    string // one of the Session* statuses
+	StartedAt time.Time
+	EndedAt   time.Time // zero while running
+	Stats     SessionStats
+	LastError string
+}
+
+// Duration is how long the session ran, up to now while it is running.
+func (s Session) Duration() time.Duration {
+	if s.EndedAt.IsZero() {
+		return time.Since(s.StartedAt)
+	}
+	return s.EndedAt.Sub(s.StartedAt)
+}
+
+func createSessionsSchema(tx *sql.Tx) error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS scan_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		source TEXT NOT NULL,
+		params TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'running',
+		started_at DATETIME NOT NULL,
+		ended_at DATETIME,
+		sectors_total INTEGER NOT NULL DEFAULT 0,
+		sectors_done INTEGER NOT NULL DEFAULT 0,
+		subdivided INTEGER NOT NULL DEFAULT 0,
+		found INTEGER NOT NULL DEFAULT 0,
+		stored INTEGER NOT NULL DEFAULT 0,
+		errors INTEGER NOT NULL DEFAULT 0,
+		rate_limits INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT
+	);
+	`
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("creating sessions schema: %w", err)
+	}
+	return nil
+}
+
+// createSessionOriginSchema records where sessions copied by a merge ran.
+func createSessionOriginSchema(tx *sql.Tx) error {
+	if _, err := tx.Exec("ALTER TABLE scan_sessions ADD COLUMN origin TEXT"); err != nil {
+		return fmt.Errorf("adding session origin: %w", err)
+	}
+	return nil
+}
+
+// StartSession records a session of kind started now from source with
+// params, proxy credentials redacted, and returns its ID for FinishSession.
+// Places InsertBatch stores from then on are marked seen by it.
+func (s *Store) StartSession(kind, source string, params model.SearchParams) (int64, error) {
+	data, err := json.Marshal(params.Redacted())
+	if err != nil {
+		return 0, fmt.Errorf("encoding params: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res, err := s.db.Exec("INSERT INTO scan_sessions (kind, source, params, status, started_at) VALUES (?,?,?,?,?)",
+		kind, source, string(data), SessionRunning, time.Now().UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("starting session: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("starting session: %w", err)
+	}
+	s.session = id
+	return id, nil
+}
+
+// FinishSession stores the end time and final counters of a session. Its
+// status follows from runErr, the error the run returned, and from the jobs
+// left unfinished in the ledger.
+func (s *Store) FinishSession(id int64, stats SessionStats, runErr error) error {
+	status, lastErr := SessionDone, ""
+	switch {
+	case errors.Is(runErr, context.Canceled):
+		status = SessionInterrupted
+	case runErr != nil:
+		status, lastErr = SessionFailed, runErr.Error()
+	default:
+		counts, err := s.JobCounts()
+		if err != nil {
+			return err
+		}
+		if Unfinished(counts) > 0 {
+			status = SessionIncomplete
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec(`
+		UPDATE scan_sessions SET status = ?, ended_at = ?, sectors_total = ?, sectors_done = ?, subdivided = ?,
+			found = ?, stored = ?, errors = ?, rate_limits = ?, last_error = ?
+		WHERE id = ?`,
+		status, time.Now().UTC().Format(time.DateTime), stats.SectorsTotal, stats.SectorsDone, stats.Subdivided,
+		stats.Found, stats.Stored, stats.Errors, stats.RateLimits, lastErr, id)
+	if err != nil {
+		return fmt.Errorf("finishing session: %w", err)
+	}
+	return nil
+}
+
+// LoadSessions reads the sessions of a project database, oldest first. It
+// is a plain function over db so read-only tools can use it without a
+// Store.
+func LoadSessions(db *sql.DB) ([]Session, error) {
+	rows, err := db.Query(`
+		SELECT id, kind, source, COALESCE(origin, ''), params, status, started_at, ended_at,
+		       sectors_total, sectors_done, subdivided, found, stored, errors, rate_limits, COALESCE(last_error, '')
+		FROM scan_sessions ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("querying sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		var ss Session
+		var params string
+		var ended sql.NullTime
+		err := rows.Scan(&ss.ID, &ss.Kind, &ss.Source, &ss.Origin, &params, &ss.Status, &ss.StartedAt, &ended,
+			&ss.Stats.SectorsTotal, &ss.Stats.SectorsDone, &ss.Stats.Subdivided, &ss.Stats.Found,
+			&ss.Stats.Stored, &ss.Stats.Errors, &ss.Stats.RateLimits, &ss.LastError)
+		if err != nil {
+			return nil, fmt.Errorf("scanning session: %w", err)
+		}
+		if err := json.Unmarshal([]byte(params), &ss.Params); err != nil {
+			return nil, fmt.Errorf("decoding session %d params: %w", ss.ID, err)
+		}
+		ss.EndedAt = ended.Time
+		sessions = append(sessions, ss)
+	}
+	return sessions, rows.Err()
+}
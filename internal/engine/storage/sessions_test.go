@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rendis/geotap/internal/model"
+)
+
+func TestSessionParams(t *testing.T) {
+	s := newTestStore(t)
+
+	// Before any session, the params SaveParams stored
+	legacy := model.SearchParams{Queries: []string{"hotels"}, Country: "Chile", Zoom: 10}
+	if err := s.SaveParams(legacy); err != nil {
+		t.Fatalf("SaveParams: %v", err)
+	}
+	if p, err := s.LoadParams(); err != nil || p.Queries[0] != "hotels" {
+		t.Fatalf("LoadParams = %+v, %v, want the saved params", p, err)
+	}
+
+	first := model.SearchParams{Queries: []string{"cafes"}, Country: "Spain", Zoom: 12, Concurrency: 10}
+	rescan := model.SearchParams{Queries: []string{"bars"}, Country: "Spain", Region: "Madrid", Zoom: 14, Concurrency: 10}
+	resumed := rescan
+	resumed.Concurrency = 50
+
+	runs := []struct {
+		kind   string
+		params model.SearchParams
+		err    error
+	}{
+		{SessionScan, first, nil},
+		{SessionScan, rescan, context.Canceled},
+		{SessionResume, resumed, nil},
+	}
+	for _, r := range runs {
+		id, err := s.StartSession(r.kind, "cli", r.params)
+		if err != nil {
+			t.Fatalf("StartSession: %v", err)
+		}
+		if err := s.FinishSession(id, SessionStats{SectorsTotal: 4, SectorsDone: 4}, r.err); err != nil {
+			t.Fatalf("FinishSession: %v", err)
+		}
+	}
+
+	// The resume does not replace the params of the scan it resumed, and
+	// the latest scan, which the ledger belongs to, wins over the first
+	p, err := s.LoadParams()
+	if err != nil {
+		t.Fatalf("LoadParams: %v", err)
+	}
+	if p.Queries[0] != "bars" || p.Region != "Madrid" || p.Zoom != 14 || p.Concurrency != 10 {
+		t.Errorf("LoadParams = %+v, want the rescan's", p)
+	}
+
+	sessions, err := LoadSessions(s.db)
+	if err != nil {
+		t.Fatalf("LoadSessions: %v", err)
+	}
+	if len(sessions) != len(runs) {
+		t.Fatalf("%d sessions, want %d", len(sessions), len(runs))
+	}
+	for i, r := range runs {
+		got := sessions[i]
+		if got.Kind != r.kind || got.Source != "cli" || got.Params.Queries[0] != r.params.Queries[0] || got.Params.Concurrency != r.params.Concurrency {
+			t.Errorf("session %d = %s %s %+v, want %s with its own params", i, got.Kind, got.Source, got.Params, r.kind)
+		}
+		if got.EndedAt.IsZero() || got.Stats.SectorsDone != 4 {
+			t.Errorf("session %d not finished: %+v", i, got)
+		}
+	}
+	if sessions[1].Status != SessionInterrupted || sessions[2].Status != SessionDone {
+		t.Errorf("statuses = %s, %s, want %s, %s", sessions[1].Status, sessions[2].Status, SessionInterrupted, SessionDone)
+	}
+}
@@ -13,6 +13,8 @@ import (
 type Store struct {
 	db *sql.DB
 	mu sync.Mutex
+
+	session int64 // scan session InsertBatch records sightings for, 0 outside one
 }
 
 func NewStore(dbPath string) (*Store, error) {
@@ -34,17 +36,26 @@ func NewStore(dbPath string) (*Store, error) {
 		}
 	}
 
-	if err := createSchema(db); err != nil {
+	if _, err := Migrate(db); err != nil {
 		return nil, err
 	}
 
 	return &Store{db: db}, nil
 }
 
-func createSchema(db *sql.DB) error {
+// placeColumns are the business fields stored per place, in the order
+// InsertBatch binds them.
+const placeColumns = `name, rating, review_count, category, address, price_range, lat, lng, cid,
+	phone, website, google_url, description, place_id,
+	open_hours, thumbnail, categories, city, postal_code, country_code`
+
+// createPlacesSchema stores places once per CID, with the queries that
+// found them in place_queries, and moves the rows of the businesses table
+// there.
+func createPlacesSchema(tx *sql.Tx) error {
 	schema := `
-	CREATE TABLE IF NOT EXISTS businesses (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
+	CREATE TABLE IF NOT EXISTS places (
+		cid TEXT PRIMARY KEY,
 		name TEXT NOT NULL,
 		rating REAL,
 		review_count INTEGER,
@@ -53,7 +64,6 @@ func createSchema(db *sql.DB) error {
 		price_range TEXT,
 		lat REAL NOT NULL,
 		lng REAL NOT NULL,
-		cid TEXT,
 		phone TEXT,
 		website TEXT,
 		google_url TEXT,
@@ -65,23 +75,47 @@ func createSchema(db *sql.DB) error {
 		city TEXT,
 		postal_code TEXT,
 		country_code TEXT,
-		query TEXT NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		UNIQUE(cid, query)
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
-	CREATE INDEX IF NOT EXISTS idx_businesses_query ON businesses(query);
-	CREATE INDEX IF NOT EXISTS idx_businesses_rating ON businesses(rating);
-	CREATE INDEX IF NOT EXISTS idx_businesses_coords ON businesses(lat, lng);
-	CREATE INDEX IF NOT EXISTS idx_businesses_city ON businesses(city);
+	CREATE INDEX IF NOT EXISTS idx_places_rating ON places(rating);
+	CREATE INDEX IF NOT EXISTS idx_places_coords ON places(lat, lng);
+	CREATE INDEX IF NOT EXISTS idx_places_city ON places(city);
+
+	CREATE TABLE IF NOT EXISTS place_queries (
+		cid TEXT NOT NULL,
+		query TEXT NOT NULL,
+		sector_lat REAL,
+		sector_lng REAL,
+		sector_zoom INTEGER,
+		page INTEGER,
+		rank INTEGER,
+		found_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (cid, query)
+	);
+	CREATE INDEX IF NOT EXISTS idx_place_queries_query ON place_queries(query);
 	`
-	_, err := db.Exec(schema)
-	if err != nil {
+	if _, err := tx.Exec(schema); err != nil {
 		return fmt.Errorf("creating schema: %w", err)
 	}
-	return nil
+	return migrateBusinesses(tx)
+}
+
+// Origin tells where a page of results was found.
+type Origin struct {
+	Sector model.Sector
+	Page   int
 }
 
-func (s *Store) InsertBatch(businesses []model.Business) (int, error) {
+// InsertBatch stores a page of results found at origin. A place is stored
+// once, by CID, whatever the queries it matches: a place seen again has its
+// fields refreshed (empty values never overwrite stored ones), and every
+// query it is found by is recorded in place_queries with the first sector,
+// page and rank it was seen at. Within a scan session the place is marked
+// seen by it, and changes of its tracked fields go to place_history.
+// Businesses without a CID are skipped. It returns the number of new
+// places.
+func (s *Store) InsertBatch(businesses []model.Business, origin Origin) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -90,33 +124,119 @@ func (s *Store) InsertBatch(businesses []model.Business) (int, error) {
 		return 0, fmt.Errorf("beginning tx: %w", err)
 	}
 
-	stmt, err := tx.Prepare(`
-		INSERT OR IGNORE INTO businesses
-		(name, rating, review_count, category, address, price_range, lat, lng, cid,
-		 phone, website, google_url, description, place_id,
-		 open_hours, thumbnail, categories, city, postal_code, country_code, query)
-		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+	insert, err := tx.Prepare(`
+		INSERT INTO places (` + placeColumns + `, first_seen, last_seen)
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+		ON CONFLICT(cid) DO NOTHING
 	`)
 	if err != nil {
 		tx.Rollback()
 		return 0, fmt.Errorf("preparing stmt: %w", err)
 	}
-	defer stmt.Close()
+	defer insert.Close()
+
+	update, err := tx.Prepare(`
+		UPDATE places SET
+			name = COALESCE(NULLIF(?, ''), name),
+			rating = COALESCE(NULLIF(?, 0), rating),
+			review_count = COALESCE(NULLIF(?, 0), review_count),
+			category = COALESCE(NULLIF(?, ''), category),
+			address = COALESCE(NULLIF(?, ''), address),
+			price_range = COALESCE(NULLIF(?, ''), price_range),
+			lat = ?, lng = ?,
+			phone = COALESCE(NULLIF(?, ''), phone),
+			website = COALESCE(NULLIF(?, ''), website),
+			google_url = COALESCE(NULLIF(?, ''), google_url),
+			description = COALESCE(NULLIF(?, ''), description),
+			place_id = COALESCE(NULLIF(?, ''), place_id),
+			open_hours = COALESCE(NULLIF(?, ''), open_hours),
+			thumbnail = COALESCE(NULLIF(?, ''), thumbnail),
+			categories = COALESCE(NULLIF(?, ''), categories),
+			city = COALESCE(NULLIF(?, ''), city),
+			postal_code = COALESCE(NULLIF(?, ''), postal_code),
+			country_code = COALESCE(NULLIF(?, ''), country_code),
+			last_seen = COALESCE(?, last_seen),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE cid = ?
+	`)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("preparing stmt: %w", err)
+	}
+	defer update.Close()
+
+	member, err := tx.Prepare(`
+		INSERT OR IGNORE INTO place_queries (cid, query, sector_lat, sector_lng, sector_zoom, page, rank)
+		VALUES (?,?,?,?,?,?,?)
+	`)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("preparing stmt: %w", err)
+	}
+	defer member.Close()
+
+	sighting, err := tx.Prepare("INSERT OR IGNORE INTO place_sightings (session_id, cid) VALUES (?,?)")
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("preparing stmt: %w", err)
+	}
+	defer sighting.Close()
+	session := nullSession(s.session)
 
 	inserted := 0
 	for _, b := range businesses {
-		res, err := stmt.Exec(
+		if b.CID == "" {
+			continue
+		}
+		res, err := insert.Exec(
 			b.Name, b.Rating, b.ReviewCount, b.Category, b.Address, b.PriceRange,
 			b.Lat, b.Lng, b.CID, b.Phone, b.Website,
 			b.GoogleURL, b.Description, b.PlaceID,
 			b.OpenHours, b.Thumbnail, b.Categories,
-			b.City, b.PostalCode, b.CountryCode, b.Query,
+			b.City, b.PostalCode, b.CountryCode, session, session,
 		)
 		if err != nil {
 			continue
 		}
-		n, _ := res.RowsAffected()
-		inserted += int(n)
+		if n, _ := res.RowsAffected(); n > 0 {
+			inserted++
+		} else {
+			if err := recordChanges(tx, s.session, &b); err != nil {
+				tx.Rollback()
+				return 0, err
+			}
+			_, err := update.Exec(
+				b.Name, b.Rating, b.ReviewCount, b.Category, b.Address, b.PriceRange,
+				b.Lat, b.Lng, b.Phone, b.Website,
+				b.GoogleURL, b.Description, b.PlaceID,
+				b.OpenHours, b.Thumbnail, b.Categories,
+				b.City, b.PostalCode, b.CountryCode, session, b.CID,
+			)
+			if err != nil {
+				tx.Rollback()
+				return 0, fmt.Errorf("updating place: %w", err)
+			}
+		}
+
+		if session != nil {
+			if _, err := sighting.Exec(session, b.CID); err != nil {
+				tx.Rollback()
+				return 0, fmt.Errorf("saving sighting: %w", err)
+			}
+		}
+		_, err = member.Exec(b.CID, b.Query, origin.Sector.Lat, origin.Sector.Lng, origin.Sector.Zoom, origin.Page, b.Rank)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("saving place query: %w", err)
+		}
+		if err := saveSchedule(tx, b.CID, b.Hours); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if err := savePhone(tx, b.CID, b.PhoneNumber); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -126,9 +246,10 @@ func (s *Store) InsertBatch(businesses []model.Business) (int, error) {
 	return inserted, nil
 }
 
+// Count returns the number of unique places stored.
 func (s *Store) Count() (int, error) {
 	var count int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM businesses").Scan(&count)
+	err := s.db.QueryRow("SELECT COUNT(*) FROM places").Scan(&count)
 	return count, err
 }
 
@@ -0,0 +1,271 @@
+package fakemaps
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var weekdays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// EncodeDetails renders a place detail page the way Google Maps serves it:
+// an HTML page whose APP_INITIALIZATION_STATE holds, at [3][6], a
+// )]}'-prefixed JSON string with the place array at index 6. Hours, popular
+// times, attributes and reviews are derived deterministically from the CID.
+func EncodeDetails(p Place) []byte {
+	seed := placeSeed(p)
+	biz := encodePlace(p)
+
+	// Weekly hours at [34][1] (also at [203][0]); status line at [34][4][4]
+	week := weekHours(seed)
+	var hours []any
+	for i, day := range weekdays {
+		hours = append(hours, []any{day, []any{week[i].text()}})
+	}
+	status := "Open ⋅ Closes 8 PM"
+	if seed%20 == 0 {
+		status = "Permanently closed"
+	}
+	biz[34] = []any{nil, hours, nil, nil, []any{nil, nil, nil, nil, status}}
+	biz[30] = "Europe/Madrid"
+
+	// Popular times at [84][0]: [day, [[hour, busyness], ...]]
+	var popular []any
+	for day := 1; day <= 6; day++ {
+		var hrs []any
+		for hr := week[0].open / 60; hr < 20; hr++ {
+			busy := int((seed>>uint(day+hr))%60) + 10
+			hrs = append(hrs, []any{hr, busy})
+		}
+		popular = append(popular, []any{day, hrs})
+	}
+	biz[84] = []any{popular}
+
+	// Attributes at [100][1]: [id, group, [[id, name, [_, [[flag]]]], ...]]
+	flag := func(b bool) []any {
+		v := 0
+		if b {
+			v = 1
+		}
+		return []any{nil, []any{[]any{v}}}
+	}
+	biz[100] = []any{nil, []any{
+		[]any{"accessibility", "Accessibility", []any{
+			[]any{"wheelchair", "Wheelchair accessible entrance", flag(seed%2 == 0)},
+		}},
+		[]any{"service_options", "Service options", []any{
+			[]any{"takeout", "Takeout", flag(true)},
+			[]any{"delivery", "Delivery", flag(seed%3 == 0)},
+		}},
+	}}
+
+	// The newest reviews at [175][9][0][0]
+	var reviews []any
+	for i := range min(3, reviewCount(seed)) {
+		reviews = append(reviews, []any{encodeReview(p, seed, i)})
+	}
+	biz[175] = []any{9: []any{[]any{reviews}}}
+
+	payload, _ := json.Marshal([]any{6: biz})
+	state, _ := json.Marshal([]any{nil, nil, nil, []any{6: xssiPrefix + string(payload)}})
+
+	page := fmt.Sprintf("<!DOCTYPE html><html><head><title>%s - Google Maps</title></head><body>"+
+		"<script>window.APP_INITIALIZATION_STATE=%s;window.APP_FLAGS=[];</script></body></html>",
+		html.EscapeString(p.Name), state)
+	return []byte(page)
+}
+
+func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request) {
+	n := s.requests.Add(1)
+
+	if d := s.delay(); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if int(n) <= s.cfg.FailFirst || s.chance(s.cfg.FaultRate) {
+		s.writeFault(w, r, n)
+		return
+	}
+
+	cid := r.URL.Query().Get("cid")
+	for _, p := range s.cfg.Places {
+		if p.CID == cid {
+			w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+			w.Write(EncodeDetails(p))
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+// placeSeed derives the per-place seed of the synthetic details from the CID.
+func placeSeed(p Place) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(p.CID))
+	return h.Sum64()
+}
+
+// dayHours is one day of a synthetic schedule, in minutes after midnight.
+type dayHours struct {
+	open, close int
+	closed      bool
+}
+
+// text renders the day the way Maps displays it, e.g. "9 AM–7 PM".
+func (d dayHours) text() string {
+	if d.closed {
+		return "Closed"
+	}
+	clock := func(m int) string {
+		h, mer := m/60%24, "AM"
+		if h >= 12 {
+			mer = "PM"
+		}
+		if h = h % 12; h == 0 {
+			h = 12
+		}
+		if m%60 != 0 {
+			return fmt.Sprintf("%d:%02d %s", h, m%60, mer)
+		}
+		return fmt.Sprintf("%d %s", h, mer)
+	}
+	return clock(d.open) + "–" + clock(d.close)
+}
+
+// weekHours is the weekly schedule of a place, Monday first: opening at 8,
+// 9 or 10 AM, closing between 6 and 9 PM, closed on Sundays. One place in
+// four stays open until 1 AM on Fridays.
+func weekHours(seed uint64) []dayHours {
+	open := (8 + int(seed%3)) * 60
+	week := make([]dayHours, 7)
+	for i := range week {
+		week[i] = dayHours{open: open, close: (18 + int(seed>>uint(i)%4)) * 60}
+	}
+	if seed%4 == 0 {
+		week[4].close = 60
+	}
+	week[6] = dayHours{closed: true}
+	return week
+}
+
+// reviewsEpoch is the publication date of every place's newest review.
+var reviewsEpoch = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+// reviewCount is the number of reviews a place has, between 5 and 64.
+func reviewCount(seed uint64) int {
+	return 5 + int(seed%60)
+}
+
+// encodeReview builds the i-th newest review of p in the element layout of
+// both detail pages and listugcposts: [id, [.., author, .., relative date],
+// [rating, .., date, .., language, text], [owner response]]. Reviews are 11
+// days apart.
+func encodeReview(p Place, seed uint64, i int) []any {
+	when := reviewsEpoch.AddDate(0, 0, -11*i)
+	lang := "en"
+	if i%3 == 2 {
+		lang = "es"
+	}
+	el := []any{
+		fmt.Sprintf("rev_%s_%d", p.CID, i),
+		[]any{4: []any{5: []any{fmt.Sprintf("Reviewer %d", i+1)}}, 6: relativeDate(reviewsEpoch.Sub(when))},
+		[]any{
+			0:  []any{1 + int(seed>>uint(i%60))%5},
+			2:  []any{[]any{nil, []any{21: []any{6: []any{8: []any{when.Year(), int(when.Month()), when.Day(), 12}}}}}},
+			14: []any{lang},
+			15: []any{[]any{fmt.Sprintf("Review %d of %s.", i+1, p.Name)}},
+		},
+	}
+	if i%4 == 1 {
+		el = append(el, []any{14: []any{[]any{"Thank you for your visit!"}}})
+	}
+	return el
+}
+
+// relativeDate renders an age the way Maps labels reviews.
+func relativeDate(age time.Duration) string {
+	days := int(age.Hours() / 24)
+	ago := func(n int, unit string) string {
+		if n <= 1 {
+			return "a " + unit + " ago"
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+	switch {
+	case days < 7:
+		return ago(days, "day")
+	case days < 30:
+		return ago(days/7, "week")
+	case days < 365:
+		return ago(days/30, "month")
+	}
+	return ago(days/365, "year")
+}
+
+var (
+	reviewsCIDRe   = regexp.MustCompile(`!1s([^!]*)`)
+	reviewsSizeRe  = regexp.MustCompile(`!1i(\d+)`)
+	reviewsTokenRe = regexp.MustCompile(`!2s([^!]*)`)
+)
+
+// serveReviews answers listugcposts requests with one page of a place's
+// reviews, newest first. The continuation token is the next offset.
+func (s *Server) serveReviews(w http.ResponseWriter, r *http.Request) {
+	n := s.requests.Add(1)
+
+	if d := s.delay(); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if int(n) <= s.cfg.FailFirst || s.chance(s.cfg.FaultRate) {
+		s.writeFault(w, r, n)
+		return
+	}
+
+	pb := r.URL.Query().Get("pb")
+	var cid string
+	if m := reviewsCIDRe.FindStringSubmatch(pb); m != nil {
+		cid = m[1]
+	}
+	size := 10
+	if m := reviewsSizeRe.FindStringSubmatch(pb); m != nil {
+		size, _ = strconv.Atoi(m[1])
+	}
+	offset := 0
+	if m := reviewsTokenRe.FindStringSubmatch(pb); m != nil && m[1] != "" {
+		offset, _ = strconv.Atoi(m[1])
+	}
+
+	for _, p := range s.cfg.Places {
+		if p.CID != cid {
+			continue
+		}
+		seed := placeSeed(p)
+		total := reviewCount(seed)
+		var items []any
+		for i := offset; i < total && i < offset+size; i++ {
+			items = append(items, []any{encodeReview(p, seed, i)})
+		}
+		var next any
+		if offset+size < total {
+			next = strconv.Itoa(offset + size)
+		}
+		payload, _ := json.Marshal([]any{nil, next, items})
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Write([]byte(xssiPrefix))
+		w.Write(payload)
+		return
+	}
+	http.NotFound(w, r)
+}
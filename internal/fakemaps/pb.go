@@ -0,0 +1,92 @@
+package fakemaps
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Viewport mirrors the fields geotap encodes in the pb= parameter.
+type Viewport struct {
+	Lat      float64
+	Lng      float64
+	Altitude float64 // !1d, meters
+	Width    int     // !1i, pixels
+	Height   int     // !2i, pixels
+	Limit    int     // !7i, page size
+	Offset   int     // !8i, result offset
+}
+
+// ParsePB extracts the viewport from a tbm=map pb= parameter as built by
+// scraper.BuildPB. Only the first occurrence of each field is used: later
+// fields with the same tag belong to nested messages.
+func ParsePB(pb string) (Viewport, error) {
+	var v Viewport
+	seen := make(map[string]bool)
+	for _, tok := range strings.Split(pb, "!") {
+		if len(tok) < 3 {
+			continue
+		}
+		tag, val := tok[:2], tok[2:]
+		if seen[tag] {
+			continue
+		}
+		var err error
+		switch tag {
+		case "1d":
+			v.Altitude, err = strconv.ParseFloat(val, 64)
+		case "2d":
+			v.Lng, err = strconv.ParseFloat(val, 64)
+		case "3d":
+			v.Lat, err = strconv.ParseFloat(val, 64)
+		case "1i":
+			v.Width, err = strconv.Atoi(val)
+		case "2i":
+			v.Height, err = strconv.Atoi(val)
+		case "7i":
+			v.Limit, err = strconv.Atoi(val)
+		case "8i":
+			v.Offset, err = strconv.Atoi(val)
+		default:
+			continue
+		}
+		if err != nil {
+			return v, fmt.Errorf("invalid pb field !%s: %w", tok, err)
+		}
+		seen[tag] = true
+	}
+	if !seen["1d"] || !seen["2d"] || !seen["3d"] {
+		return v, fmt.Errorf("pb is missing viewport fields")
+	}
+	if v.Width == 0 {
+		v.Width = 1024
+	}
+	if v.Height == 0 {
+		v.Height = 768
+	}
+	if v.Limit == 0 {
+		v.Limit = 20
+	}
+	return v, nil
+}
+
+// Zoom recovers the zoom level from the altitude, inverting scraper's
+// alt = 2πR·H·cos(lat) / (512·2^zoom).
+func (v Viewport) Zoom() int {
+	const earthRadius = 6371010.0
+	if v.Altitude <= 0 {
+		return 0
+	}
+	z := math.Log2(2 * math.Pi * earthRadius * float64(v.Height) * math.Cos(v.Lat*math.Pi/180) / (512 * v.Altitude))
+	return int(math.Round(z))
+}
+
+// Contains reports whether a point falls inside the visible map area.
+func (v Viewport) Contains(lat, lng float64) bool {
+	// Web Mercator: 256px tiles, 360° of longitude across 256·2^zoom pixels
+	degPerPx := 360 / (256 * math.Pow(2, float64(v.Zoom())))
+	halfLng := degPerPx * float64(v.Width) / 2
+	halfLat := degPerPx * float64(v.Height) / 2 * math.Cos(v.Lat*math.Pi/180)
+	return math.Abs(lat-v.Lat) <= halfLat && math.Abs(lng-v.Lng) <= halfLng
+}
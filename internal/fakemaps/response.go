@@ -0,0 +1,119 @@
+package fakemaps
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+
+	"github.com/paulmach/orb"
+)
+
+// Place is a synthetic business served by the fake server.
+type Place struct {
+	Name       string
+	Categories []string
+	Address    string
+	City       string
+	PostalCode string
+	Country    string // ISO 3166-1 alpha-2
+	Phone      string
+	Website    string
+	CID        string
+	PlaceID    string
+	Lat        float64
+	Lng        float64
+	Rating     float64
+	Reviews    int
+}
+
+// xssiPrefix is the anti-XSS guard Google prepends to tbm=map JSON.
+const xssiPrefix = ")]}'\n"
+
+// EncodeResponse renders places in the nested-array layout of a tbm=map
+// response: root[0][1][1..N][14] holds one business each, with the field
+// positions scraper.ParseMapResponse reads.
+func EncodeResponse(places []Place) []byte {
+	items := []any{[]any{"fake search metadata"}}
+	for _, p := range places {
+		items = append(items, []any{14: encodePlace(p)})
+	}
+	root := []any{[]any{nil, items}}
+
+	data, _ := json.Marshal(root)
+	return append([]byte(xssiPrefix), data...)
+}
+
+func encodePlace(p Place) []any {
+	biz := make([]any, 204)
+
+	rating := make([]any, 9)
+	rating[7] = p.Rating
+	rating[8] = p.Reviews
+	biz[4] = rating
+
+	if p.Website != "" {
+		biz[7] = []any{p.Website}
+	}
+	biz[9] = []any{nil, nil, p.Lat, p.Lng}
+	biz[10] = p.CID
+	biz[11] = p.Name
+	if len(p.Categories) > 0 {
+		cats := make([]any, len(p.Categories))
+		for i, c := range p.Categories {
+			cats[i] = c
+		}
+		biz[13] = cats
+	}
+	biz[18] = p.Address
+	biz[78] = p.PlaceID
+	if p.Phone != "" {
+		biz[178] = []any{[]any{p.Phone}}
+	}
+	biz[183] = []any{nil, []any{nil, nil, nil, p.City, p.PostalCode, nil, p.Country}}
+
+	// Weekly hours at [203][0]: [day, dayIndex, date, [[range, [[h, m], [h, m]]]]]
+	var hours []any
+	for i, d := range weekHours(placeSeed(p)) {
+		rng := []any{d.text()}
+		if !d.closed {
+			rng = append(rng, []any{[]any{d.open / 60, d.open % 60}, []any{d.close / 60, d.close % 60}})
+		}
+		hours = append(hours, []any{weekdays[i], i + 1, nil, []any{rng}})
+	}
+	biz[203] = []any{hours}
+	return biz
+}
+
+// RandomPlaces generates n deterministic places spread uniformly over bound,
+// cycling through categories. The same seed always yields the same dataset.
+func RandomPlaces(seed uint64, n int, bound orb.Bound, categories []string) []Place {
+	if len(categories) == 0 {
+		categories = []string{"Restaurant"}
+	}
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+
+	places := make([]Place, n)
+	for i := range places {
+		cat := categories[i%len(categories)]
+		lat := bound.Min.Lat() + rng.Float64()*(bound.Max.Lat()-bound.Min.Lat())
+		lng := bound.Min.Lon() + rng.Float64()*(bound.Max.Lon()-bound.Min.Lon())
+		places[i] = Place{
+			Name:       fmt.Sprintf("%s %d", cat, i+1),
+			Categories: []string{cat},
+			Address:    fmt.Sprintf("Calle Falsa %d", 100+i),
+			City:       "Fakeville",
+			PostalCode: fmt.Sprintf("%05d", i%100000),
+			Country:    "XX",
+			Phone:      fmt.Sprintf("+34 600 %03d %03d", i/1000%1000, i%1000),
+			Website:    fmt.Sprintf("https://example.com/%d", i+1),
+			CID:        fmt.Sprintf("%d", 1_000_000_000+i),
+			PlaceID:    fmt.Sprintf("ChIJfake%08d", i),
+			Lat:        lat,
+			Lng:        lng,
+			Rating:     math.Round((1+rng.Float64()*4)*10) / 10,
+			Reviews:    rng.IntN(2000),
+		}
+	}
+	return places
+}
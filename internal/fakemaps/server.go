@@ -0,0 +1,276 @@
+// Package fakemaps is a stand-in for Google's tbm=map search endpoint, place
+// detail pages and review listings, plus the businesses' own websites. It
+// serves synthetic places by viewport and offset and can inject rate-limit
+// responses and latency, so the scan and enrichment pipelines can run fully
+// offline.
+package fakemaps
+
+import (
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
+// Request is a decoded tbm=map request.
+type Request struct {
+	Query    string
+	Lang     string
+	Viewport Viewport
+}
+
+// Config controls what the fake server returns.
+type Config struct {
+	// Places is the dataset. A request gets the places inside its viewport
+	// whose name or a category has every word of the query, singular or
+	// plural, nearest first, paginated by the pb offset and limit.
+	Places []Place
+
+	// Respond, if set, overrides the dataset: it returns the raw response
+	// body for a request, or ok=false to fall back to Places.
+	Respond func(Request) (body []byte, ok bool)
+
+	// Latency delays every response; Jitter adds up to that much at random.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// FaultRate is the fraction of requests (0-1) answered with a fault
+	// instead of results. FailFirst fails the first N requests regardless.
+	FaultRate float64
+	FailFirst int
+	// FaultStatuses are the statuses faults rotate through: 429, 403, or a
+	// 302 redirect to /sorry/ like Google's captcha page. Default: 429.
+	FaultStatuses []int
+}
+
+// Server is a running fake endpoint. Use URL as the client base URL.
+type Server struct {
+	cfg      Config
+	requests atomic.Int64
+	faults   atomic.Int64
+
+	mu  sync.Mutex
+	rng *rand.Rand
+
+	ts *httptest.Server
+}
+
+// New creates a fake server without starting a listener; use Handler to
+// mount it, e.g. with http.ListenAndServe.
+func New(cfg Config) *Server {
+	if len(cfg.FaultStatuses) == 0 {
+		cfg.FaultStatuses = []int{http.StatusTooManyRequests}
+	}
+	return &Server{
+		cfg: cfg,
+		rng: rand.New(rand.NewPCG(1, 2)),
+	}
+}
+
+// NewServer starts a fake server on a local httptest listener.
+func NewServer(cfg Config) *Server {
+	s := New(cfg)
+	s.ts = httptest.NewServer(s.Handler())
+	return s
+}
+
+// URL returns the search endpoint of a server started with NewServer.
+func (s *Server) URL() string {
+	return s.ts.URL + "/search"
+}
+
+// DetailsURL returns the place details endpoint of a server started with NewServer.
+func (s *Server) DetailsURL() string {
+	return s.ts.URL + "/maps"
+}
+
+// ReviewsURL returns the review listing endpoint of a server started with NewServer.
+func (s *Server) ReviewsURL() string {
+	return s.ts.URL + "/maps/rpc/listugcposts"
+}
+
+// Close shuts down a server started with NewServer.
+func (s *Server) Close() {
+	if s.ts != nil {
+		s.ts.Close()
+	}
+}
+
+// Requests returns the number of search and details requests received.
+func (s *Server) Requests() int64 { return s.requests.Load() }
+
+// Faults returns the number of injected fault responses.
+func (s *Server) Faults() int64 { return s.faults.Load() }
+
+// Handler returns the HTTP handler serving /search, /maps (place details),
+// /maps/rpc/listugcposts (reviews) and /sites/ (business websites, see
+// UseSites).
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", s.serveSearch)
+	mux.HandleFunc("/maps", s.serveDetails)
+	mux.HandleFunc("/maps/rpc/listugcposts", s.serveReviews)
+	mux.HandleFunc("/sites/", s.serveSite)
+	mux.HandleFunc("/robots.txt", serveRobots)
+	mux.HandleFunc("/sorry/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unusual traffic from your computer network", http.StatusTooManyRequests)
+	})
+	return mux
+}
+
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request) {
+	n := s.requests.Add(1)
+
+	q := r.URL.Query()
+	if q.Get("tbm") != "map" {
+		http.Error(w, "only tbm=map is supported", http.StatusBadRequest)
+		return
+	}
+	vp, err := ParsePB(q.Get("pb"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if d := s.delay(); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if int(n) <= s.cfg.FailFirst || s.chance(s.cfg.FaultRate) {
+		s.writeFault(w, r, n)
+		return
+	}
+
+	req := Request{Query: q.Get("q"), Lang: q.Get("hl"), Viewport: vp}
+	body, ok := []byte(nil), false
+	if s.cfg.Respond != nil {
+		body, ok = s.cfg.Respond(req)
+	}
+	if !ok {
+		body = EncodeResponse(s.search(req))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Write(body)
+}
+
+func (s *Server) writeFault(w http.ResponseWriter, r *http.Request, n int64) {
+	s.faults.Add(1)
+	status := s.cfg.FaultStatuses[int(n)%len(s.cfg.FaultStatuses)]
+	switch status {
+	case http.StatusFound, http.StatusMovedPermanently, http.StatusTemporaryRedirect:
+		http.Redirect(w, r, "/sorry/index?continue="+r.URL.Path, status)
+	default:
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+// search returns the page of places matching req.
+func (s *Server) search(req Request) []Place {
+	vp := req.Viewport
+	query := words(req.Query)
+
+	var matches []Place
+	for _, p := range s.cfg.Places {
+		if !vp.Contains(p.Lat, p.Lng) || !matchesQuery(p, query) {
+			continue
+		}
+		matches = append(matches, p)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return dist2(matches[i], vp) < dist2(matches[j], vp)
+	})
+
+	if vp.Offset >= len(matches) {
+		return nil
+	}
+	end := min(vp.Offset+vp.Limit, len(matches))
+	return matches[vp.Offset:end]
+}
+
+// matchesQuery reports whether the place's name or one of its categories
+// has every word of query, as returned by words. An empty query matches
+// every place.
+func matchesQuery(p Place, query []string) bool {
+	if hasWords(words(p.Name), query) {
+		return true
+	}
+	for _, c := range p.Categories {
+		if hasWords(words(c), query) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasWords(have, want []string) bool {
+	for _, w := range want {
+		if !slices.Contains(have, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// words splits s into lower-case words in singular form, so "Pharmacies"
+// and "pharmacy", or "Restaurants" and "restaurant", are the same word.
+func words(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range fields {
+		fields[i] = singular(w)
+	}
+	return fields
+}
+
+// singular strips the English plural endings of w: "pharmacies" →
+// "pharmacy", "churches" → "church", "bars" → "bar".
+func singular(w string) string {
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		return w[:len(w)-3] + "y"
+	case len(w) > 4 && (strings.HasSuffix(w, "ches") || strings.HasSuffix(w, "shes") ||
+		strings.HasSuffix(w, "sses") || strings.HasSuffix(w, "xes") || strings.HasSuffix(w, "zes")):
+		return w[:len(w)-2]
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func dist2(p Place, vp Viewport) float64 {
+	dLat := p.Lat - vp.Lat
+	dLng := (p.Lng - vp.Lng) * math.Cos(vp.Lat*math.Pi/180)
+	return dLat*dLat + dLng*dLng
+}
+
+func (s *Server) delay() time.Duration {
+	d := s.cfg.Latency
+	if s.cfg.Jitter > 0 {
+		s.mu.Lock()
+		d += time.Duration(s.rng.Int64N(int64(s.cfg.Jitter)))
+		s.mu.Unlock()
+	}
+	return d
+}
+
+func (s *Server) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rng.Float64() < p
+}
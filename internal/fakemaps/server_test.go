@@ -0,0 +1,99 @@
+package fakemaps
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestMatchesQuery(t *testing.T) {
+	tests := []struct {
+		query      string
+		name       string
+		categories []string
+		want       bool
+	}{
+		{"", "Restaurant 1", []string{"Restaurant"}, true},
+		{"restaurants", "Restaurant 1", []string{"Restaurant"}, true},
+		{"restaurant", "Casa Pepe", []string{"Restaurants"}, true},
+		{"pharmacies", "Pharmacy 3", []string{"Pharmacy"}, true},
+		{"Pharmacy", "Farmacia Sol", []string{"Pharmacies"}, true},
+		{"cafes", "Cafe 2", []string{"Cafe"}, true},
+		{"bars", "Bar 7", []string{"Bar"}, true},
+		{"coffee shops", "Blue Bottle", []string{"Coffee shop"}, true},
+		{"churches", "San Ginés", []string{"Church"}, true},
+		{"glasses", "Optica", []string{"Glass"}, true},
+		{"hotels", "Hotel 5", nil, true},
+		{"bar", "Barber 1", []string{"Barber shop"}, false},
+		{"bars", "Restaurant 1", []string{"Restaurant"}, false},
+		{"italian restaurants", "Restaurant 1", []string{"Restaurant"}, false},
+		{"italian restaurants", "Trattoria", []string{"Italian restaurant"}, true},
+	}
+	for _, tt := range tests {
+		p := Place{Name: tt.name, Categories: tt.categories}
+		if got := matchesQuery(p, words(tt.query)); got != tt.want {
+			t.Errorf("matchesQuery(%q, %q %v) = %t, want %t", tt.query, tt.name, tt.categories, got, tt.want)
+		}
+	}
+}
+
+// searchURL builds a tbm=map request for a 20-place page around lat, lng.
+func searchURL(base string, lat, lng float64, query string, offset int) string {
+	pb := fmt.Sprintf("!4m8!1m3!1d5000!2d%f!3d%f!7i20!8i%d", lng, lat, offset)
+	return base + "?" + url.Values{"tbm": {"map"}, "q": {query}, "pb": {pb}}.Encode()
+}
+
+func TestServerFaults(t *testing.T) {
+	srv := NewServer(Config{
+		Places:        []Place{{Name: "Cafe 1", Categories: []string{"Cafe"}, CID: "1", Lat: 40.4, Lng: -3.7}},
+		FailFirst:     3,
+		FaultStatuses: []int{http.StatusTooManyRequests, http.StatusForbidden, http.StatusFound},
+	})
+	defer srv.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	get := func() *http.Response {
+		t.Helper()
+		resp, err := client.Get(searchURL(srv.URL(), 40.4, -3.7, "cafes", 0))
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// Faults rotate through the statuses by request number
+	for _, want := range []int{http.StatusForbidden, http.StatusFound, http.StatusTooManyRequests, http.StatusOK} {
+		resp := get()
+		if resp.StatusCode != want {
+			t.Errorf("status = %d, want %d", resp.StatusCode, want)
+		}
+		if want == http.StatusFound {
+			if loc := resp.Header.Get("Location"); len(loc) < 7 || loc[:7] != "/sorry/" {
+				t.Errorf("redirect to %q, want /sorry/", loc)
+			}
+		}
+	}
+	if srv.Faults() != 3 || srv.Requests() != 4 {
+		t.Errorf("faults = %d, requests = %d, want 3 and 4", srv.Faults(), srv.Requests())
+	}
+}
+
+func TestServerLatency(t *testing.T) {
+	srv := NewServer(Config{Latency: 80 * time.Millisecond, Jitter: 40 * time.Millisecond})
+	defer srv.Close()
+
+	start := time.Now()
+	resp, err := http.Get(searchURL(srv.URL(), 40.4, -3.7, "cafes", 0))
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if d := time.Since(start); d < 80*time.Millisecond {
+		t.Errorf("response after %v, want at least the 80ms latency", d)
+	}
+}
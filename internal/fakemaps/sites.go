@@ -0,0 +1,99 @@
+package fakemaps
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// chainSite is the path of the website shared by chain places.
+const chainSite = "chain"
+
+// UseSites points the website of every place at the fake business sites
+// served under base + "/sites/", so the contacts crawl can run offline.
+// One place in eight belongs to a chain sharing a single site and one in
+// ten has no website.
+func UseSites(places []Place, base string) {
+	base = strings.TrimRight(base, "/")
+	for i := range places {
+		seed := placeSeed(places[i])
+		switch {
+		case seed%10 == 0:
+			places[i].Website = ""
+		case seed%8 == 1:
+			places[i].Website = base + "/sites/" + chainSite + "/"
+		default:
+			places[i].Website = base + "/sites/" + places[i].CID + "/"
+		}
+	}
+}
+
+// serveSite serves the fake business websites: a homepage linking to a
+// contact page and an about page, and a /private/ page that robots.txt
+// disallows. Homepages of one site in twelve answer 500.
+func (s *Server) serveSite(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/sites/")
+	site, page, _ := strings.Cut(rest, "/")
+	page = strings.Trim(page, "/")
+
+	var p Place
+	if site == chainSite {
+		p = Place{Name: "Fake Chain", CID: chainSite, Phone: "+34 900 100 200"}
+	} else {
+		found := false
+		for _, q := range s.cfg.Places {
+			if q.CID == site {
+				p, found = q, true
+				break
+			}
+		}
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	seed := placeSeed(p)
+	slug := strings.ToLower(strings.ReplaceAll(p.Name, " ", ""))
+	prefix := "/sites/" + site + "/"
+
+	var body string
+	switch page {
+	case "":
+		if seed%12 == 5 {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		body = fmt.Sprintf(`<h1>%s</h1>
+<nav><a href="%s">Home</a> <a href="%scontacto">Contacto</a> <a href="%sabout-us">About us</a> <a href="%sprivate/staff">Staff</a> <a href="%smenu">Menu</a></nav>
+<footer>
+<a href="https://www.instagram.com/%s/">Instagram</a>
+<a href="https://www.facebook.com/sharer/sharer.php?u=%s">Share</a>
+<script type="application/ld+json">{"@type":"LocalBusiness","name":"%s","telephone":"%s"}</script>
+</footer>`, p.Name, prefix, prefix, prefix, prefix, prefix, slug, slug, p.Name, p.Phone)
+	case "contacto":
+		body = fmt.Sprintf(`<h1>Contacto</h1>
+<p>Escríbenos a <a href="mailto:info@%s.example?subject=Hola">info@%s.example</a>
+o a reservas [at] %s [dot] example.</p>
+<p>Tel: <a href="tel:+34-91-%03d-%04d">91 %03d %04d</a></p>
+<img src="/img/logo@2x.png">`, slug, slug, slug, seed%1000, seed%10000, seed%1000, seed%10000)
+		if seed%3 == 0 {
+			body += fmt.Sprintf(`<a href="https://es.linkedin.com/company/%s">LinkedIn</a>`, slug)
+		}
+	case "about-us":
+		body = fmt.Sprintf(`<h1>About</h1><p>Family run since 19%02d.</p>
+<a href="https://x.com/%s">Follow us</a> <a href="https://twitter.com/intent/tweet?text=%s">Tweet</a>`, seed%100, slug, slug)
+	case "private/staff":
+		body = fmt.Sprintf(`<p>Staff only: manager@%s.example</p>`, slug)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	fmt.Fprintf(w, "<!doctype html><html><body>%s</body></html>", body)
+}
+
+// serveRobots disallows the private pages of every fake site.
+func serveRobots(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprint(w, "User-agent: *\nDisallow: /sites/*/private/\nDisallow: /search\n")
+}
@@ -1,10 +1,18 @@
 package model
 
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
 // Sector represents a grid cell for geographic searching.
 type Sector struct {
 	Lat  float64
 	Lng  float64
 	Span float64 // degrees covered by this sector
+	Zoom int     // zoom level the sector is searched at
 	Row  int
 	Col  int
 }
@@ -25,13 +33,18 @@ type Business struct {
 	GoogleURL   string  `json:"google_url"`
 	Description string  `json:"description"`
 	PlaceID     string  `json:"place_id"`
-	OpenHours   string  `json:"open_hours"`
+	OpenHours   string  `json:"open_hours"` // raw hours array as served by Google
 	Thumbnail   string  `json:"thumbnail"`
 	Categories  string  `json:"categories"`
 	City        string  `json:"city"`
 	PostalCode  string  `json:"postal_code"`
 	CountryCode string  `json:"country_code"`
-	Query       string  `json:"query"`
+	Query       string  `json:"query"` // query the place was found by; the first one when loaded from a scan
+
+	Queries     []string     `json:"queries,omitempty"`      // every query that found the place, when loaded from a scan
+	Rank        int          `json:"rank,omitempty"`         // 1-based position in the results page it was parsed from
+	Hours       Schedule     `json:"hours,omitempty"`        // OpenHours normalized, nil when unknown
+	PhoneNumber *PhoneNumber `json:"phone_number,omitempty"` // Phone parsed, nil when there is none
 }
 
 // SearchParams holds all configuration for a scraping session.
@@ -47,19 +60,112 @@ type SearchParams struct {
 	Lng    float64
 	Radius float64 // km
 
+	// Mode 3: By custom polygon (GeoJSON, KML or WKT file)
+	AreaFile string
+
 	// Common
 	Queries     []string
 	Zoom        int
 	Concurrency int
 	MaxPages    int     // max pagination pages per sector (default 1)
+	Adaptive    bool    // split saturated sectors into quadrants at zoom+1
+	MaxZoom     int     // deepest zoom adaptive subdivision may reach (default 16)
 	MinRating   float64 // min star filter (0 = no filter)
 	MaxRating   float64 // max star filter (0 = no filter)
 	DBPath      string
-	Lang        string // hl parameter (default "es")
-	ProxyURL    string // HTTP/SOCKS5 proxy URL (optional)
+	Lang        string   // hl parameter (default "es")
+	ProxyURL    string   // HTTP/SOCKS5 proxy URL (optional)
+	Proxies     []string // proxy pool to rotate across (optional, overrides ProxyURL)
+	GeocoderURL string   // Nominatim base URL for region lookups (default: public instance)
+	Endpoint    string   // tbm=map search URL override, e.g. a local fake server (default: Google)
+	RecordDir   string   // archive raw responses here for later replay (optional)
 	Debug       bool
+
+	// Enrichment
+	DetailsEndpoint string // place details URL override (default: Google)
+	ReviewsEndpoint string // review listing URL override (default: Google)
 }
 
 func (p *SearchParams) IsCoordMode() bool {
 	return p.Lat != 0 || p.Lng != 0
 }
+
+// RegionName returns the first-level division to scan: Region, or Province
+// when Region is not set.
+func (p *SearchParams) RegionName() string {
+	if p.Region != "" {
+		return p.Region
+	}
+	return p.Province
+}
+
+// AreaLabel describes where a scan ran: the area file, the country with its
+// region and city, or the search center and radius.
+func (p *SearchParams) AreaLabel() string {
+	switch {
+	case p.AreaFile != "":
+		return filepath.Base(p.AreaFile)
+	case p.Country != "":
+		parts := []string{p.Country}
+		if r := p.RegionName(); r != "" {
+			parts = append(parts, r)
+		}
+		if p.City != "" {
+			parts = append(parts, p.City)
+		}
+		return strings.Join(parts, ", ")
+	case p.IsCoordMode():
+		return fmt.Sprintf("%.4f, %.4f (r=%.1fkm)", p.Lat, p.Lng, p.Radius)
+	}
+	return ""
+}
+
+// UsesProxies reports whether the scan runs through a proxy or proxy pool.
+func (p *SearchParams) UsesProxies() bool {
+	return p.ProxyURL != "" || len(p.Proxies) > 0
+}
+
+// Redacted returns a copy of p with the passwords of its proxy URLs masked,
+// for params that are stored in a project database or recording.
+func (p SearchParams) Redacted() SearchParams {
+	p.ProxyURL = RedactURL(p.ProxyURL)
+	if len(p.Proxies) > 0 {
+		proxies := make([]string, len(p.Proxies))
+		for i, u := range p.Proxies {
+			proxies[i] = RedactURL(u)
+		}
+		p.Proxies = proxies
+	}
+	return p
+}
+
+// Search returns a copy of p with only the fields that define what a scan
+// searches and keeps: the area, queries, grid, filters, language and
+// endpoint. How it runs (concurrency, proxies, output, debugging) is cleared,
+// so two scans of the same search compare equal.
+func (p SearchParams) Search() SearchParams {
+	p.Concurrency = 0
+	p.DBPath = ""
+	p.ProxyURL, p.Proxies = "", nil
+	p.GeocoderURL = ""
+	p.RecordDir = ""
+	p.Debug = false
+	p.DetailsEndpoint, p.ReviewsEndpoint = "", ""
+	if len(p.Queries) == 0 {
+		p.Queries = nil
+	}
+	return p
+}
+
+// RedactURL masks the password of a URL. A value that does not parse is
+// masked entirely, since it may still hold credentials.
+func RedactURL(s string) string {
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return "xxxxx"
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,18 @@
+package model
+
+// Contact kinds stored in the contacts table.
+const (
+	ContactEmail  = "email"
+	ContactSocial = "social"
+	ContactPhone  = "phone"
+)
+
+// Contact is one email address, social profile or phone number found on a
+// business's website.
+type Contact struct {
+	CID     string `json:"cid"`
+	Kind    string `json:"kind"`              // one of the Contact* kinds
+	Value   string `json:"value"`             // address, profile URL or phone as written
+	Network string `json:"network,omitempty"` // social network, e.g. "instagram"
+	Source  string `json:"source"`            // page the contact was found on
+}
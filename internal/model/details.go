@@ -0,0 +1,58 @@
+package model
+
+// Business status values derived from the place detail payload.
+const (
+	StatusOperational       = "operational"
+	StatusTemporarilyClosed = "temporarily_closed"
+	StatusPermanentlyClosed = "permanently_closed"
+)
+
+// PlaceDetails is the enrichment data of one business, parsed from its
+// place detail page.
+type PlaceDetails struct {
+	CID        string
+	PlaceID    string
+	Status     string // one of the Status* constants, empty when unknown
+	StatusText string // Google's status line, e.g. "Open ⋅ Closes 10 PM"
+	Timezone   string
+	PlusCode   string
+
+	Hours        []DayHours
+	Schedule     Schedule // Hours normalized
+	PopularTimes []PopularHour
+	Attributes   []Attribute
+	Reviews      []Review
+}
+
+// DayHours is the opening hours of one weekday as displayed by Google.
+type DayHours struct {
+	Day   string   // weekday name in the request language
+	Hours []string // ranges such as "9 AM–5 PM", or "Closed"
+}
+
+// PopularHour is how busy a place usually is at one hour of the week.
+type PopularHour struct {
+	Day      int // 1 = Monday … 7 = Sunday
+	Hour     int // 0-23
+	Busyness int // 0-100, relative to the place's busiest hour
+}
+
+// Attribute is one accessibility, service or amenity flag of a place.
+type Attribute struct {
+	Group     string // e.g. "Accessibility", "Service options"
+	Name      string // e.g. "Wheelchair accessible entrance"
+	Available bool
+}
+
+// Review is one user review of a place.
+type Review struct {
+	CID           string `json:"cid"`
+	ID            string `json:"review_id"`
+	Author        string `json:"author"`
+	Rating        int    `json:"rating"`
+	Text          string `json:"text"`
+	Language      string `json:"language"`
+	Published     string `json:"published"`      // YYYY-MM-DD, when known
+	RelativeDate  string `json:"relative_date"`  // as displayed, e.g. "a month ago"
+	OwnerResponse string `json:"owner_response"` // empty when the owner did not reply
+}
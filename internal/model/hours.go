@@ -0,0 +1,196 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OpeningSpan is one opening interval of a weekly schedule. A day with no
+// opening has a single span with Closed set; a place open around the clock
+// has AllDay set. Open and Close are "HH:MM" in the place's local time.
+type OpeningSpan struct {
+	Day       int    `json:"day"` // 1 = Monday … 7 = Sunday
+	Open      string `json:"open,omitempty"`
+	Close     string `json:"close,omitempty"`
+	Overnight bool   `json:"overnight,omitempty"` // closes after midnight, on the next day
+	AllDay    bool   `json:"all_day,omitempty"`
+	Closed    bool   `json:"closed,omitempty"`
+}
+
+var dayAbbrevs = []string{"", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// String renders the span as "Mon 09:00-17:00", "Sun closed" or "Sat 24h".
+func (s OpeningSpan) String() string {
+	day := "?"
+	if s.Day >= 1 && s.Day <= 7 {
+		day = dayAbbrevs[s.Day]
+	}
+	switch {
+	case s.Closed:
+		return day + " closed"
+	case s.AllDay:
+		return day + " 24h"
+	}
+	return day + " " + s.Open + "-" + s.Close
+}
+
+// Schedule is the weekly opening hours of a place.
+type Schedule []OpeningSpan
+
+// String renders the schedule as "; "-separated spans in week order.
+func (s Schedule) String() string {
+	parts := make([]string, len(s))
+	for i, sp := range s {
+		parts[i] = sp.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ByDay renders the schedule as one "Mon  09:00-14:00, 17:00-21:00" line
+// per day.
+func (s Schedule) ByDay() []string {
+	var lines []string
+	for i := 0; i < len(s); {
+		name, _, _ := strings.Cut(s[i].String(), " ")
+		var spans []string
+		for day := s[i].Day; i < len(s) && s[i].Day == day; i++ {
+			_, span, _ := strings.Cut(s[i].String(), " ")
+			spans = append(spans, span)
+		}
+		lines = append(lines, name+"  "+strings.Join(spans, ", "))
+	}
+	return lines
+}
+
+// OpenAt reports whether the place is open at t, read as local time of the
+// place. Spans that run past midnight count on the following day too. The
+// result is false for an empty schedule.
+func (s Schedule) OpenAt(t time.Time) bool {
+	day := Weekday(t)
+	prev := day - 1
+	if prev == 0 {
+		prev = 7
+	}
+	minute := t.Hour()*60 + t.Minute()
+
+	for _, sp := range s {
+		if sp.Closed {
+			continue
+		}
+		if sp.AllDay {
+			if sp.Day == day {
+				return true
+			}
+			continue
+		}
+		open, ok1 := clockMinutes(sp.Open)
+		close, ok2 := clockMinutes(sp.Close)
+		if !ok1 || !ok2 {
+			continue
+		}
+		switch {
+		case sp.Day == day && !sp.Overnight && minute >= open && minute < close:
+			return true
+		case sp.Day == day && sp.Overnight && minute >= open:
+			return true
+		case sp.Day == prev && sp.Overnight && minute < close:
+			return true
+		}
+	}
+	return false
+}
+
+// OpenAtSpec reports whether the place is open at an "open at" spec (see
+// ParseOpenAt), read in the place's IANA time zone tz, or in now's location
+// when tz is empty or unknown. An invalid spec matches nothing.
+func (s Schedule) OpenAtSpec(spec string, now time.Time, tz string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	if loc := loadLocation(tz); loc != nil {
+		now = now.In(loc)
+	}
+	t, err := ParseOpenAt(spec, now)
+	if err != nil {
+		return false
+	}
+	return s.OpenAt(t)
+}
+
+var locations sync.Map // tz name → *time.Location, nil when unknown
+
+func loadLocation(tz string) *time.Location {
+	if tz == "" {
+		return nil
+	}
+	if loc, ok := locations.Load(tz); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		loc = nil
+	}
+	locations.Store(tz, loc)
+	return loc
+}
+
+// Weekday returns t's day of the week as 1 = Monday … 7 = Sunday.
+func Weekday(t time.Time) int {
+	if wd := int(t.Weekday()); wd != 0 {
+		return wd
+	}
+	return 7
+}
+
+// clockMinutes parses "HH:MM" into minutes after midnight.
+func clockMinutes(s string) (int, bool) {
+	h, m, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, false
+	}
+	hh, err1 := strconv.Atoi(h)
+	mm, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || hh < 0 || hh > 24 || mm < 0 || mm > 59 {
+		return 0, false
+	}
+	return hh*60 + mm, true
+}
+
+// ParseOpenAt resolves an "open at" spec relative to now: "now", a time of
+// today ("21:30") or a weekday and time ("sat 21:30", "sat-21:30"). The
+// result is in now's location, in the current week.
+func ParseOpenAt(spec string, now time.Time) (time.Time, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "now" || spec == "" {
+		return now, nil
+	}
+
+	dayPart, clock := "", spec
+	if i := strings.IndexAny(spec, " -@"); i >= 0 {
+		dayPart, clock = spec[:i], strings.TrimSpace(spec[i+1:])
+	}
+	minute, ok := clockMinutes(clock)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid time %q: want HH:MM", clock)
+	}
+
+	day := Weekday(now)
+	if dayPart != "" {
+		day = 0
+		for i, abbrev := range dayAbbrevs[1:] {
+			if strings.HasPrefix(dayPart, strings.ToLower(abbrev)) {
+				day = i + 1
+				break
+			}
+		}
+		if day == 0 {
+			return time.Time{}, fmt.Errorf("invalid weekday %q: want mon … sun", dayPart)
+		}
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return midnight.AddDate(0, 0, day-Weekday(now)).Add(time.Duration(minute) * time.Minute), nil
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleOpenAt(t *testing.T) {
+	s := Schedule{
+		{Day: 1, Open: "09:00", Close: "14:00"},
+		{Day: 1, Open: "17:00", Close: "21:00"},
+		{Day: 2, AllDay: true},
+		{Day: 3, Closed: true},
+		{Day: 5, Open: "22:00", Close: "02:00", Overnight: true},
+		{Day: 7, Open: "20:00", Close: "01:30", Overnight: true},
+	}
+	// 2026-01-12 is a Monday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 1, 11+day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"mon morning", at(1, 9, 0), true},
+		{"mon at close", at(1, 14, 0), false},
+		{"mon between spans", at(1, 15, 30), false},
+		{"mon evening", at(1, 20, 59), true},
+		{"tue around the clock", at(2, 3, 0), true},
+		{"wed closed", at(3, 12, 0), false},
+		{"thu not listed", at(4, 12, 0), false},
+		{"fri before the night span", at(5, 21, 59), false},
+		{"fri night", at(5, 23, 0), true},
+		{"sat past midnight of fri", at(6, 1, 0), true},
+		{"sat at fri's close", at(6, 2, 0), false},
+		{"sat night", at(6, 23, 0), false},
+		{"mon past midnight of sun", at(1, 1, 0), true},
+		{"mon after sun's close", at(1, 1, 30), false},
+	}
+	for _, tt := range tests {
+		if got := s.OpenAt(tt.t); got != tt.want {
+			t.Errorf("%s: OpenAt(%s) = %v, want %v", tt.name, tt.t.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+
+	if (Schedule{}).OpenAt(at(1, 12, 0)) {
+		t.Error("an empty schedule is open, want closed")
+	}
+	bad := Schedule{{Day: 1, Open: "9am", Close: "5pm"}}
+	if bad.OpenAt(at(1, 12, 0)) {
+		t.Error("a span with unreadable times is open, want it ignored")
+	}
+}
+
+func TestParseOpenAt(t *testing.T) {
+	// Wednesday
+	now := time.Date(2026, 1, 14, 10, 15, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want string // "" when invalid
+	}{
+		{"now", "Wed 2026-01-14 10:15"},
+		{"", "Wed 2026-01-14 10:15"},
+		{"21:30", "Wed 2026-01-14 21:30"},
+		{"sat 21:30", "Sat 2026-01-17 21:30"},
+		{"Sat-01:00", "Sat 2026-01-17 01:00"},
+		{"saturday 01:00", "Sat 2026-01-17 01:00"},
+		{"mon 09:00", "Mon 2026-01-12 09:00"},
+		{"sun@23:59", "Sun 2026-01-18 23:59"},
+
+		{"9pm", ""},
+		{"25:00", ""},
+		{"sat", ""},
+		{"xyz 10:00", ""},
+		{"sat 10", ""},
+	}
+	for _, tt := range tests {
+		got, err := ParseOpenAt(tt.spec, now)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("ParseOpenAt(%q) = %s, want an error", tt.spec, got)
+		case tt.want != "" && err != nil:
+			t.Errorf("ParseOpenAt(%q): %v", tt.spec, err)
+		case tt.want != "" && got.Format("Mon 2006-01-02 15:04") != tt.want:
+			t.Errorf("ParseOpenAt(%q) = %s, want %s", tt.spec, got.Format("Mon 2006-01-02 15:04"), tt.want)
+		}
+	}
+}
+
+func TestScheduleOpenAtSpec(t *testing.T) {
+	s := Schedule{{Day: 5, Open: "22:00", Close: "02:00", Overnight: true}}
+	// Friday 23:30 in UTC is Saturday 00:30 in Madrid
+	now := time.Date(2026, 1, 16, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec, tz string
+		want     bool
+	}{
+		{"now", "", true},
+		{"now", "Europe/Madrid", true},
+		{"sat 01:00", "", true},
+		{"sat 01:00", "Europe/Madrid", true},
+		{"sat 03:00", "Europe/Madrid", false},
+		{"fri 21:00", "Not/AZone", false},
+		{"fri 23:00", "Not/AZone", true},
+		{"soon", "", false},
+	}
+	for _, tt := range tests {
+		if got := s.OpenAtSpec(tt.spec, now, tt.tz); got != tt.want {
+			t.Errorf("OpenAtSpec(%q, %q) = %v, want %v", tt.spec, tt.tz, got, tt.want)
+		}
+	}
+	if (Schedule{}).OpenAtSpec("now", now, "") {
+		t.Error("an empty schedule matches, want no match")
+	}
+}
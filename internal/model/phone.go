@@ -0,0 +1,30 @@
+package model
+
+// Phone line types, as far as the numbering plan tells them apart.
+const (
+	PhoneMobile   = "mobile"
+	PhoneLandline = "landline"
+	PhoneTollFree = "toll_free"
+)
+
+// PhoneNumber is a business phone parsed against the numbering plan of its
+// country.
+type PhoneNumber struct {
+	Raw    string `json:"raw"`              // as displayed by Google
+	E164   string `json:"e164,omitempty"`   // e.g. "+34912345678", empty unless Valid
+	Region string `json:"region,omitempty"` // ISO 3166-1 alpha-2 code of the number's country
+	Valid  bool   `json:"valid"`            // length and prefix fit the country's numbering plan
+	Type   string `json:"type,omitempty"`   // one of the Phone* types, empty when not inferable
+}
+
+// Normalized returns the E.164 form when the number is valid, the raw
+// display string otherwise.
+func (p *PhoneNumber) Normalized() string {
+	if p == nil {
+		return ""
+	}
+	if p.E164 != "" {
+		return p.E164
+	}
+	return p.Raw
+}
@@ -66,6 +66,11 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.currentView = viewProgress
 		a.progress = views.NewProgressModel(msg)
 		return a, tea.Batch(a.progress.Init(), a.sizeCmd())
+	case views.ResumeScanMsg:
+		a.currentView = viewProgress
+		a.progress = views.NewResumeProgressModel(msg.DBPath)
+		SaveRecent(msg.DBPath)
+		return a, tea.Batch(a.progress.Init(), a.sizeCmd())
 	case views.NavigateToExplorer:
 		a.currentView = viewExplorer
 		a.explorer = views.NewExplorerModel(msg.DBPath)
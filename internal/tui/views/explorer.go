@@ -2,13 +2,13 @@ package views
 
 import (
 	"database/sql"
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/charmbracelet/bubbles/table"
@@ -19,6 +19,8 @@ import (
 	"golang.org/x/text/transform"
 	"golang.org/x/text/unicode/norm"
 
+	"github.com/rendis/geotap/internal/engine/export"
+	"github.com/rendis/geotap/internal/engine/storage"
 	"github.com/rendis/geotap/internal/model"
 	"github.com/rendis/geotap/internal/tui/styles"
 )
@@ -30,6 +32,7 @@ const (
 	focusFilter
 	focusCard
 	focusJSON
+	focusReviews
 )
 
 // ExplorerModel displays scraped data with table + detail panels.
@@ -46,6 +49,7 @@ type ExplorerModel struct {
 	err        error
 	total      int
 	exportMsg  string
+	exporting  bool // waiting for the export format key
 
 	// Scroll state for detail panels
 	cardScrollY int
@@ -54,10 +58,20 @@ type ExplorerModel struct {
 	jsonScrollX int
 	jsonLines   []string // cached raw JSON lines
 	jsonRaw     string   // full JSON for clipboard copy
+
+	timezones map[string]string // time zones of enriched places, by CID
+	sessions  []storage.Session // runs that wrote the database, oldest first
+
+	// Reviews share the right panel with JSON
+	showReviews    bool
+	reviewsScrollY int
+	reviewLines    []string // cached wrapped review lines
 }
 
 type dbLoadedMsg struct {
 	Businesses []model.Business
+	Timezones  map[string]string
+	Sessions   []storage.Session
 	Err        error
 }
 
@@ -75,8 +89,19 @@ func NewExplorerModel(dbPath string) ExplorerModel {
 
 func (m ExplorerModel) Init() tea.Cmd {
 	return func() tea.Msg {
-		businesses, err := loadBusinesses(m.dbPath)
-		return dbLoadedMsg{Businesses: businesses, Err: err}
+		// Export reads databases as they are; the explorer upgrades the
+		// projects it opens, as scans do
+		store, err := storage.NewStore(m.dbPath)
+		if err != nil {
+			return dbLoadedMsg{Err: err}
+		}
+		store.Close()
+		businesses, timezones, err := export.Load(m.dbPath)
+		if err != nil {
+			return dbLoadedMsg{Err: err}
+		}
+		sessions, _ := loadSessions(m.dbPath)
+		return dbLoadedMsg{Businesses: businesses, Timezones: timezones, Sessions: sessions}
 	}
 }
 
@@ -94,6 +119,15 @@ func (m ExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		}
 
+		if m.exporting {
+			m.exporting = false
+			m.exportMsg = ""
+			if n, err := strconv.Atoi(key); err == nil && n >= 1 && n <= len(export.Formats) {
+				m.exportFile(export.Formats[n-1])
+			}
+			return m, nil
+		}
+
 		switch m.focus {
 		case focusTable:
 			switch key {
@@ -113,10 +147,19 @@ func (m ExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			case "2":
 				m.focus = focusJSON
+				m.showReviews = false
+				m.table.SetStyles(m.unfocusedTableStyles())
+				return m, nil
+			case "3":
+				m.focus = focusReviews
+				m.showReviews = true
+				m.reviewsScrollY = 0
+				m.cacheReviewLines()
 				m.table.SetStyles(m.unfocusedTableStyles())
 				return m, nil
 			case "e":
-				m.exportCSV()
+				m.exporting = true
+				m.exportMsg = ""
 				return m, nil
 			}
 
@@ -187,6 +230,29 @@ func (m ExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.copyToClipboard()
 				return m, nil
 			}
+
+		case focusReviews:
+			ph := m.panelHeight()
+			maxScroll := len(m.reviewLines) - ph
+			if maxScroll < 0 {
+				maxScroll = 0
+			}
+			switch key {
+			case "esc":
+				m.focus = focusTable
+				m.table.SetStyles(m.focusedTableStyles())
+				return m, nil
+			case "up", "k":
+				if m.reviewsScrollY > 0 {
+					m.reviewsScrollY--
+				}
+				return m, nil
+			case "down", "j":
+				if m.reviewsScrollY < maxScroll {
+					m.reviewsScrollY++
+				}
+				return m, nil
+			}
 		}
 
 	case dbLoadedMsg:
@@ -195,6 +261,8 @@ func (m ExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.businesses = msg.Businesses
+		m.timezones = msg.Timezones
+		m.sessions = msg.Sessions
 		m.filtered = msg.Businesses
 		m.total = len(m.businesses)
 		m.buildTable(m.businesses)
@@ -217,6 +285,7 @@ func (m ExplorerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.cardScrollY = 0
 			m.jsonScrollY = 0
 			m.jsonScrollX = 0
+			m.reviewsScrollY = 0
 			m.cacheDetailContent()
 		}
 	case focusFilter:
@@ -248,6 +317,10 @@ func (m *ExplorerModel) cacheDetailContent() {
 	}
 	m.jsonRaw = string(data)
 	m.jsonLines = strings.Split(m.jsonRaw, "\n")
+
+	if m.showReviews {
+		m.cacheReviewLines()
+	}
 }
 
 func (m ExplorerModel) buildCardLines(biz model.Business) []string {
@@ -287,16 +360,34 @@ func (m ExplorerModel) buildCardLines(biz model.Business) []string {
 		addRow("City:", strings.Join(parts, ", "))
 	}
 	addRow("Phone:", biz.Phone)
+	if p := biz.PhoneNumber; p != nil && p.Valid && p.E164 != biz.Phone {
+		e164 := p.E164
+		if p.Type != "" {
+			e164 += " (" + strings.ReplaceAll(p.Type, "_", "-") + ")"
+		}
+		addRow("E.164:", e164)
+	}
 	addRow("Website:", biz.Website)
 	addRow("Maps:", biz.GoogleURL)
 	addRow("Price:", biz.PriceRange)
 	if biz.Lat != 0 || biz.Lng != 0 {
 		addRow("Coords:", fmt.Sprintf("%.6f, %.6f", biz.Lat, biz.Lng))
 	}
+	addRow("Queries:", strings.Join(biz.Queries, ", "))
 	addRow("CID:", biz.CID)
 	addRow("PlaceID:", biz.PlaceID)
 
-	if biz.OpenHours != "" {
+	if len(biz.Hours) > 0 {
+		lines = append(lines, "")
+		status := "closed now"
+		if biz.Hours.OpenAtSpec("now", time.Now(), m.timezones[biz.CID]) {
+			status = "open now"
+		}
+		addRow("Hours:", status)
+		for _, day := range biz.Hours.ByDay() {
+			lines = append(lines, "  "+day)
+		}
+	} else if biz.OpenHours != "" {
 		lines = append(lines, "")
 		addRow("Hours:", biz.OpenHours)
 	}
@@ -404,6 +495,9 @@ func (m *ExplorerModel) updateLayout() {
 	}
 	m.table.SetHeight(tableH)
 	m.buildTable(m.filtered)
+	if m.showReviews {
+		m.cacheReviewLines()
+	}
 }
 
 // normalize removes accents/diacritics and lowercases text for fuzzy matching.
@@ -427,12 +521,22 @@ func (m *ExplorerModel) applyFilter() {
 		return
 	}
 
-	words := strings.Fields(normalize(raw))
+	// "open:now", "open:21:30" or "open:sat-21:30" keep places open then
+	var words, openAt []string
+	for _, w := range strings.Fields(normalize(raw)) {
+		if spec, ok := strings.CutPrefix(w, "open:"); ok {
+			openAt = append(openAt, spec)
+		} else {
+			words = append(words, w)
+		}
+	}
+	now := time.Now()
 	m.filtered = nil
 	for _, b := range m.businesses {
 		haystack := normalize(strings.Join([]string{
 			b.Name, b.Category, b.Categories, b.City,
-			b.Address, b.Description,
+			b.Address, b.Description, b.Phone, b.PhoneNumber.Normalized(),
+			strings.Join(b.Queries, " "),
 		}, " "))
 		match := true
 		for _, w := range words {
@@ -441,6 +545,11 @@ func (m *ExplorerModel) applyFilter() {
 				break
 			}
 		}
+		for _, spec := range openAt {
+			if match && !b.Hours.OpenAtSpec(spec, now, m.timezones[b.CID]) {
+				match = false
+			}
+		}
 		if match {
 			m.filtered = append(m.filtered, b)
 		}
@@ -466,7 +575,11 @@ func (m ExplorerModel) View() string {
 		b.WriteString(lipgloss.NewStyle().Foreground(styles.Muted).
 			Render(fmt.Sprintf(" (showing %d)", len(m.filtered))))
 	}
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	if line := sessionLine(m.sessions); line != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(styles.Muted).MaxWidth(m.width - 2).Render(line))
+	}
+	b.WriteString("\n")
 
 	// Filter
 	filterStyle := lipgloss.NewStyle().Foreground(styles.Muted)
@@ -516,16 +629,18 @@ func (m ExplorerModel) View() string {
 	cardLabel := lipgloss.NewStyle().Bold(true).Foreground(cardBorderColor).Render("[1] Details")
 	cardBox = cardLabel + "\n" + cardBox
 
-	// JSON panel
+	// JSON or reviews panel
 	jsonBorderColor := styles.Muted
-	if m.focus == focusJSON {
+	if m.focus == focusJSON || m.focus == focusReviews {
 		jsonBorderColor = styles.Primary
 	}
-	jsonInnerW := jsonOuterW - 4
-	if jsonInnerW < 20 {
-		jsonInnerW = 20
+	jsonInnerW := m.rightPanelInnerW()
+	var jsonContent string
+	if m.showReviews {
+		jsonContent = m.viewReviewsPanel(panelH)
+	} else {
+		jsonContent = m.viewJSONPanel(jsonInnerW, panelH)
 	}
-	jsonContent := m.viewJSONPanel(jsonInnerW, panelH)
 	jsonBox := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(jsonBorderColor).
@@ -533,7 +648,12 @@ func (m ExplorerModel) View() string {
 		Width(jsonOuterW - 2).
 		Height(panelH).
 		Render(jsonContent)
-	jsonLabel := lipgloss.NewStyle().Bold(true).Foreground(jsonBorderColor).Render("[2] JSON")
+	tabStyle := lipgloss.NewStyle().Bold(true).Foreground(jsonBorderColor)
+	otherTab := lipgloss.NewStyle().Foreground(styles.Muted)
+	jsonLabel := tabStyle.Render("[2] JSON") + "  " + otherTab.Render("[3] Reviews")
+	if m.showReviews {
+		jsonLabel = otherTab.Render("[2] JSON") + "  " + tabStyle.Render("[3] Reviews")
+	}
 	jsonBox = jsonLabel + "\n" + jsonBox
 
 	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, cardBox, " ", jsonBox))
@@ -547,15 +667,23 @@ func (m ExplorerModel) View() string {
 
 	// Status bar changes by focus
 	var statusText string
-	switch m.focus {
-	case focusTable:
-		statusText = "↑↓ navigate • 1 details • 2 json • / filter • e export • esc back"
-	case focusFilter:
-		statusText = "type to filter • esc back"
-	case focusCard:
+	switch {
+	case m.exporting:
+		formats := make([]string, len(export.Formats))
+		for i, f := range export.Formats {
+			formats[i] = fmt.Sprintf("%d %s", i+1, f.Name)
+		}
+		statusText = "export as: " + strings.Join(formats, " • ") + " • esc cancel"
+	case m.focus == focusTable:
+		statusText = "↑↓ navigate • 1 details • 2 json • 3 reviews • / filter • e export • esc back"
+	case m.focus == focusFilter:
+		statusText = "type to filter • open:now or open:sat-21:30 for opening hours • esc back"
+	case m.focus == focusCard:
 		statusText = "↑↓ scroll • esc back to table"
-	case focusJSON:
+	case m.focus == focusJSON:
 		statusText = "↑↓ scroll • ←→ pan • c copy json • esc back to table"
+	case m.focus == focusReviews:
+		statusText = "↑↓ scroll • esc back to table"
 	}
 	b.WriteString(styles.StatusBar.Render(statusText))
 
@@ -729,87 +857,55 @@ func truncate(s string, max int) string {
 	return s[:max-1] + "…"
 }
 
-func (m *ExplorerModel) exportCSV() {
+// exportFile writes the filtered places, or all of them when the filter
+// matches none, next to the database in format f.
+func (m *ExplorerModel) exportFile(f export.Format) {
 	dir := filepath.Dir(m.dbPath)
 	base := strings.TrimSuffix(filepath.Base(m.dbPath), ".db")
-	csvPath := filepath.Join(dir, base+".csv")
-
-	f, err := os.Create(csvPath)
-	if err != nil {
-		m.exportMsg = fmt.Sprintf("Export error: %v", err)
-		return
-	}
-	defer f.Close()
-
-	w := csv.NewWriter(f)
-	defer w.Flush()
-
-	w.Write([]string{
-		"name", "rating", "review_count", "category", "categories",
-		"address", "city", "postal_code", "country_code",
-		"lat", "lng", "phone", "website", "google_url",
-		"description", "price_range", "query",
-	})
+	path := filepath.Join(dir, base+f.Ext)
 
 	data := m.filtered
 	if len(data) == 0 {
 		data = m.businesses
 	}
 
-	for _, b := range data {
-		w.Write([]string{
-			b.Name,
-			fmt.Sprintf("%.1f", b.Rating),
-			fmt.Sprintf("%d", b.ReviewCount),
-			b.Category,
-			b.Categories,
-			b.Address,
-			b.City,
-			b.PostalCode,
-			b.CountryCode,
-			fmt.Sprintf("%.6f", b.Lat),
-			fmt.Sprintf("%.6f", b.Lng),
-			b.Phone,
-			b.Website,
-			b.GoogleURL,
-			b.Description,
-			b.PriceRange,
-			b.Query,
-		})
+	if err := export.WriteFile(path, f, data); err != nil {
+		m.exportMsg = fmt.Sprintf("Export error: %v", err)
+		return
 	}
-
-	m.exportMsg = fmt.Sprintf("Exported %d rows to %s", len(data), csvPath)
+	m.exportMsg = fmt.Sprintf("Exported %d rows to %s", len(data), path)
 }
 
-func loadBusinesses(dbPath string) ([]model.Business, error) {
+// loadSessions reads the scan sessions of a database already brought to
+// the current schema by export.Load.
+func loadSessions(dbPath string) ([]storage.Session, error) {
 	db, err := sql.Open("sqlite", dbPath)
 	if err != nil {
 		return nil, err
 	}
 	defer db.Close()
+	return storage.LoadSessions(db)
+}
 
-	rows, err := db.Query(`
-		SELECT name, rating, review_count, category, address, price_range,
-		       lat, lng, cid, phone, website, google_url, description, place_id,
-		       open_hours, thumbnail, categories, city, postal_code, country_code, query
-		FROM businesses ORDER BY name`)
-	if err != nil {
-		return nil, err
+// sessionLine summarizes what produced a database for the explorer header:
+// the queries and area of the scan that created it, when it started, and
+// the status of the latest session.
+func sessionLine(sessions []storage.Session) string {
+	if len(sessions) == 0 {
+		return ""
 	}
-	defer rows.Close()
-
-	var businesses []model.Business
-	for rows.Next() {
-		var b model.Business
-		err := rows.Scan(
-			&b.Name, &b.Rating, &b.ReviewCount, &b.Category, &b.Address, &b.PriceRange,
-			&b.Lat, &b.Lng, &b.CID, &b.Phone, &b.Website, &b.GoogleURL, &b.Description, &b.PlaceID,
-			&b.OpenHours, &b.Thumbnail, &b.Categories, &b.City, &b.PostalCode, &b.CountryCode, &b.Query,
-		)
-		if err != nil {
-			continue
-		}
-		businesses = append(businesses, b)
+	first, last := sessions[0], sessions[len(sessions)-1]
+	parts := []string{strings.Join(first.Params.Queries, ", ")}
+	if area := first.Params.AreaLabel(); area != "" {
+		parts = append(parts, area)
+	}
+	parts = append(parts,
+		fmt.Sprintf("zoom %d", first.Params.Zoom),
+		first.Kind+" "+first.StartedAt.Local().Format("2006-01-02 15:04"),
+	)
+	if len(sessions) > 1 {
+		parts = append(parts, fmt.Sprintf("%d sessions", len(sessions)))
 	}
-	return businesses, nil
+	parts = append(parts, last.Status)
+	return strings.Join(parts, " · ")
 }
@@ -0,0 +1,180 @@
+package views
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/rendis/geotap/internal/model"
+	"github.com/rendis/geotap/internal/tui/styles"
+)
+
+// rightPanelInnerW is the text width of the JSON/reviews panel, matching the
+// layout in View.
+func (m ExplorerModel) rightPanelInnerW() int {
+	detailW := m.width - 2
+	if detailW < 40 {
+		detailW = 40
+	}
+	w := detailW - detailW*2/5 - 1 - 4
+	if w < 20 {
+		w = 20
+	}
+	return w
+}
+
+// cacheReviewLines loads the stored reviews of the selected business and
+// wraps them to the panel width.
+func (m *ExplorerModel) cacheReviewLines() {
+	if m.selected < 0 || m.selected >= len(m.filtered) {
+		m.reviewLines = nil
+		return
+	}
+	biz := m.filtered[m.selected]
+	if biz.CID == "" {
+		m.reviewLines = []string{"No CID for this business"}
+		return
+	}
+
+	reviews, err := loadReviews(m.dbPath, biz.CID)
+	if err != nil || len(reviews) == 0 {
+		m.reviewLines = []string{
+			"No reviews stored for this place.",
+			"",
+			"Fetch them with:",
+			"  geotap reviews -db " + m.dbPath,
+		}
+		return
+	}
+
+	w := m.rightPanelInnerW()
+	lines := []string{fmt.Sprintf("%d reviews, newest first", len(reviews)), ""}
+	for _, r := range reviews {
+		date := r.Published
+		if date == "" {
+			date = r.RelativeDate
+		}
+		stars := min(max(r.Rating, 0), 5)
+		head := strings.Repeat("★", stars) + strings.Repeat("☆", 5-stars) + "  " + date
+		if r.Language != "" {
+			head += "  [" + r.Language + "]"
+		}
+		lines = append(lines, head, truncate(r.Author, w))
+		if r.Text != "" {
+			lines = append(lines, wrapText(r.Text, w)...)
+		}
+		if r.OwnerResponse != "" {
+			lines = append(lines, wrapText("↳ Owner: "+r.OwnerResponse, w)...)
+		}
+		lines = append(lines, "")
+	}
+	m.reviewLines = lines
+}
+
+func (m ExplorerModel) viewReviewsPanel(h int) string {
+	if m.selected < 0 || m.selected >= len(m.filtered) || len(m.reviewLines) == 0 {
+		return lipgloss.NewStyle().Foreground(styles.Muted).Italic(true).
+			Render("Select a business\nto view reviews")
+	}
+
+	lines := m.reviewLines
+
+	// Clamp scroll
+	scrollY := m.reviewsScrollY
+	if scrollY > len(lines)-h {
+		scrollY = len(lines) - h
+	}
+	if scrollY < 0 {
+		scrollY = 0
+	}
+
+	end := scrollY + h
+	if end > len(lines) {
+		end = len(lines)
+	}
+	visible := lines[scrollY:end]
+
+	muted := lipgloss.NewStyle().Foreground(styles.Muted)
+	starStyle := lipgloss.NewStyle().Foreground(styles.Warning)
+	textStyle := lipgloss.NewStyle().Foreground(styles.Text)
+
+	var sb strings.Builder
+	for i, line := range visible {
+		switch {
+		case strings.HasPrefix(line, "★") || strings.HasPrefix(line, "☆"):
+			sb.WriteString(starStyle.Render(line))
+		case strings.HasPrefix(line, "↳"):
+			sb.WriteString(muted.Italic(true).Render(line))
+		default:
+			sb.WriteString(textStyle.Render(line))
+		}
+		if i < len(visible)-1 {
+			sb.WriteString("\n")
+		}
+	}
+
+	// Scroll indicators
+	if scrollY > 0 || end < len(lines) {
+		sb.WriteString("\n")
+		sb.WriteString(muted.Render(fmt.Sprintf("  [%d/%d]", scrollY+1, len(lines))))
+	}
+
+	return sb.String()
+}
+
+// wrapText splits s into lines of at most w runes, breaking at spaces.
+func wrapText(s string, w int) []string {
+	var lines []string
+	var cur []rune
+	for _, word := range strings.Fields(s) {
+		r := []rune(word)
+		if len(cur) > 0 && len(cur)+1+len(r) > w {
+			lines = append(lines, string(cur))
+			cur = nil
+		}
+		for len(r) > w {
+			lines = append(lines, string(r[:w]))
+			r = r[w:]
+		}
+		if len(cur) > 0 {
+			cur = append(cur, ' ')
+		}
+		cur = append(cur, r...)
+	}
+	if len(cur) > 0 {
+		lines = append(lines, string(cur))
+	}
+	return lines
+}
+
+func loadReviews(dbPath, cid string) ([]model.Review, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT review_id, COALESCE(author, ''), COALESCE(rating, 0), COALESCE(text, ''),
+		       COALESCE(language, ''), COALESCE(published, ''), COALESCE(relative_date, ''),
+		       COALESCE(owner_response, '')
+		FROM reviews WHERE cid = ?
+		ORDER BY published DESC, id`, cid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []model.Review
+	for rows.Next() {
+		r := model.Review{CID: cid}
+		if err := rows.Scan(&r.ID, &r.Author, &r.Rating, &r.Text, &r.Language,
+			&r.Published, &r.RelativeDate, &r.OwnerResponse); err != nil {
+			continue
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, nil
+}
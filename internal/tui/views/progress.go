@@ -6,7 +6,6 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,7 +13,6 @@ import (
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/paulmach/orb"
 	"github.com/rendis/geotap/internal/engine/geo"
 	"github.com/rendis/geotap/internal/engine/scraper"
 	"github.com/rendis/geotap/internal/engine/storage"
@@ -41,6 +39,8 @@ type ProgressModel struct {
 	err         error
 	dbPath      string
 	logPath   string
+	resume    bool
+	area      *geo.Area // precomputed by the search preview (nil = resolve on start)
 	width     int
 	height    int
 	shared    *sharedState
@@ -65,35 +65,8 @@ func NewProgressModel(msg StartScanMsg) ProgressModel {
 		shared:    &sharedState{},
 	}
 
-	// Parse params
-	m.params.Queries = strings.Split(msg.Query, ",")
-	for i := range m.params.Queries {
-		m.params.Queries[i] = strings.TrimSpace(m.params.Queries[i])
-	}
-
-	if msg.Mode == modeCountry {
-		m.params.Country = msg.Country
-		m.params.Region = msg.Region
-	} else {
-		m.params.Lat, _ = strconv.ParseFloat(msg.Lat, 64)
-		m.params.Lng, _ = strconv.ParseFloat(msg.Lng, 64)
-		m.params.Radius, _ = strconv.ParseFloat(msg.Radius, 64)
-	}
-
-	m.params.Zoom, _ = strconv.Atoi(msg.Zoom)
-	if m.params.Zoom == 0 {
-		if m.params.IsCoordMode() {
-			m.params.Zoom = 13
-		} else {
-			m.params.Zoom = 10
-		}
-	}
-	m.params.Concurrency, _ = strconv.Atoi(msg.Concurrency)
-	if m.params.Concurrency <= 0 {
-		m.params.Concurrency = 50
-	}
-	m.params.MaxPages = 1
-	m.params.Lang = "en"
+	m.params = msg.searchParams()
+	m.area = msg.Area
 
 	// Setup output paths
 	ts := time.Now().Format("20060102_150405")
@@ -107,7 +80,52 @@ func NewProgressModel(msg StartScanMsg) ProgressModel {
 	return m
 }
 
+// NewResumeProgressModel creates a progress view that resumes the unfinished
+// jobs of an existing project database.
+func NewResumeProgressModel(dbPath string) ProgressModel {
+	p := progress.New(
+		progress.WithDefaultGradient(),
+		progress.WithWidth(50),
+	)
+
+	m := ProgressModel{
+		progress:  p,
+		startTime: time.Now(),
+		shared:    &sharedState{},
+		dbPath:    dbPath,
+		logPath:   strings.TrimSuffix(dbPath, ".db") + ".log",
+		resume:    true,
+	}
+
+	store, err := storage.NewStore(dbPath)
+	if err != nil {
+		m.done = true
+		m.err = err
+		return m
+	}
+	defer store.Close()
+
+	m.params, err = store.ResumeParams()
+	if err != nil {
+		m.done = true
+		m.err = err
+		return m
+	}
+	m.params.DBPath = dbPath
+	// Projects keep proxies redacted, and the TUI has no proxy settings
+	if m.params.UsesProxies() {
+		m.done = true
+		m.err = fmt.Errorf("the scan ran through proxies, which the project does not store: run 'geotap resume -db %s -proxies <file>'", dbPath)
+		return m
+	}
+
+	return m
+}
+
 func (m ProgressModel) Init() tea.Cmd {
+	if m.done {
+		return nil
+	}
 	return tea.Batch(
 		m.startScraping(),
 		tickCmd(),
@@ -125,62 +143,29 @@ func (m ProgressModel) startScraping() tea.Cmd {
 	params := m.params
 	dbPath := m.dbPath
 	logPath := m.logPath
+	precomputed := m.area
+
+	if m.resume {
+		return m.startResume()
+	}
 
 	return func() tea.Msg {
 		ctx, cancel := context.WithCancel(context.Background())
 
-		// Generate sectors
-		var sectors []model.Sector
-
-		// Load country polygon for geo-filtering
-		var poly orb.MultiPolygon
-		var err error
-		if params.Country != "" {
-			var bs *geo.BoundaryStore
-			bs, err = geo.NewBoundaryStore()
-			if err != nil {
-				cancel()
-				return scrapeCompleteMsg{Err: err}
-			}
-			poly, err = bs.GetCountryPolygon(params.Country)
+		// Generate sectors (reuse the preview's area when available)
+		area := precomputed
+		if area == nil {
+			var err error
+			area, err = geo.ResolveArea(params)
 			if err != nil {
 				cancel()
 				return scrapeCompleteMsg{Err: err}
 			}
 		}
-
-		if params.IsCoordMode() {
-			sectors = geo.GenerateRadiusGrid(params.Lat, params.Lng, params.Radius, params.Zoom)
-			if poly != nil {
-				sectors = geo.FilterLandSectors(sectors, poly)
-			}
-		} else {
-			var minLat, minLng, maxLat, maxLng float64
-			if params.Region != "" {
-				minLat, minLng, maxLat, maxLng, err = geo.GeocodeRegion(params.Region, params.Country)
-				if err != nil {
-					cancel()
-					return scrapeCompleteMsg{Err: fmt.Errorf("geocoding region %q: %w", params.Region, err)}
-				}
-			} else {
-				var bs *geo.BoundaryStore
-				bs, err = geo.NewBoundaryStore()
-				if err != nil {
-					cancel()
-					return scrapeCompleteMsg{Err: err}
-				}
-				minLat, minLng, maxLat, maxLng, err = bs.GetCountryBounds(params.Country)
-				if err != nil {
-					cancel()
-					return scrapeCompleteMsg{Err: err}
-				}
-			}
-			allSectors := geo.GenerateGrid(minLat, minLng, maxLat, maxLng, params.Zoom)
-			if poly != nil {
-				sectors = geo.FilterLandSectors(allSectors, poly)
-			} else {
-				sectors = allSectors
-			}
+		sectors := area.Sectors
+		if len(sectors) == 0 {
+			cancel()
+			return scrapeCompleteMsg{Err: fmt.Errorf("no sectors to process")}
 		}
 
 		// Open storage
@@ -200,7 +185,8 @@ func (m ProgressModel) startScraping() tea.Cmd {
 		logger := log.New(logFile, "", log.LstdFlags)
 
 		numSectors := len(sectors) * len(params.Queries)
-		stats := &scraper.Stats{SectorsTotal: numSectors}
+		stats := &scraper.Stats{}
+		stats.SectorsTotal.Store(int64(numSectors))
 
 		// Store into shared state (survives bubbletea value copies)
 		shared.mu.Lock()
@@ -209,11 +195,95 @@ func (m ProgressModel) startScraping() tea.Cmd {
 		shared.numSectors = numSectors
 		shared.mu.Unlock()
 
+		session, err := store.StartSession(storage.SessionScan, "tui", params)
+		if err != nil {
+			logFile.Close()
+			store.Close()
+			cancel()
+			return scrapeCompleteMsg{Err: err}
+		}
 		_, runErr := scraper.Run(ctx, sectors, params, store, logger, &scraper.RunOptions{
+			SuppressStderr: true,
+			Stats:          stats,
+			GeoFilter:      area.Filter,
+		})
+		if err := store.FinishSession(session, stats.Snapshot(), runErr); err != nil {
+			logger.Printf("SESSION err=%v", err)
+		}
+
+		logFile.Close()
+		store.Close()
+
+		return scrapeCompleteMsg{Err: runErr}
+	}
+}
+
+func (m ProgressModel) startResume() tea.Cmd {
+	shared := m.shared
+	params := m.params
+	dbPath := m.dbPath
+	logPath := m.logPath
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		// Rebuild the geographic filter used by the original scan
+		poly, err := geo.ResolveGeoFilter(params)
+		if err != nil {
+			cancel()
+			return scrapeCompleteMsg{Err: err}
+		}
+
+		store, err := storage.NewStore(dbPath)
+		if err != nil {
+			cancel()
+			return scrapeCompleteMsg{Err: err}
+		}
+
+		counts, err := store.JobCounts()
+		if err != nil {
+			store.Close()
+			cancel()
+			return scrapeCompleteMsg{Err: err}
+		}
+		if storage.Unfinished(counts) == 0 {
+			store.Close()
+			cancel()
+			return scrapeCompleteMsg{Err: fmt.Errorf("nothing to resume: all jobs are done")}
+		}
+
+		logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			store.Close()
+			cancel()
+			return scrapeCompleteMsg{Err: err}
+		}
+		logger := log.New(logFile, "", log.LstdFlags)
+		logger.Printf("=== Session resume (TUI): pending=%d interrupted=%d failed=%d done=%d ===",
+			counts[storage.JobPending], counts[storage.JobInterrupted], counts[storage.JobFailed], counts[storage.JobDone])
+
+		stats := &scraper.Stats{}
+
+		shared.mu.Lock()
+		shared.stats = stats
+		shared.cancel = cancel
+		shared.mu.Unlock()
+
+		session, err := store.StartSession(storage.SessionResume, "tui", params)
+		if err != nil {
+			logFile.Close()
+			store.Close()
+			cancel()
+			return scrapeCompleteMsg{Err: err}
+		}
+		_, runErr := scraper.Resume(ctx, params, store, logger, &scraper.RunOptions{
 			SuppressStderr: true,
 			Stats:          stats,
 			GeoFilter:      poly,
 		})
+		if err := store.FinishSession(session, stats.Snapshot(), runErr); err != nil {
+			logger.Printf("SESSION err=%v", err)
+		}
 
 		logFile.Close()
 		store.Close()
@@ -288,10 +358,17 @@ func (m ProgressModel) View() string {
 
 	query := strings.Join(m.params.Queries, ", ")
 	location := m.params.Country
+	if m.params.AreaFile != "" {
+		location = filepath.Base(m.params.AreaFile)
+	}
 	if location == "" {
 		location = fmt.Sprintf("%.4f, %.4f", m.params.Lat, m.params.Lng)
 	}
-	b.WriteString(styles.Title.Render(fmt.Sprintf("Scraping: %q in %s", query, location)))
+	title := "Scraping"
+	if m.resume {
+		title = "Resuming"
+	}
+	b.WriteString(styles.Title.Render(fmt.Sprintf("%s: %q in %s", title, query, location)))
 	b.WriteString("\n\n")
 
 	// Stats
@@ -307,9 +384,20 @@ func (m ProgressModel) View() string {
 
 	// Progress bar
 	stats := m.shared.getStats()
+	if stats != nil && len(stats.Proxies()) > 0 {
+		b.WriteString(renderProxies(stats.Proxies()))
+		b.WriteString("\n\n")
+	}
+	if stats != nil {
+		if alerts := stats.Coverage().Alerts(); len(alerts) > 0 {
+			b.WriteString(renderDrift(alerts))
+			b.WriteString("\n\n")
+		}
+	}
+
 	var pct float64
-	if stats != nil && stats.SectorsTotal > 0 {
-		pct = float64(stats.SectorsDone.Load()) / float64(stats.SectorsTotal)
+	if stats != nil && stats.SectorsTotal.Load() > 0 {
+		pct = float64(stats.SectorsDone.Load()) / float64(stats.SectorsTotal.Load())
 	}
 	b.WriteString(m.progress.ViewAs(pct))
 	b.WriteString("\n\n")
@@ -348,16 +436,17 @@ func (m ProgressModel) renderStats() string {
 
 	var sectorsDone int64
 	var sectorsTotal int64
-	var found, stored, errors, rateLimits int64
+	var found, stored, errors, rateLimits, subdivided int64
 
 	stats := m.shared.getStats()
 	if stats != nil {
 		sectorsDone = stats.SectorsDone.Load()
-		sectorsTotal = int64(stats.SectorsTotal)
+		sectorsTotal = stats.SectorsTotal.Load()
 		found = stats.BusinessesFound.Load()
 		stored = stats.BusinessesStored.Load()
 		errors = stats.Errors.Load()
 		rateLimits = stats.RateLimits.Load()
+		subdivided = stats.Subdivided.Load()
 	}
 
 	statLabel := lipgloss.NewStyle().Foreground(styles.Muted).Width(12)
@@ -370,6 +459,9 @@ func (m ProgressModel) renderStats() string {
 	}
 
 	row("Sectors:", fmt.Sprintf("%d/%d", sectorsDone, sectorsTotal))
+	if subdivided > 0 {
+		row("Split:", fmt.Sprintf("%d", subdivided))
+	}
 	row("Found:", fmt.Sprintf("%d", found))
 	row("Stored:", fmt.Sprintf("%d", stored))
 
@@ -401,6 +493,51 @@ func (m ProgressModel) renderStats() string {
 	return sb.String()
 }
 
+// maxProxyRows caps the per-proxy table; the rest are summarized in one line.
+const maxProxyRows = 8
+
+func renderProxies(proxies []*scraper.ProxyStats) string {
+	var sb strings.Builder
+	healthy := 0
+	for _, p := range proxies {
+		if p.Healthy() {
+			healthy++
+		}
+	}
+
+	header := lipgloss.NewStyle().Foreground(styles.Muted)
+	ok := lipgloss.NewStyle().Foreground(styles.Text)
+	benched := lipgloss.NewStyle().Foreground(styles.Warning)
+
+	sb.WriteString(header.Render(fmt.Sprintf("Proxies: %d/%d healthy", healthy, len(proxies))))
+	sb.WriteString("\n")
+	for i, p := range proxies {
+		if i == maxProxyRows {
+			sb.WriteString(header.Render(fmt.Sprintf("  +%d more", len(proxies)-maxProxyRows)))
+			sb.WriteString("\n")
+			break
+		}
+		style, mark := ok, "●"
+		if !p.Healthy() {
+			style, mark = benched, "○"
+		}
+		sb.WriteString(style.Render(fmt.Sprintf("  %s %-28s ok %-6d fail %-5d rl %d",
+			mark, truncate(p.URL, 28), p.Successes.Load(), p.Failures.Load(), p.RateLimits.Load())))
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// renderDrift warns about fields whose fill rate dropped during the scan.
+func renderDrift(alerts []scraper.DriftAlert) string {
+	parts := make([]string, len(alerts))
+	for i, a := range alerts {
+		parts[i] = a.String()
+	}
+	return lipgloss.NewStyle().Foreground(styles.Warning).Bold(true).
+		Render("⚠ Parser drift: " + strings.Join(parts, ", ") + " — response layout may have changed")
+}
+
 func (s *sharedState) getCancel() context.CancelFunc {
 	s.mu.Lock()
 	defer s.mu.Unlock()
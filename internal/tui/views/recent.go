@@ -1,6 +1,7 @@
 package views
 
 import (
+	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,12 +10,14 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/rendis/geotap/internal/engine/storage"
 	"github.com/rendis/geotap/internal/tui/styles"
 )
 
 type RecentEntry struct {
 	Path     string
 	OpenedAt time.Time
+	Summary  string // what the scan was, from its sessions; "" when unknown
 }
 
 type RecentModel struct {
@@ -23,9 +26,43 @@ type RecentModel struct {
 }
 
 func NewRecentModel(entries []RecentEntry) RecentModel {
+	for i := range entries {
+		entries[i].Summary = projectSummary(entries[i].Path)
+	}
 	return RecentModel{entries: entries}
 }
 
+// projectSummary describes a project database from its scan sessions: the
+// queries and area scanned, the number of places and the status of the
+// latest session. The database is opened read-only, so projects from older
+// versions are left as they are and get no summary.
+func projectSummary(path string) string {
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return ""
+	}
+	defer db.Close()
+
+	sessions, err := storage.LoadSessions(db)
+	if err != nil || len(sessions) == 0 {
+		return ""
+	}
+	first, last := sessions[0], sessions[len(sessions)-1]
+	parts := []string{strings.Join(first.Params.Queries, ", ")}
+	if area := first.Params.AreaLabel(); area != "" {
+		parts = append(parts, area)
+	}
+	var places int
+	if err := db.QueryRow("SELECT COUNT(*) FROM places").Scan(&places); err == nil {
+		parts = append(parts, fmt.Sprintf("%d places", places))
+	}
+	parts = append(parts, last.Status)
+	return strings.Join(parts, " · ")
+}
+
 func (m RecentModel) Init() tea.Cmd {
 	return nil
 }
@@ -48,6 +85,12 @@ func (m RecentModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return NavigateToExplorer{DBPath: m.entries[m.cursor].Path}
 				}
 			}
+		case "r":
+			if m.cursor < len(m.entries) {
+				return m, func() tea.Msg {
+					return ResumeScanMsg{DBPath: m.entries[m.cursor].Path}
+				}
+			}
 		case "esc":
 			return m, func() tea.Msg { return NavigateToHome{} }
 		}
@@ -95,10 +138,13 @@ func (m RecentModel) View() string {
 			fmt.Sprintf("  %s  %s", dir, ago))
 
 		b.WriteString(fmt.Sprintf("%s%s\n%s\n", cursor, nameStr, dirStr))
+		if entry.Summary != "" {
+			b.WriteString(lipgloss.NewStyle().Foreground(styles.Muted).Render("  "+entry.Summary) + "\n")
+		}
 	}
 
 	b.WriteString("\n")
-	b.WriteString(styles.StatusBar.Render("enter open • esc back"))
+	b.WriteString(styles.StatusBar.Render("enter open • r resume scan • esc back"))
 
 	return styles.Border.Render(b.String())
 }
@@ -119,3 +165,8 @@ func timeAgo(t time.Time) string {
 
 // NavigateToRecent signals navigation to recent projects view.
 type NavigateToRecent struct{}
+
+// ResumeScanMsg signals resuming the unfinished jobs of a project.
+type ResumeScanMsg struct {
+	DBPath string
+}
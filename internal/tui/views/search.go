@@ -2,6 +2,7 @@ package views
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -9,6 +10,8 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/rendis/geotap/internal/engine/geo"
+	"github.com/rendis/geotap/internal/engine/scraper"
+	"github.com/rendis/geotap/internal/model"
 	"github.com/rendis/geotap/internal/tui/styles"
 )
 
@@ -17,6 +20,7 @@ type searchMode int
 const (
 	modeCountry searchMode = iota
 	modeCoords
+	modeArea
 )
 
 // Field indices — fieldMode is a virtual field (not a textinput)
@@ -28,6 +32,7 @@ const (
 	fieldLat
 	fieldLng
 	fieldRadius
+	fieldArea
 	fieldZoom
 	fieldConcurrency
 	fieldOutput
@@ -39,15 +44,39 @@ type SearchModel struct {
 	mode        searchMode
 	focused     int
 	err         string
+	store       *geo.BoundaryStore
 	countries   []geo.CountryEntry
 	suggestions []geo.CountryEntry
+	regions     []geo.RegionEntry // admin-1 divisions of regionsFor
+	regionsFor  string
+	regionSugg  []geo.RegionEntry
 	suggIdx     int
+	planning    bool
+	preview     *scanPreview
+}
+
+// scanPreview is the dry-run shown before a scan starts.
+type scanPreview struct {
+	msg      StartScanMsg
+	params   model.SearchParams
+	estimate scraper.Estimate
 }
 
 type countriesLoadedMsg struct {
+	store   *geo.BoundaryStore
 	entries []geo.CountryEntry
 }
 
+type regionsLoadedMsg struct {
+	country string
+	entries []geo.RegionEntry
+}
+
+type planReadyMsg struct {
+	preview *scanPreview
+	err     error
+}
+
 func NewSearchModel() SearchModel {
 	inputs := make([]textinput.Model, fieldCount)
 
@@ -58,6 +87,7 @@ func NewSearchModel() SearchModel {
 	inputs[fieldLat] = newInput("40.4168", "", 15)
 	inputs[fieldLng] = newInput("-3.7038", "", 15)
 	inputs[fieldRadius] = newInput("10", "", 10)
+	inputs[fieldArea] = newInput("path to .geojson, .kml or .wkt", "", 50)
 	inputs[fieldZoom] = newInput("10", "", 5)
 	inputs[fieldConcurrency] = newInput("50", "", 5)
 	inputs[fieldOutput] = newInput("./projects", "", 50)
@@ -89,24 +119,54 @@ func (m SearchModel) Init() tea.Cmd {
 		if err != nil {
 			return countriesLoadedMsg{}
 		}
-		return countriesLoadedMsg{entries: bs.ListCountryEntries()}
+		return countriesLoadedMsg{store: bs, entries: bs.ListCountryEntries()}
 	}
 }
 
 func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case countriesLoadedMsg:
+		m.store = msg.store
 		m.countries = msg.entries
 		return m, nil
+	case regionsLoadedMsg:
+		// Ignore stale results if the country changed while loading
+		if msg.country == m.regionsFor {
+			m.regions = msg.entries
+			m.updateRegionSuggestions()
+		}
+		return m, nil
+	case planReadyMsg:
+		m.planning = false
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			return m, nil
+		}
+		m.preview = msg.preview
+		return m, nil
 	case tea.KeyMsg:
 		key := msg.String()
 
+		if m.planning {
+			return m, nil
+		}
+		if m.preview != nil {
+			switch key {
+			case "enter":
+				start := m.preview.msg
+				return m, func() tea.Msg { return start }
+			case "esc":
+				m.preview = nil
+			}
+			return m, nil
+		}
+
 		switch key {
 		case "esc":
 			return m, func() tea.Msg { return NavigateToHome{} }
 
 		case "up":
-			if m.focused == fieldCountry && len(m.suggestions) > 0 && m.suggIdx > 0 {
+			if m.suggestionCount() > 0 && m.suggIdx > 0 {
 				m.suggIdx--
 				return m, nil
 			}
@@ -114,7 +174,7 @@ func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.focusPrev()
 
 		case "down":
-			if m.focused == fieldCountry && len(m.suggestions) > 0 && m.suggIdx < len(m.suggestions)-1 {
+			if m.suggestionCount() > 0 && m.suggIdx < m.suggestionCount()-1 {
 				m.suggIdx++
 				return m, nil
 			}
@@ -123,7 +183,7 @@ func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "tab":
 			m.err = ""
-			if m.focused == fieldCountry && len(m.suggestions) > 0 {
+			if m.suggestionCount() > 0 {
 				m.selectSuggestion()
 			}
 			return m, m.focusNext()
@@ -133,7 +193,7 @@ func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.focusPrev()
 
 		case "enter":
-			if m.focused == fieldCountry && len(m.suggestions) > 0 {
+			if m.suggestionCount() > 0 {
 				m.selectSuggestion()
 				return m, m.focusNext()
 			}
@@ -143,13 +203,17 @@ func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "left":
 			if m.focused == fieldMode {
-				m.mode = modeCountry
+				if m.mode > modeCountry {
+					m.mode--
+				}
 				return m, nil
 			}
 
 		case "right":
 			if m.focused == fieldMode {
-				m.mode = modeCoords
+				if m.mode < modeArea {
+					m.mode++
+				}
 				return m, nil
 			}
 		}
@@ -161,18 +225,96 @@ func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.inputs[m.focused], cmd = m.inputs[m.focused].Update(msg)
 	}
 
-	// Update suggestions when typing in country field
-	if m.focused == fieldCountry {
+	// Update suggestions when typing in country or region field
+	switch m.focused {
+	case fieldCountry:
 		m.updateSuggestions()
+	case fieldRegion:
+		m.updateRegionSuggestions()
 	}
 
 	return m, cmd
 }
 
+// suggestionCount returns the number of suggestions shown for the focused field.
+func (m *SearchModel) suggestionCount() int {
+	switch m.focused {
+	case fieldCountry:
+		return len(m.suggestions)
+	case fieldRegion:
+		return len(m.regionSugg)
+	}
+	return 0
+}
+
 func (m *SearchModel) selectSuggestion() {
-	if m.suggIdx >= 0 && m.suggIdx < len(m.suggestions) {
-		m.inputs[fieldCountry].SetValue(m.suggestions[m.suggIdx].Name)
-		m.suggestions = nil
+	switch m.focused {
+	case fieldCountry:
+		if m.suggIdx >= 0 && m.suggIdx < len(m.suggestions) {
+			m.inputs[fieldCountry].SetValue(m.suggestions[m.suggIdx].Name)
+			m.suggestions = nil
+			m.suggIdx = -1
+		}
+	case fieldRegion:
+		if m.suggIdx >= 0 && m.suggIdx < len(m.regionSugg) {
+			m.inputs[fieldRegion].SetValue(m.regionSugg[m.suggIdx].Name)
+			m.regionSugg = nil
+			m.suggIdx = -1
+		}
+	}
+}
+
+// loadRegions fetches the admin-1 divisions of the selected country in the
+// background, once per country.
+func (m *SearchModel) loadRegions() tea.Cmd {
+	country, ok := m.resolveCountry(m.inputs[fieldCountry].Value())
+	if !ok || m.store == nil || country == m.regionsFor {
+		return nil
+	}
+	m.regionsFor = country
+	m.regions = nil
+	m.regionSugg = nil
+	bs := m.store
+	return func() tea.Msg {
+		return regionsLoadedMsg{country: country, entries: bs.ListRegionEntries(country)}
+	}
+}
+
+func (m *SearchModel) updateRegionSuggestions() {
+	if m.focused != fieldRegion {
+		return
+	}
+	raw := strings.TrimSpace(m.inputs[fieldRegion].Value())
+	if raw == "" {
+		m.regionSugg = nil
+		m.suggIdx = -1
+		return
+	}
+
+	q := normalize(raw)
+	var matches []geo.RegionEntry
+	for _, r := range m.regions {
+		if normalize(r.Name) == q {
+			// Exact match: nothing left to complete
+			m.regionSugg = nil
+			m.suggIdx = -1
+			return
+		}
+		if strings.Contains(normalize(r.Name), q) ||
+			(r.NameEN != "" && strings.Contains(normalize(r.NameEN), q)) ||
+			(r.NameES != "" && strings.Contains(normalize(r.NameES), q)) ||
+			strings.EqualFold(r.Code, raw) {
+			if len(matches) < 5 {
+				matches = append(matches, r)
+			}
+		}
+	}
+	m.regionSugg = matches
+	if len(matches) > 0 {
+		if m.suggIdx < 0 || m.suggIdx >= len(matches) {
+			m.suggIdx = 0
+		}
+	} else {
 		m.suggIdx = -1
 	}
 }
@@ -238,7 +380,7 @@ func (m *SearchModel) focusNext() tea.Cmd {
 		return nil
 	}
 	m.inputs[m.focused].Focus()
-	return textinput.Blink
+	return m.focusCmd()
 }
 
 func (m *SearchModel) focusPrev() tea.Cmd {
@@ -256,16 +398,29 @@ func (m *SearchModel) focusPrev() tea.Cmd {
 		return nil
 	}
 	m.inputs[m.focused].Focus()
+	return m.focusCmd()
+}
+
+// focusCmd returns the commands to run when a text field gains focus.
+func (m *SearchModel) focusCmd() tea.Cmd {
+	m.suggIdx = -1
+	if m.focused == fieldRegion {
+		return tea.Batch(textinput.Blink, m.loadRegions())
+	}
 	return textinput.Blink
 }
 
 func (m *SearchModel) skipField(idx, dir int) int {
 	for idx > fieldMode && idx < fieldCount {
-		if m.mode == modeCountry && (idx == fieldLat || idx == fieldLng || idx == fieldRadius) {
+		if m.mode == modeCountry && (idx == fieldLat || idx == fieldLng || idx == fieldRadius || idx == fieldArea) {
+			idx += dir
+			continue
+		}
+		if m.mode == modeCoords && (idx == fieldCountry || idx == fieldRegion || idx == fieldArea) {
 			idx += dir
 			continue
 		}
-		if m.mode == modeCoords && (idx == fieldCountry || idx == fieldRegion) {
+		if m.mode == modeArea && idx >= fieldCountry && idx <= fieldRadius {
 			idx += dir
 			continue
 		}
@@ -299,6 +454,11 @@ func (m *SearchModel) submit() tea.Cmd {
 			return nil
 		}
 		resolvedCountry = name
+	} else if m.mode == modeArea {
+		if strings.TrimSpace(m.inputs[fieldArea].Value()) == "" {
+			m.err = "Area file is required"
+			return nil
+		}
 	} else {
 		if strings.TrimSpace(m.inputs[fieldLat].Value()) == "" ||
 			strings.TrimSpace(m.inputs[fieldLng].Value()) == "" {
@@ -335,19 +495,37 @@ func (m *SearchModel) submit() tea.Cmd {
 		}
 	}
 
+	start := StartScanMsg{
+		Query:       query,
+		Mode:        m.mode,
+		Country:     resolvedCountry,
+		Region:      strings.TrimSpace(m.inputs[fieldRegion].Value()),
+		Lat:         strings.TrimSpace(m.inputs[fieldLat].Value()),
+		Lng:         strings.TrimSpace(m.inputs[fieldLng].Value()),
+		Radius:      strings.TrimSpace(m.inputs[fieldRadius].Value()),
+		AreaFile:    strings.TrimSpace(m.inputs[fieldArea].Value()),
+		Zoom:        zoomStr,
+		Concurrency: concStr,
+		Output:      output,
+	}
+
+	// Resolve the grid in the background and show a preview before scanning
+	m.planning = true
 	return func() tea.Msg {
-		return StartScanMsg{
-			Query:       query,
-			Mode:        m.mode,
-			Country:     resolvedCountry,
-			Region:      strings.TrimSpace(m.inputs[fieldRegion].Value()),
-			Lat:         strings.TrimSpace(m.inputs[fieldLat].Value()),
-			Lng:         strings.TrimSpace(m.inputs[fieldLng].Value()),
-			Radius:      strings.TrimSpace(m.inputs[fieldRadius].Value()),
-			Zoom:        zoomStr,
-			Concurrency: concStr,
-			Output:      output,
+		params := start.searchParams()
+		area, err := geo.ResolveArea(params)
+		if err != nil {
+			return planReadyMsg{err: err}
+		}
+		if len(area.Sectors) == 0 {
+			return planReadyMsg{err: fmt.Errorf("no sectors to process")}
 		}
+		start.Area = area
+		return planReadyMsg{preview: &scanPreview{
+			msg:      start,
+			params:   params,
+			estimate: scraper.EstimateScan(len(area.Sectors), params),
+		}}
 	}
 }
 
@@ -370,6 +548,11 @@ func (m SearchModel) View() string {
 			b.WriteString(m.renderSuggestions())
 		}
 		b.WriteString(m.renderField("Region:", fieldRegion))
+		if m.focused == fieldRegion && len(m.regionSugg) > 0 {
+			b.WriteString(m.renderRegionSuggestions())
+		}
+	} else if m.mode == modeArea {
+		b.WriteString(m.renderField("Area file:", fieldArea))
 	} else {
 		b.WriteString(m.renderField("Latitude:", fieldLat))
 		b.WriteString(m.renderField("Longitude:", fieldLng))
@@ -391,12 +574,57 @@ func (m SearchModel) View() string {
 		b.WriteString(styles.ErrorText.Render("  " + m.err))
 	}
 
+	if m.planning {
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(styles.Muted).Italic(true).Render("  Generating grid..."))
+	}
+
+	if m.preview != nil {
+		b.WriteString("\n")
+		b.WriteString(m.renderPreview())
+		b.WriteString("\n")
+		b.WriteString(styles.StatusBar.Render("enter start scan • esc edit"))
+		return styles.Border.Render(b.String())
+	}
+
 	b.WriteString("\n\n")
-	b.WriteString(styles.StatusBar.Render("enter start • tab next • esc back"))
+	b.WriteString(styles.StatusBar.Render("enter preview • tab next • esc back"))
 
 	return styles.Border.Render(b.String())
 }
 
+func (m SearchModel) renderPreview() string {
+	var sb strings.Builder
+	p := m.preview
+	area := p.msg.Area
+	est := p.estimate
+
+	statLabel := lipgloss.NewStyle().Foreground(styles.Muted).Width(12)
+	statVal := lipgloss.NewStyle().Foreground(styles.Text).Bold(true)
+	row := func(label, value string) {
+		sb.WriteString(statLabel.Render(label))
+		sb.WriteString(statVal.Render(value))
+		sb.WriteString("\n")
+	}
+
+	row("Zoom:", fmt.Sprintf("%d", p.params.Zoom))
+	row("Grid:", fmt.Sprintf("%d sectors", area.GridSize))
+	if area.Clip == geo.ClipAreaFile || area.Clip == geo.ClipRegion {
+		row("Inside:", fmt.Sprintf("%d (%.1f%% outside %s)", len(area.Sectors), 100*area.OceanRatio(), area.Clip))
+	} else if !p.params.IsCoordMode() {
+		row("Land:", fmt.Sprintf("%d (%.1f%% ocean removed)", len(area.Sectors), 100*area.OceanRatio()))
+	}
+	row("Jobs:", fmt.Sprintf("%d (%d queries)", est.Jobs, len(p.params.Queries)))
+	row("Requests:", fmt.Sprintf("%d", est.MinRequests))
+	row("ETA:", fmt.Sprintf("~%s (concurrency %d)", est.MinETA, p.params.Concurrency))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.Secondary).
+		Padding(0, 1).
+		Render(sb.String())
+}
+
 func (m SearchModel) renderSuggestions() string {
 	var sb strings.Builder
 	active := lipgloss.NewStyle().Foreground(styles.Primary).Bold(true)
@@ -420,22 +648,46 @@ func (m SearchModel) renderSuggestions() string {
 	return sb.String()
 }
 
+func (m SearchModel) renderRegionSuggestions() string {
+	var sb strings.Builder
+	active := lipgloss.NewStyle().Foreground(styles.Primary).Bold(true)
+	inactive := lipgloss.NewStyle().Foreground(styles.Muted)
+
+	for i, r := range m.regionSugg {
+		label := r.Name
+		if r.NameEN != "" && r.NameEN != r.Name {
+			label += " · " + r.NameEN
+		}
+		if r.Type != "" {
+			label += " (" + r.Type + ")"
+		}
+		if i == m.suggIdx {
+			sb.WriteString(active.Render("  > " + label))
+		} else {
+			sb.WriteString(inactive.Render("    " + label))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
 func (m SearchModel) renderMode() string {
 	label := styles.Label.Render("Mode:")
 
 	active := lipgloss.NewStyle().Foreground(styles.Primary).Bold(true)
 	inactive := lipgloss.NewStyle().Foreground(styles.Muted)
 
-	var countryStr, coordsStr string
-	if m.mode == modeCountry {
-		countryStr = active.Render("< Country >")
-		coordsStr = inactive.Render("Coordinates")
-	} else {
-		countryStr = inactive.Render("Country")
-		coordsStr = active.Render("< Coordinates >")
+	names := []string{"Country", "Coordinates", "Area"}
+	parts := make([]string, len(names))
+	for i, name := range names {
+		if searchMode(i) == m.mode {
+			parts[i] = active.Render("< " + name + " >")
+		} else {
+			parts[i] = inactive.Render(name)
+		}
 	}
 
-	line := fmt.Sprintf("%s  %s   %s", label, countryStr, coordsStr)
+	line := fmt.Sprintf("%s  %s", label, strings.Join(parts, "   "))
 
 	if m.focused == fieldMode {
 		indicator := lipgloss.NewStyle().Foreground(styles.Secondary).Render(" ←→")
@@ -462,7 +714,54 @@ type StartScanMsg struct {
 	Lat         string
 	Lng         string
 	Radius      string
+	AreaFile    string
 	Zoom        string
 	Concurrency string
 	Output      string
+	Area        *geo.Area // resolved by the preview step
+}
+
+// searchParams converts the form values into search parameters.
+func (msg StartScanMsg) searchParams() model.SearchParams {
+	var params model.SearchParams
+
+	params.Queries = strings.Split(msg.Query, ",")
+	for i := range params.Queries {
+		params.Queries[i] = strings.TrimSpace(params.Queries[i])
+	}
+
+	switch msg.Mode {
+	case modeCountry:
+		params.Country = msg.Country
+		params.Region = msg.Region
+	case modeArea:
+		params.AreaFile = msg.AreaFile
+		if abs, err := filepath.Abs(msg.AreaFile); err == nil {
+			params.AreaFile = abs
+		}
+	default:
+		params.Lat, _ = strconv.ParseFloat(msg.Lat, 64)
+		params.Lng, _ = strconv.ParseFloat(msg.Lng, 64)
+		params.Radius, _ = strconv.ParseFloat(msg.Radius, 64)
+	}
+
+	params.Zoom, _ = strconv.Atoi(msg.Zoom)
+	if params.Zoom == 0 {
+		switch {
+		case params.IsCoordMode():
+			params.Zoom = 13
+		case params.AreaFile != "":
+			params.Zoom = 12
+		default:
+			params.Zoom = 10
+		}
+	}
+	params.Concurrency, _ = strconv.Atoi(msg.Concurrency)
+	if params.Concurrency <= 0 {
+		params.Concurrency = 50
+	}
+	params.MaxPages = 1
+	params.Lang = "en"
+
+	return params
 }